@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/provider"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	prompts "github.com/krishkalaria12/nyron-ai-cli/config/prompts"
+	"github.com/krishkalaria12/nyron-ai-cli/memory"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// DefaultMaxIterations caps the model calls of one run when nothing else is configured
+const DefaultMaxIterations = 50
+
+// ErrMaxIterations ends a run whose model kept asking for tools
+var ErrMaxIterations = errors.New("stopped after reaching the maximum number of model calls for one message")
+
+// streamFunc makes one streamed model call, ai.Stream outside of tests
+type streamFunc func(ctx context.Context, model config.SelectedModel, messages []openrouter.ChatCompletionMessage) (<-chan provider.StreamMessage, error)
+
+// Agent runs the loop of model calls and tool calls that answers a message
+type Agent struct {
+	permissions   *permission.Manager
+	maxIterations int
+	stream        streamFunc
+}
+
+// New returns an agent that checks tool calls against permissions and makes at most maxIterations
+// model calls per run; zero or less uses DefaultMaxIterations
+func New(permissions *permission.Manager, maxIterations int) *Agent {
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	return &Agent{permissions: permissions, maxIterations: maxIterations, stream: ai.Stream}
+}
+
+// NewFromConfig returns an agent with the permissions from the config and the limit from NYRON_MAX_ITERATIONS
+func NewFromConfig() *Agent {
+	return New(permission.NewManagerFromConfig(), config.MaxIterations())
+}
+
+// run is the state of one Run
+type run struct {
+	agent   *Agent
+	ctx     context.Context
+	session *session.Session
+	model   config.SelectedModel // Taken from the session when the run starts
+	events  chan<- Event
+}
+
+// Run answers input in the session with s.Model, running the tools the model asks for until it gives
+// a final answer. An empty input continues from the history as it is, e.g. to retry the last message.
+//
+// The run owns s.History until it ends: messages, tool results and, on cancellation, the partial answer
+// are added to it. The returned channel must be read until it is closed, the last event is always Done.
+func (a *Agent) Run(ctx context.Context, s *session.Session, input string) <-chan Event {
+	events := make(chan Event, 64)
+	r := &run{agent: a, ctx: ctx, session: s, model: s.Model, events: events}
+
+	go func() {
+		defer close(events)
+		events <- r.loop(input)
+	}()
+	return events
+}
+
+// emit sends an event unless the run was cancelled; Done is sent separately and always arrives
+func (r *run) emit(event Event) {
+	select {
+	case r.events <- event:
+	case <-r.ctx.Done():
+	}
+}
+
+// SystemPrompt returns the prompt a conversation starts with: the instructions with a description of the
+// environment, followed by the memory files of the workspace
+func SystemPrompt() string {
+	root := tools.CurrentWorkspace().Root()
+	prompt := prompts.GetPrompts("", "openrouter", prompts.CollectEnvironment(root)).SystemPrompt
+	// A memory file that can't be read is left out rather than failing the conversation
+	files, _ := memory.Discover(root)
+	if section := memory.Prompt(files); section != "" {
+		prompt += "\n\n" + section
+	}
+	return prompt
+}
+
+func (r *run) loop(input string) Done {
+	if input != "" {
+		// A new conversation starts with the system prompt
+		if len(r.session.History) == 0 {
+			r.session.History = append(r.session.History, openrouter.ChatCompletionMessage{
+				Role:    openrouter.ChatMessageRoleSystem,
+				Content: openrouter.Content{Text: SystemPrompt()},
+			})
+		}
+		r.session.History = append(r.session.History, openrouter.ChatCompletionMessage{
+			Role:    openrouter.ChatMessageRoleUser,
+			Content: openrouter.Content{Text: input},
+		})
+	}
+
+	var total openrouter.Usage
+	for iteration := 0; ; iteration++ {
+		if iteration >= r.agent.maxIterations {
+			return Done{Usage: total, Err: fmt.Errorf("%w (%d)", ErrMaxIterations, r.agent.maxIterations)}
+		}
+
+		if usage := r.fitContext(); usage != nil {
+			addUsage(&total, *usage)
+			r.emit(Usage{Usage: *usage})
+		}
+
+		message, usage, err := r.stream()
+		if usage != nil {
+			addUsage(&total, *usage)
+			r.emit(Usage{Usage: *usage})
+		}
+		if err != nil {
+			if r.ctx.Err() != nil && message.Content.Text != "" {
+				// Keep what was said before the user stopped it, so the model knows on the next message
+				r.session.History = append(r.session.History, openrouter.ChatCompletionMessage{
+					Role:    openrouter.ChatMessageRoleAssistant,
+					Content: message.Content,
+				})
+			}
+			return Done{Usage: total, Err: err}
+		}
+
+		r.session.History = append(r.session.History, message)
+		r.emit(MessageDone{Message: message})
+		if len(message.ToolCalls) == 0 {
+			return Done{Message: message, Usage: total}
+		}
+
+		r.session.History = append(r.session.History, r.runTools(message.ToolCalls)...)
+		if r.ctx.Err() != nil {
+			return Done{Usage: total, Err: r.ctx.Err()}
+		}
+	}
+}
+
+// stream makes one model call and forwards the deltas. On error the message holds what arrived so far.
+func (r *run) stream() (openrouter.ChatCompletionMessage, *openrouter.Usage, error) {
+	chunks, err := r.agent.stream(r.ctx, r.model, r.session.History)
+	if err != nil {
+		return openrouter.ChatCompletionMessage{}, nil, err
+	}
+
+	var acc provider.StreamAccumulator
+	for {
+		var chunk provider.StreamMessage
+		var ok bool
+		select {
+		case chunk, ok = <-chunks:
+		case <-r.ctx.Done():
+			return acc.Message(), acc.Usage, r.ctx.Err()
+		}
+		if !ok {
+			if r.ctx.Err() != nil {
+				return acc.Message(), acc.Usage, r.ctx.Err()
+			}
+			return acc.Message(), acc.Usage, nil
+		}
+		if chunk.Error != nil {
+			return acc.Message(), acc.Usage, chunk.Error
+		}
+
+		acc.Add(chunk)
+		if chunk.Done {
+			return acc.Message(), acc.Usage, nil
+		}
+
+		if chunk.Content != "" {
+			r.emit(TextDelta{Text: chunk.Content})
+		}
+		if chunk.Reasoning != "" {
+			r.emit(ReasoningDelta{Text: chunk.Reasoning})
+		}
+		if len(chunk.ToolCalls) > 0 {
+			r.emit(ToolCallDelta{Calls: append([]openrouter.ToolCall(nil), acc.ToolCalls()...)})
+		}
+	}
+}
+
+// addUsage adds the usage of one model call to the running total
+func addUsage(total *openrouter.Usage, usage openrouter.Usage) {
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+	total.Cost += usage.Cost
+}
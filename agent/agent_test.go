@@ -0,0 +1,265 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/provider"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+var testModel = config.SelectedModel{Provider: "openrouter", Model: "test/model"}
+
+// fakeProvider answers each model call with the next of its scripted replies, repeating the last one
+type fakeProvider struct {
+	mu      sync.Mutex
+	replies [][]provider.StreamMessage
+	calls   int
+	// block keeps the stream open after the chunks of a reply until the context ends, like a model still answering
+	block bool
+}
+
+func (p *fakeProvider) stream(ctx context.Context, model config.SelectedModel, messages []openrouter.ChatCompletionMessage) (<-chan provider.StreamMessage, error) {
+	p.mu.Lock()
+	reply := p.replies[min(p.calls, len(p.replies)-1)]
+	p.calls++
+	block := p.block
+	p.mu.Unlock()
+
+	chunks := make(chan provider.StreamMessage)
+	go func() {
+		defer close(chunks)
+		for _, chunk := range reply {
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if block {
+			<-ctx.Done()
+		}
+	}()
+	return chunks, nil
+}
+
+func (p *fakeProvider) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+// textReply is a reply with a final answer
+func textReply(text string) []provider.StreamMessage {
+	return []provider.StreamMessage{
+		{Content: text},
+		{Done: true, Usage: &openrouter.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+	}
+}
+
+// toolReply is a reply that calls tools, given as name and arguments pairs
+func toolReply(calls ...[2]string) []provider.StreamMessage {
+	var deltas []openrouter.ToolCall
+	for i, call := range calls {
+		index := i
+		deltas = append(deltas, openrouter.ToolCall{
+			Index:    &index,
+			ID:       fmt.Sprintf("call_%d", i),
+			Type:     openrouter.ToolTypeFunction,
+			Function: openrouter.FunctionCall{Name: call[0], Arguments: call[1]},
+		})
+	}
+	return []provider.StreamMessage{
+		{ToolCalls: deltas},
+		{Done: true, Usage: &openrouter.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+	}
+}
+
+// newTestAgent returns an agent that streams from fake, with the policies given per tool
+func newTestAgent(fake *fakeProvider, maxIterations int, policies map[string]permission.Policy) *Agent {
+	a := New(permission.NewManager(policies), maxIterations)
+	a.stream = fake.stream
+	return a
+}
+
+// newTestSession returns a session that already has a system prompt, so Run doesn't build one
+func newTestSession() *session.Session {
+	s := session.New(testModel)
+	s.History = []openrouter.ChatCompletionMessage{{
+		Role:    openrouter.ChatMessageRoleSystem,
+		Content: openrouter.Content{Text: "You are a test."},
+	}}
+	return s
+}
+
+// collect reads every event of a run, answering approval requests with approve, and returns them
+func collect(t *testing.T, events <-chan Event, approve func(ApprovalRequested)) []Event {
+	t.Helper()
+	var all []Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return all
+			}
+			all = append(all, event)
+			if request, isRequest := event.(ApprovalRequested); isRequest && approve != nil {
+				approve(request)
+			}
+		case <-timeout:
+			t.Fatalf("the run didn't end, events so far: %v", eventNames(all))
+		}
+	}
+}
+
+// lastDone returns the Done event, which must be the last one
+func lastDone(t *testing.T, events []Event) Done {
+	t.Helper()
+	if len(events) == 0 {
+		t.Fatal("the run sent no events")
+	}
+	done, ok := events[len(events)-1].(Done)
+	if !ok {
+		t.Fatalf("the last event is %T, want Done", events[len(events)-1])
+	}
+	return done
+}
+
+func eventNames(events []Event) []string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, reflect.TypeOf(event).Name())
+	}
+	return names
+}
+
+func TestRunAnswers(t *testing.T) {
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{textReply("Hello!")}}
+	s := newTestSession()
+
+	events := collect(t, newTestAgent(fake, 0, nil).Run(context.Background(), s, "Hi"), nil)
+	done := lastDone(t, events)
+	if done.Err != nil {
+		t.Fatalf("Done.Err = %v", done.Err)
+	}
+	if done.Message.Content.Text != "Hello!" {
+		t.Errorf("answer = %q, want Hello!", done.Message.Content.Text)
+	}
+	if done.Usage.TotalTokens != 15 {
+		t.Errorf("usage = %d tokens, want 15", done.Usage.TotalTokens)
+	}
+
+	roles := []string{}
+	for _, message := range s.History {
+		roles = append(roles, message.Role)
+	}
+	if want := []string{"system", "user", "assistant"}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("history roles = %q, want %q", roles, want)
+	}
+}
+
+func TestRunStopsAtMaxIterations(t *testing.T) {
+	// The model asks for a tool every time and never answers
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{toolReply([2]string{readTool, `{"id":"a"}`})}}
+	registerTestTools(t, nil)
+
+	events := collect(t, newTestAgent(fake, 3, nil).Run(context.Background(), newTestSession(), "Loop"), nil)
+	done := lastDone(t, events)
+	if !errors.Is(done.Err, ErrMaxIterations) {
+		t.Fatalf("Done.Err = %v, want ErrMaxIterations", done.Err)
+	}
+	if fake.callCount() != 3 {
+		t.Errorf("the model was called %d times, want 3", fake.callCount())
+	}
+	if done.Usage.TotalTokens != 45 {
+		t.Errorf("usage = %d tokens, want the 3 calls summed to 45", done.Usage.TotalTokens)
+	}
+}
+
+func TestRunEventOrder(t *testing.T) {
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{
+		toolReply([2]string{readTool, `{"id":"a"}`}),
+		textReply("Done reading"),
+	}}
+	registerTestTools(t, nil)
+
+	events := collect(t, newTestAgent(fake, 0, nil).Run(context.Background(), newTestSession(), "Read a"), nil)
+	var order []string
+	for _, name := range eventNames(events) {
+		switch name {
+		case "MessageDone", "ToolCallRequested", "ToolResult", "Done":
+			order = append(order, name)
+		}
+	}
+	want := []string{"MessageDone", "ToolCallRequested", "ToolResult", "MessageDone", "Done"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("events = %q, want %q", order, want)
+	}
+	if done := lastDone(t, events); done.Err != nil || done.Message.Content.Text != "Done reading" {
+		t.Errorf("Done = %+v, want the final answer", done)
+	}
+}
+
+func TestRunCancelledWhileStreaming(t *testing.T) {
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{{{Content: "Partial"}}}, block: true}
+	s := newTestSession()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var all []Event
+	for event := range newTestAgent(fake, 0, nil).Run(ctx, s, "Tell me") {
+		all = append(all, event)
+		if _, ok := event.(TextDelta); ok {
+			cancel()
+		}
+	}
+	done := lastDone(t, all)
+	if !errors.Is(done.Err, context.Canceled) {
+		t.Fatalf("Done.Err = %v, want context.Canceled", done.Err)
+	}
+	last := s.History[len(s.History)-1]
+	if last.Role != openrouter.ChatMessageRoleAssistant || last.Content.Text != "Partial" {
+		t.Errorf("last message = %s %q, want the partial answer kept", last.Role, last.Content.Text)
+	}
+}
+
+func TestRunCancelledWhileWaitingForApproval(t *testing.T) {
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{
+		toolReply([2]string{writeTool, `{"id":"1"}`}, [2]string{writeTool, `{"id":"2"}`}),
+		textReply("never sent"),
+	}}
+	ran := registerTestTools(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newTestSession()
+	// Ask for every write, then stop instead of answering
+	events := collect(t, newTestAgent(fake, 0, nil).Run(ctx, s, "Write"), func(ApprovalRequested) { cancel() })
+	done := lastDone(t, events)
+	if !errors.Is(done.Err, context.Canceled) {
+		t.Fatalf("Done.Err = %v, want context.Canceled", done.Err)
+	}
+	if fake.callCount() != 1 {
+		t.Errorf("the model was called %d times after the cancellation, want no more calls", fake.callCount()-1)
+	}
+	if calls := ran.calls(); len(calls) > 0 {
+		t.Errorf("tools ran after the cancellation: %q", calls)
+	}
+
+	// Every call still gets a result, so the history stays valid for the next message
+	results := s.History[len(s.History)-2:]
+	for i, result := range results {
+		if result.Role != openrouter.ChatMessageRoleTool || result.ToolCallID != fmt.Sprintf("call_%d", i) {
+			t.Errorf("message %d = %s for %q, want the tool result of call_%d", i, result.Role, result.ToolCallID, i)
+		}
+	}
+}
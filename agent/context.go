@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	prompts "github.com/krishkalaria12/nyron-ai-cli/config/prompts"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+const (
+	// compactThreshold is the share of the context window the history may fill before it is compacted
+	compactThreshold = 0.8
+	// keepRecentTurns is how many of the last user messages are kept verbatim, with everything after them
+	keepRecentTurns = 2
+	// staleToolOutputChars is how much of an old tool result survives truncation
+	staleToolOutputChars = 500
+	// summaryMessageChars caps each message in the transcript sent for summarizing
+	summaryMessageChars = 4000
+	// charsPerToken is the rough ratio used to estimate tokens without a tokenizer
+	charsPerToken = 4
+	// messageOverheadTokens approximates the role and formatting tokens added to each message
+	messageOverheadTokens = 4
+)
+
+const (
+	summaryPrefix   = "Summary of the earlier conversation:\n\n"
+	truncatedNotice = "\n[... output truncated to save context, run the tool again if you need it]"
+)
+
+// ErrNothingToCompact is returned by Compact when the history has no earlier turns to summarize
+var ErrNothingToCompact = errors.New("nothing to compact yet, the conversation only has recent turns")
+
+// EstimateTokens approximates how many tokens the messages take in a request
+func EstimateTokens(messages []openrouter.ChatCompletionMessage) int {
+	chars := 0
+	for _, message := range messages {
+		chars += len(message.Content.Text)
+		for _, part := range message.Content.Multi {
+			chars += len(part.Text)
+		}
+		for _, call := range message.ToolCalls {
+			chars += len(call.Function.Name) + len(call.Function.Arguments)
+		}
+	}
+	return chars/charsPerToken + len(messages)*messageOverheadTokens
+}
+
+// Compact summarizes the turns before the most recent ones and truncates the tool output in them,
+// whatever the size of the history. It must not be called while a run uses the session.
+// The result carries the usage of the summary request also when it failed.
+func (a *Agent) Compact(ctx context.Context, s *session.Session) (Compacted, error) {
+	history, result, err := compact(ctx, s.Model, s.History, 0, true)
+	if err != nil {
+		return result, err
+	}
+	s.History = history
+	return result, nil
+}
+
+// fitContext compacts the history when it comes close to the context window of the model and returns the
+// usage of the summary request. A failed summary isn't fatal: the truncated history is used, and the model
+// call reports the problem if it still doesn't fit.
+func (r *run) fitContext() *openrouter.Usage {
+	limit := int(float64(config.ContextWindow(r.model)) * compactThreshold)
+	if EstimateTokens(r.session.History) <= limit {
+		return nil
+	}
+
+	history, result, _ := compact(r.ctx, r.model, r.session.History, limit, false)
+	r.session.History = history
+	if result.Before != result.After {
+		r.emit(result)
+	}
+	return result.Usage
+}
+
+// compact shrinks history until its estimate is at most target: first the output of old tool calls is
+// truncated, then the old turns are summarized, then the output of tool calls in the recent turns is truncated.
+// With force the old turns are summarized in any case.
+func compact(ctx context.Context, model config.SelectedModel, history []openrouter.ChatCompletionMessage, target int, force bool) ([]openrouter.ChatCompletionMessage, Compacted, error) {
+	result := Compacted{Before: EstimateTokens(history)}
+	history = append([]openrouter.ChatCompletionMessage(nil), history...)
+	head := systemPromptEnd(history)
+	recent := recentTurnsStart(history, head)
+
+	result.TruncatedOutputs += truncateToolOutputs(history[head:recent])
+	fits := func() bool { return !force && EstimateTokens(history) <= target }
+
+	if !fits() {
+		if recent > head {
+			summary, usage, err := summarize(ctx, model, history[head:recent])
+			result.Usage = usage
+			if err != nil {
+				result.After = EstimateTokens(history)
+				return history, result, fmt.Errorf("summarizing the conversation: %w", err)
+			}
+			compacted := append([]openrouter.ChatCompletionMessage(nil), history[:head]...)
+			compacted = append(compacted, openrouter.ChatCompletionMessage{
+				Role:    openrouter.ChatMessageRoleSystem,
+				Content: openrouter.Content{Text: summaryPrefix + summary},
+			})
+			result.SummarizedMessages = recent - head
+			history = append(compacted, history[recent:]...)
+			recent = head + 1
+		} else if force {
+			return history, result, ErrNothingToCompact
+		}
+	}
+
+	if last := lastAssistantMessage(history); !force && !fits() && last > recent {
+		// One long turn: keep only the results the model hasn't answered yet
+		result.TruncatedOutputs += truncateToolOutputs(history[recent:last])
+	}
+
+	result.After = EstimateTokens(history)
+	return history, result, nil
+}
+
+// systemPromptEnd returns the index after the leading system prompt; earlier summaries aren't part of it
+func systemPromptEnd(history []openrouter.ChatCompletionMessage) int {
+	end := 0
+	for end < len(history) && history[end].Role == openrouter.ChatMessageRoleSystem && !isSummary(history[end]) {
+		end++
+	}
+	return end
+}
+
+// recentTurnsStart returns the index of the oldest user message that is kept verbatim
+func recentTurnsStart(history []openrouter.ChatCompletionMessage, head int) int {
+	turns := 0
+	for i := len(history) - 1; i >= head; i-- {
+		if history[i].Role == openrouter.ChatMessageRoleUser {
+			turns++
+			if turns == keepRecentTurns {
+				return i
+			}
+		}
+	}
+	return head
+}
+
+// lastAssistantMessage returns the index of the last assistant message, or 0 when there is none
+func lastAssistantMessage(history []openrouter.ChatCompletionMessage) int {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role == openrouter.ChatMessageRoleAssistant {
+			return i
+		}
+	}
+	return 0
+}
+
+func isSummary(message openrouter.ChatCompletionMessage) bool {
+	return strings.HasPrefix(message.Content.Text, summaryPrefix)
+}
+
+// truncateToolOutputs shortens long tool results in place and returns how many it shortened
+func truncateToolOutputs(messages []openrouter.ChatCompletionMessage) int {
+	truncated := 0
+	for i, message := range messages {
+		text := message.Content.Text
+		if message.Role != openrouter.ChatMessageRoleTool || len(text) <= 2*staleToolOutputChars || strings.HasSuffix(text, truncatedNotice) {
+			continue
+		}
+		messages[i].Content = openrouter.Content{Text: cutText(text, staleToolOutputChars) + truncatedNotice}
+		truncated++
+	}
+	return truncated
+}
+
+// cutText returns at most n bytes of text without splitting a character
+func cutText(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+// summarize asks the model for a summary of the messages. The transcript it sends is cut to half the
+// context window, dropping the oldest part, so the summary request itself fits.
+func summarize(ctx context.Context, model config.SelectedModel, messages []openrouter.ChatCompletionMessage) (string, *openrouter.Usage, error) {
+	var transcript strings.Builder
+	for _, message := range messages {
+		text := message.Content.Text
+		if isSummary(message) {
+			text = strings.TrimPrefix(text, summaryPrefix)
+		}
+		if len(text) > summaryMessageChars {
+			text = cutText(text, summaryMessageChars) + "\n[...]"
+		}
+
+		fmt.Fprintf(&transcript, "## %s\n", message.Role)
+		if text != "" {
+			transcript.WriteString(text + "\n")
+		}
+		for _, call := range message.ToolCalls {
+			fmt.Fprintf(&transcript, "Tool call: %s %s\n", call.Function.Name, call.Function.Arguments)
+		}
+		transcript.WriteString("\n")
+	}
+
+	text := transcript.String()
+	if maxChars := config.ContextWindow(model) / 2 * charsPerToken; len(text) > maxChars {
+		start := len(text) - maxChars
+		for start < len(text) && !utf8.RuneStart(text[start]) {
+			start++
+		}
+		text = "[earlier messages omitted]\n\n" + text[start:]
+	}
+
+	response, err := ai.Complete(ctx, model, []openrouter.ChatCompletionMessage{
+		{Role: openrouter.ChatMessageRoleSystem, Content: openrouter.Content{Text: prompts.GetCompactPrompt()}},
+		{Role: openrouter.ChatMessageRoleUser, Content: openrouter.Content{Text: text}},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	summary := strings.TrimSpace(response.Message.Content.Text)
+	if summary == "" {
+		return "", response.Usage, errors.New("the model returned an empty summary")
+	}
+	return summary, response.Usage, nil
+}
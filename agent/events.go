@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// Event is something that happened during a run, one of the types below
+type Event interface {
+	isEvent()
+}
+
+// TextDelta is a piece of the answer as it streams in
+type TextDelta struct {
+	Text string
+}
+
+// ReasoningDelta is a piece of the model's reasoning as it streams in
+type ReasoningDelta struct {
+	Text string
+}
+
+// ToolCallDelta carries the tool calls streamed so far; arguments may still be incomplete
+type ToolCallDelta struct {
+	Calls []openrouter.ToolCall
+}
+
+// MessageDone is a complete assistant message, already added to the session history
+type MessageDone struct {
+	Message openrouter.ChatCompletionMessage
+}
+
+// ToolCallRequested is sent for each tool call of a message before it is checked against the policies
+type ToolCallRequested struct {
+	Index int // Position of the call in the message
+	Call  openrouter.ToolCall
+}
+
+// ApprovalRequested asks whether a tool call may run. The run waits until Respond is called or the context ends.
+type ApprovalRequested struct {
+	Index        int
+	Call         openrouter.ToolCall
+	OutsidePaths []string // Paths outside the workspace the call would use
+	Dangerous    bool     // A shell command that could destroy data or change the system
+	reply        chan Approval
+}
+
+// Approval answers an ApprovalRequested
+type Approval struct {
+	Decision permission.Decision
+	Feedback string // Sent to the model with DenyWithFeedback
+}
+
+// Respond answers the request; only the first answer counts
+func (e ApprovalRequested) Respond(approval Approval) {
+	select {
+	case e.reply <- approval:
+	default:
+	}
+}
+
+// ToolOutput is output a running tool streamed, e.g. a command's stdout
+type ToolOutput struct {
+	Index int
+	Call  openrouter.ToolCall
+	Chunk string
+}
+
+// ToolResult is the result of a tool call as sent back to the model
+type ToolResult struct {
+	Index  int
+	Call   openrouter.ToolCall
+	Result string
+	Denied bool // The call didn't run because of a policy or the user's answer
+}
+
+// Usage is the token usage of one model call
+type Usage struct {
+	Usage openrouter.Usage
+}
+
+// Compacted tells that the history was shrunk to fit the context window of the model
+type Compacted struct {
+	Before             int               // Estimated tokens of the history before
+	After              int               // And after
+	SummarizedMessages int               // Messages replaced by a summary, 0 when only tool output was truncated
+	TruncatedOutputs   int               // Tool results that were shortened
+	Usage              *openrouter.Usage // Of the summary request, if one was made and the provider reported it
+}
+
+// Done is the last event of a run. Err is set when the run failed, was cancelled or hit the iteration limit.
+type Done struct {
+	Message openrouter.ChatCompletionMessage // The final answer, empty when Err is set
+	Usage   openrouter.Usage                 // Summed over all model calls of the run
+	Err     error
+}
+
+func (TextDelta) isEvent()         {}
+func (ReasoningDelta) isEvent()    {}
+func (ToolCallDelta) isEvent()     {}
+func (MessageDone) isEvent()       {}
+func (ToolCallRequested) isEvent() {}
+func (ApprovalRequested) isEvent() {}
+func (ToolOutput) isEvent()        {}
+func (ToolResult) isEvent()        {}
+func (Usage) isEvent()             {}
+func (Compacted) isEvent()         {}
+func (Done) isEvent()              {}
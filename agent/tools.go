@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// maxParallelTools bounds how many read-only tool calls run at the same time
+const maxParallelTools = 4
+
+// decision is what happens to one tool call
+type decision struct {
+	run     bool
+	result  string   // The result sent to the model for calls that don't run
+	granted []string // Paths outside the workspace the call may use
+}
+
+// runTools checks every call against the policies, asking where they require it, then runs the allowed
+// calls. It returns one tool result per call in the order of the calls, also for denied and cancelled calls.
+func (r *run) runTools(calls []openrouter.ToolCall) []openrouter.ChatCompletionMessage {
+	decisions := make([]decision, len(calls))
+	for i, call := range calls {
+		r.emit(ToolCallRequested{Index: i, Call: call})
+		if r.ctx.Err() != nil {
+			decisions[i] = decision{result: tools.CancelledResponse()}
+			continue
+		}
+		decisions[i] = r.decide(i, call)
+	}
+
+	// Calls that only read, and denied calls, run side by side; calls that change things run alone and in order
+	results := make([]string, len(calls))
+	for start := 0; start < len(calls); {
+		end := start + 1
+		if runsInParallel(calls[start], decisions[start]) {
+			for end < len(calls) && runsInParallel(calls[end], decisions[end]) {
+				end++
+			}
+		}
+		r.executeBatch(calls, decisions, results, start, end)
+		start = end
+	}
+
+	messages := make([]openrouter.ChatCompletionMessage, 0, len(calls))
+	for i, call := range calls {
+		messages = append(messages, openrouter.ChatCompletionMessage{
+			Role:       openrouter.ChatMessageRoleTool,
+			Content:    openrouter.Content{Text: results[i]},
+			ToolCallID: call.ID,
+		})
+	}
+	return messages
+}
+
+// runsInParallel reports whether a call may run at the same time as others
+func runsInParallel(call openrouter.ToolCall, d decision) bool {
+	return !d.run || tools.IsReadOnly(call.Function.Name)
+}
+
+// executeBatch runs calls[start:end] on up to maxParallelTools workers and reports each result as it finishes
+func (r *run) executeBatch(calls []openrouter.ToolCall, decisions []decision, results []string, start int, end int) {
+	workers := make(chan struct{}, maxParallelTools)
+	var wg sync.WaitGroup
+	for i := start; i < end; i++ {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+			results[i] = r.execute(i, calls[i], decisions[i])
+		}()
+	}
+	wg.Wait()
+}
+
+// execute runs one call if it was allowed and reports its result
+func (r *run) execute(index int, call openrouter.ToolCall, d decision) string {
+	result := d.result
+	if d.run {
+		ctx := tools.WithGrantedPaths(r.ctx, d.granted)
+		ctx = tools.WithOutputHandler(ctx, func(chunk string) {
+			r.emit(ToolOutput{Index: index, Call: call, Chunk: chunk})
+		})
+		result = tools.ExecuteTool(ctx, call.Function.Name, call.Function.Arguments)
+	}
+
+	r.emit(ToolResult{Index: index, Call: call, Result: result, Denied: !d.run})
+	return result
+}
+
+// decide applies the permission policies to a call and asks for approval when they say so
+func (r *run) decide(index int, call openrouter.ToolCall) decision {
+	permissions := r.agent.permissions
+	policy := toolPolicy(permissions, call)
+
+	var granted []string
+	outside := tools.CurrentWorkspace().OutsidePaths(r.ctx, call.Function.Name, call.Function.Arguments)
+	if policy != permission.PolicyDeny && len(outside) > 0 {
+		switch permissions.CheckOutsideRoot() {
+		case permission.PolicyAllow:
+			granted = outside
+		case permission.PolicyAsk:
+			// Leaving the workspace needs an answer even from tools that normally run without asking
+			policy = permission.PolicyAsk
+		}
+		// With the deny policy the call runs and the tool refuses the path itself
+	}
+
+	switch policy {
+	case permission.PolicyAllow:
+		return decision{run: true, granted: granted}
+	case permission.PolicyDeny:
+		return decision{result: tools.DeniedResponse("This tool is disabled by the user's permission settings")}
+	}
+
+	request := ApprovalRequested{
+		Index:        index,
+		Call:         call,
+		OutsidePaths: outside,
+		Dangerous:    call.Function.Name == permission.RunCommandTool && permission.IsDangerousCommand(commandOf(call)),
+		reply:        make(chan Approval, 1),
+	}
+	r.emit(request)
+
+	var approval Approval
+	select {
+	case approval = <-request.reply:
+	case <-r.ctx.Done():
+		return decision{result: tools.CancelledResponse()}
+	}
+
+	if call.Function.Name == permission.RunCommandTool {
+		permissions.RecordCommand(commandOf(call), approval.Decision)
+	} else {
+		permissions.Record(call.Function.Name, approval.Decision)
+	}
+	switch approval.Decision {
+	case permission.AllowOnce:
+		return decision{run: true, granted: outside}
+	case permission.AllowSession:
+		tools.CurrentWorkspace().Grant(outside...)
+		return decision{run: true}
+	case permission.DenyWithFeedback:
+		return decision{result: tools.DeniedResponse(approval.Feedback)}
+	default:
+		return decision{result: tools.DeniedResponse("")}
+	}
+}
+
+// toolPolicy returns the policy for a call; shell commands are checked one by one against the allow-list
+func toolPolicy(permissions *permission.Manager, call openrouter.ToolCall) permission.Policy {
+	if call.Function.Name == permission.RunCommandTool {
+		return permissions.CheckCommand(commandOf(call))
+	}
+	return permissions.Check(call.Function.Name)
+}
+
+// commandOf returns the shell command of a run_command call
+func commandOf(call openrouter.ToolCall) string {
+	params := tools.RunCommandParams{}
+	_ = json.Unmarshal([]byte(call.Function.Arguments), &params)
+	return params.Command
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/provider"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+const (
+	readTool  = "test_read"
+	writeTool = "test_write"
+)
+
+type testParams struct {
+	ID string `json:"id"`
+}
+
+// toolRecorder remembers the calls of the test tools and how many ran at the same time
+type toolRecorder struct {
+	mu        sync.Mutex
+	ran       []string
+	active    int
+	maxActive int
+	// Writes that ran while another call was running
+	overlappingWrites []string
+}
+
+func (r *toolRecorder) start(name string, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ran = append(r.ran, name+":"+id)
+	r.active++
+	r.maxActive = max(r.maxActive, r.active)
+	if name == writeTool && r.active > 1 {
+		r.overlappingWrites = append(r.overlappingWrites, id)
+	}
+}
+
+func (r *toolRecorder) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active--
+}
+
+func (r *toolRecorder) calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.ran...)
+}
+
+// registerTestTools registers a read-only and a mutating tool for the test. onRead, when set, runs inside
+// each read call, e.g. to hold it until others have started.
+func registerTestTools(t *testing.T, onRead func(id string)) *toolRecorder {
+	t.Helper()
+	recorder := &toolRecorder{}
+	parameters := jsonschema.Definition{
+		Type:       jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{"id": {Type: jsonschema.String}},
+		Required:   []string{"id"},
+	}
+
+	tools.Register(tools.Spec[testParams, string]{
+		Name:       readTool,
+		Parameters: parameters,
+		Class:      tools.ReadOnly,
+		Handler: func(ctx context.Context, params testParams) (string, tools.ToolError) {
+			recorder.start(readTool, params.ID)
+			defer recorder.finish()
+			if onRead != nil {
+				onRead(params.ID)
+			}
+			return "read " + params.ID, tools.ToolError{Success: true}
+		},
+	})
+	tools.Register(tools.Spec[testParams, string]{
+		Name:       writeTool,
+		Parameters: parameters,
+		Class:      tools.Mutating,
+		Handler: func(ctx context.Context, params testParams) (string, tools.ToolError) {
+			recorder.start(writeTool, params.ID)
+			defer recorder.finish()
+			// Long enough for a call running alongside to show up
+			time.Sleep(10 * time.Millisecond)
+			return "wrote " + params.ID, tools.ToolError{Success: true}
+		},
+	})
+	t.Cleanup(func() {
+		tools.Unregister(readTool)
+		tools.Unregister(writeTool)
+	})
+	return recorder
+}
+
+// toolResults returns the ToolResult events by call index
+func toolResults(events []Event) map[int]ToolResult {
+	results := map[int]ToolResult{}
+	for _, event := range events {
+		if result, ok := event.(ToolResult); ok {
+			results[result.Index] = result
+		}
+	}
+	return results
+}
+
+func TestRunToolsReadsInParallelAndWritesInOrder(t *testing.T) {
+	// Each read waits until the other reads of its batch have started, which only happens when they run side by side
+	var started sync.WaitGroup
+	started.Add(3)
+	recorder := registerTestTools(t, func(id string) {
+		started.Done()
+		done := make(chan struct{})
+		go func() { started.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Errorf("read %s waited for the other reads, they don't run in parallel", id)
+		}
+	})
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{
+		toolReply(
+			[2]string{readTool, `{"id":"a"}`},
+			[2]string{readTool, `{"id":"b"}`},
+			[2]string{readTool, `{"id":"c"}`},
+			[2]string{writeTool, `{"id":"1"}`},
+			[2]string{writeTool, `{"id":"2"}`},
+			[2]string{writeTool, `{"id":"3"}`},
+		),
+		textReply("All done"),
+	}}
+	s := newTestSession()
+	policies := map[string]permission.Policy{writeTool: permission.PolicyAllow}
+
+	events := collect(t, newTestAgent(fake, 0, policies).Run(context.Background(), s, "Go"), nil)
+	if done := lastDone(t, events); done.Err != nil {
+		t.Fatalf("Done.Err = %v", done.Err)
+	}
+
+	var writes []string
+	for _, call := range recorder.calls() {
+		if strings.HasPrefix(call, writeTool) {
+			writes = append(writes, call)
+		}
+	}
+	if want := []string{"test_write:1", "test_write:2", "test_write:3"}; !reflect.DeepEqual(writes, want) {
+		t.Errorf("writes ran as %q, want %q", writes, want)
+	}
+	if len(recorder.overlappingWrites) > 0 {
+		t.Errorf("writes %q ran alongside other calls", recorder.overlappingWrites)
+	}
+	if recorder.maxActive < 3 {
+		t.Errorf("at most %d calls ran at the same time, want the 3 reads together", recorder.maxActive)
+	}
+
+	// The results follow the system prompt, the message and the tool calls, in the order of the calls
+	// whatever order they finished in
+	for i, result := range s.History[3:9] {
+		if want := fmt.Sprintf("call_%d", i); result.ToolCallID != want {
+			t.Errorf("result %d is for %s, want %s", i, result.ToolCallID, want)
+		}
+	}
+}
+
+func TestRunToolsApprovals(t *testing.T) {
+	recorder := registerTestTools(t, nil)
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{
+		toolReply(
+			[2]string{writeTool, `{"id":"once"}`},
+			[2]string{writeTool, `{"id":"denied"}`},
+			[2]string{writeTool, `{"id":"feedback"}`},
+			[2]string{readTool, `{"id":"auto"}`},
+		),
+		textReply("OK"),
+	}}
+	answers := map[string]Approval{
+		`{"id":"once"}`:     {Decision: permission.AllowOnce},
+		`{"id":"denied"}`:   {Decision: permission.Deny},
+		`{"id":"feedback"}`: {Decision: permission.DenyWithFeedback, Feedback: "use another file"},
+	}
+
+	var asked []string
+	events := collect(t, newTestAgent(fake, 0, nil).Run(context.Background(), newTestSession(), "Go"), func(request ApprovalRequested) {
+		asked = append(asked, request.Call.Function.Arguments)
+		request.Respond(answers[request.Call.Function.Arguments])
+	})
+	if done := lastDone(t, events); done.Err != nil {
+		t.Fatalf("Done.Err = %v", done.Err)
+	}
+
+	if len(asked) != 3 {
+		t.Errorf("asked about %q, want the 3 writes and not the read", asked)
+	}
+	if want := []string{"test_write:once", "test_read:auto"}; !reflect.DeepEqual(recorder.calls(), want) {
+		t.Errorf("ran %q, want %q", recorder.calls(), want)
+	}
+
+	results := toolResults(events)
+	for index, want := range map[int]struct {
+		denied bool
+		text   string
+	}{
+		0: {false, "wrote once"},
+		1: {true, "The user denied this tool call"},
+		2: {true, "User feedback: use another file"},
+		3: {false, "read auto"},
+	} {
+		result := results[index]
+		if result.Denied != want.denied || !strings.Contains(result.Result, want.text) {
+			t.Errorf("call %d: denied = %v, result %s, want denied = %v with %q", index, result.Denied, result.Result, want.denied, want.text)
+		}
+	}
+}
+
+func TestRunToolsDenyPolicy(t *testing.T) {
+	recorder := registerTestTools(t, nil)
+	fake := &fakeProvider{replies: [][]provider.StreamMessage{
+		toolReply([2]string{writeTool, `{"id":"1"}`}),
+		textReply("OK"),
+	}}
+	policies := map[string]permission.Policy{writeTool: permission.PolicyDeny}
+
+	events := collect(t, newTestAgent(fake, 0, policies).Run(context.Background(), newTestSession(), "Go"), func(request ApprovalRequested) {
+		t.Errorf("asked about %s although its policy is deny", request.Call.Function.Name)
+		request.Respond(Approval{Decision: permission.AllowOnce})
+	})
+	if calls := recorder.calls(); len(calls) > 0 {
+		t.Errorf("ran %q although the policy is deny", calls)
+	}
+	result := toolResults(events)[0]
+	if !result.Denied || !strings.Contains(result.Result, "disabled by the user's permission settings") {
+		t.Errorf("result = %+v, want the call denied by the policy", result)
+	}
+}
@@ -1,10 +1,59 @@
 package ai
 
 import (
+	"context"
+
 	"github.com/krishkalaria12/nyron-ai-cli/ai/provider"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
 	"github.com/revrost/go-openrouter"
 )
 
-func OpenRouterAPI(messages []openrouter.ChatCompletionMessage, model string) (openrouter.ChatCompletionResponse, error) {
-	return provider.OpenRouterAPI(messages, model)
+// toolsFor returns the tools to offer a model, none when its catalog entry says it can't call tools
+func toolsFor(selectedModel config.SelectedModel) []openrouter.Tool {
+	if !config.SupportsTools(selectedModel) {
+		return nil
+	}
+	return tools.GetAllTools()
+}
+
+// Chat sends the history to the selected model with the tools it supports and waits for the reply
+func Chat(ctx context.Context, selectedModel config.SelectedModel, messages []openrouter.ChatCompletionMessage) (provider.Response, error) {
+	backend, err := provider.Get(selectedModel.Provider)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	return backend.Chat(ctx, provider.Request{
+		Model:    selectedModel.Model,
+		Messages: messages,
+		Tools:    toolsFor(selectedModel),
+	})
+}
+
+// Stream sends the history to the selected model with the tools it supports and streams the reply
+func Stream(ctx context.Context, selectedModel config.SelectedModel, messages []openrouter.ChatCompletionMessage) (<-chan provider.StreamMessage, error) {
+	backend, err := provider.Get(selectedModel.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.Stream(ctx, provider.Request{
+		Model:    selectedModel.Model,
+		Messages: messages,
+		Tools:    toolsFor(selectedModel),
+	})
+}
+
+// Complete sends messages to the selected model without tools and waits for the reply, for side tasks like summaries
+func Complete(ctx context.Context, selectedModel config.SelectedModel, messages []openrouter.ChatCompletionMessage) (provider.Response, error) {
+	backend, err := provider.Get(selectedModel.Provider)
+	if err != nil {
+		return provider.Response{}, err
+	}
+
+	return backend.Chat(ctx, provider.Request{
+		Model:    selectedModel.Model,
+		Messages: messages,
+	})
 }
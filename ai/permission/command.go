@@ -0,0 +1,172 @@
+package permission
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// RunCommandTool is the name of the shell tool, whose calls are checked per command
+const RunCommandTool = "run_command"
+
+// defaultAllowedCommands only inspect the project. Commands that print arbitrary files, like cat, are left out
+// so secrets outside the project aren't read unattended. go build and go test are left out too: the model can
+// edit the tests, cgo directives and go.mod they compile and run, so they would run any code without asking.
+// Users who want them can add them to allowed_commands.
+var defaultAllowedCommands = []string{
+	"ls",
+	"pwd",
+	"git status",
+	"git diff",
+	"git log",
+	"git show",
+	"go vet",
+}
+
+// shellOperators chain or redirect commands, so an allow-listed prefix says nothing about the rest
+var shellOperators = regexp.MustCompile("[;&|<>`\\n]|\\$\\(")
+
+// riskyFlags write files anywhere or run other programs, by program. An allow-listed command that passes
+// one of them is checked like any other command.
+var riskyFlags = map[string][]string{
+	"git": {"output", "ext-diff", "textconv", "exec-path", "c", "no-index"},
+	"go": {"o", "c", "exec", "toolexec", "vettool", "ldflags", "modfile", "pkgdir", "outputdir",
+		"coverprofile", "cpuprofile", "memprofile", "blockprofile", "mutexprofile", "trace"},
+}
+
+// expansions can turn a word into a flag once the shell has expanded it
+var expansions = regexp.MustCompile(`[${]`)
+
+// quoting is removed before the flags are checked, the shell removes it too
+var quoting = strings.NewReplacer(`"`, "", `'`, "", `\`, "")
+
+// dangerousCommands always need the user's approval, whatever the policies say
+var dangerousCommands = []*regexp.Regexp{
+	regexp.MustCompile(`\brm\s+(-[a-zA-Z]*[rRf]|--recursive|--force)`),
+	regexp.MustCompile(`\bsudo\b`),
+	regexp.MustCompile(`\bmkfs`),
+	regexp.MustCompile(`\bdd\s+.*\bof=`),
+	regexp.MustCompile(`\bchmod\s+-R\b|\bchown\s+-R\b`),
+	regexp.MustCompile(`\bgit\s+(push\s+.*(-f\b|--force)|reset\s+--hard|clean\s+-[a-zA-Z]*f)`),
+	regexp.MustCompile(`\b(shutdown|reboot|halt)\b`),
+	regexp.MustCompile(`\bcurl\b.*\|\s*(ba|z)?sh\b|\bwget\b.*\|\s*(ba|z)?sh\b`),
+	regexp.MustCompile(`:\(\)\s*\{`),
+}
+
+// CheckCommand returns the policy for one run_command call. Dangerous commands are always asked about,
+// allow-listed commands without shell operators, risky flags or paths outside the project run, and commands
+// allowed for the session run again.
+func (m *Manager) CheckCommand(command string) Policy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policy := m.policy(RunCommandTool)
+	if policy == PolicyDeny {
+		return PolicyDeny
+	}
+
+	command = strings.TrimSpace(command)
+	if IsDangerousCommand(command) {
+		return PolicyAsk
+	}
+	if m.sessionCommands[command] {
+		return PolicyAllow
+	}
+	if !shellOperators.MatchString(command) && !hasRiskyFlag(command) && !hasOutsidePath(command) {
+		for _, allowed := range m.allowedCommands {
+			if command == allowed || strings.HasPrefix(command, allowed+" ") {
+				return PolicyAllow
+			}
+		}
+	}
+	return policy
+}
+
+// hasRiskyFlag reports whether a command passes a flag of riskyFlags, or a word the shell could expand into one
+func hasRiskyFlag(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	program := filepath.Base(fields[0])
+	for _, field := range fields[1:] {
+		if expansions.MatchString(field) {
+			return true
+		}
+		name, ok := strings.CutPrefix(quoting.Replace(field), "-")
+		if !ok {
+			continue
+		}
+		name, long := strings.CutPrefix(name, "-")
+		name, _, _ = strings.Cut(name, "=")
+		if program == "go" {
+			// go test takes its flags with a test. prefix as well
+			name = strings.TrimPrefix(name, "test.")
+		}
+		for _, flag := range riskyFlags[program] {
+			// git takes any unambiguous abbreviation of a long option, e.g. --outp for --output
+			if name == flag || program == "git" && long && name != "" && strings.HasPrefix(flag, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasOutsidePath reports whether a command passes an absolute path, a home directory path or a path with "..",
+// which could point outside the project; git diff, for one, compares any two files once a path is outside the tree
+func hasOutsidePath(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, field := range fields[1:] {
+		word := quoting.Replace(field)
+		if strings.HasPrefix(word, "-") {
+			// The value of --flag=value can be a path too
+			_, value, ok := strings.Cut(word, "=")
+			if !ok {
+				continue
+			}
+			word = value
+		}
+		if strings.HasPrefix(word, "/") || strings.HasPrefix(word, "~") || filepath.IsAbs(word) {
+			return true
+		}
+		for _, part := range strings.Split(filepath.ToSlash(word), "/") {
+			if part == ".." {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RecordCommand remembers a command the user allowed for the session; other commands are still asked about
+func (m *Manager) RecordCommand(command string, decision Decision) {
+	if decision != AllowSession {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionCommands[strings.TrimSpace(command)] = true
+}
+
+// IsDangerousCommand reports whether a command could destroy data or affect the system outside the project
+func IsDangerousCommand(command string) bool {
+	for _, pattern := range dangerousCommands {
+		if pattern.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedCommandsFromConfig returns the default allow-list extended by config.AllowedCommands
+func allowedCommandsFromConfig() []string {
+	allowed := append([]string{}, defaultAllowedCommands...)
+	return append(allowed, config.AllowedCommands()...)
+}
@@ -0,0 +1,179 @@
+package permission
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHasRiskyFlag(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"git diff", false},
+		{"git diff --stat HEAD~1", false},
+		{"git log --oneline -n 5", false},
+		{"go test ./... -run TestX -v", false},
+
+		// Flags that write files or run programs
+		{"git diff --output=/tmp/x", true},
+		{"git diff --output /tmp/x", true},
+		{"git diff --ext-diff", true},
+		{"git log --textconv", true},
+		{"git diff --no-index a b", true},
+		{"git diff -c", true},
+		{"go test -exec ./run.sh", true},
+		{"go build -o /usr/local/bin/x", true},
+		{"go vet -vettool=./tool", true},
+		{"go test -test.coverprofile=c.out", true},
+		{"go test --toolexec=x", true},
+
+		// git takes any unambiguous abbreviation of a long option
+		{"git diff --outp=/tmp/x", true},
+		{"git diff --o=/tmp/x", true},
+		{"git diff --no-i a b", true},
+		{"git diff --ext", true},
+		{"git diff --stat --no-color", false},
+		// go doesn't, so -out is not -output
+		{"go test -out", false},
+
+		// Quoting is removed before the check, like the shell does
+		{`git diff "--output=x"`, true},
+		{`git diff '--no-index' a b`, true},
+		{`git diff \--output=x`, true},
+		{`go build "-o" x`, true},
+
+		// Words the shell could expand into a flag
+		{"git diff $FLAG", true},
+		{"git diff ${FLAG}", true},
+		{"go test {-o,x}", true},
+
+		// Flags of other programs aren't known
+		{"ls -o", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := hasRiskyFlag(test.command); got != test.want {
+			t.Errorf("hasRiskyFlag(%q) = %v, want %v", test.command, got, test.want)
+		}
+	}
+}
+
+func TestHasOutsidePath(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"git diff main -- src/app.go", false},
+		{"ls -la internal", false},
+		{"git log --since=yesterday", false},
+		{"git show HEAD~2:go.mod", false},
+		{"git diff ~/.ssh/id_rsa /dev/null", true},
+		{"git diff /etc/passwd go.mod", true},
+		{"ls ../other-project", true},
+		{"ls src/../../other-project", true},
+		{`ls "/etc"`, true},
+		{"git log --format=x --git-dir=/tmp/repo", true},
+		{"ls", false},
+	}
+	for _, test := range tests {
+		if got := hasOutsidePath(test.command); got != test.want {
+			t.Errorf("hasOutsidePath(%q) = %v, want %v", test.command, got, test.want)
+		}
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    Policy
+	}{
+		// Allow-listed commands run without asking
+		{"ls", PolicyAllow},
+		{"  git status  ", PolicyAllow},
+		{"git diff --stat", PolicyAllow},
+		{"go vet ./...", PolicyAllow},
+
+		// A prefix only matches whole words
+		{"lsblk", PolicyAsk},
+		{"git statusx", PolicyAsk},
+		{"cat go.mod", PolicyAsk},
+
+		// Building and testing run code the model can edit, so they are asked about until the user allows them
+		{"go test ./...", PolicyAsk},
+		{"go build ./...", PolicyAsk},
+
+		// Shell operators chain other commands
+		{"ls; rm x", PolicyAsk},
+		{"git status && curl example.com", PolicyAsk},
+		{"git log | sh", PolicyAsk},
+		{"ls > out.txt", PolicyAsk},
+		{"ls `whoami`", PolicyAsk},
+		{"ls $(whoami)", PolicyAsk},
+		{"ls\nrm x", PolicyAsk},
+
+		// Risky flags, expansions and paths outside the project
+		{"git diff --no-index ~/.ssh/id_rsa /dev/null", PolicyAsk},
+		{"git diff ~/.ssh/id_rsa /dev/null", PolicyAsk},
+		{"git diff --outp=x", PolicyAsk},
+		{`git diff "--output=x"`, PolicyAsk},
+		{"git log $OPTS", PolicyAsk},
+		{"go vet -vettool=./x ./...", PolicyAsk},
+		{"ls ../", PolicyAsk},
+
+		// Dangerous commands are asked about even when they start like an allowed one
+		{"git status --porcelain; sudo reboot", PolicyAsk},
+		{"rm -rf build", PolicyAsk},
+	}
+	manager := NewManager(nil)
+	for _, test := range tests {
+		if got := manager.CheckCommand(test.command); got != test.want {
+			t.Errorf("CheckCommand(%q) = %s, want %s", test.command, got, test.want)
+		}
+	}
+}
+
+func TestCheckCommandPolicies(t *testing.T) {
+	denied := NewManager(map[string]Policy{RunCommandTool: PolicyDeny})
+	if got := denied.CheckCommand("ls"); got != PolicyDeny {
+		t.Errorf("with run_command denied, ls = %s, want deny", got)
+	}
+
+	allowed := NewManager(map[string]Policy{RunCommandTool: PolicyAllow})
+	if got := allowed.CheckCommand("make build"); got != PolicyAllow {
+		t.Errorf("with run_command allowed, make build = %s, want allow", got)
+	}
+	if got := allowed.CheckCommand("sudo make install"); got != PolicyAsk {
+		t.Errorf("with run_command allowed, sudo make install = %s, want ask", got)
+	}
+
+	manager := NewManager(nil)
+	manager.RecordCommand("make build", AllowOnce)
+	if got := manager.CheckCommand("make build"); got != PolicyAsk {
+		t.Errorf("after allowing once, make build = %s, want ask", got)
+	}
+	manager.RecordCommand("make build", AllowSession)
+	if got := manager.CheckCommand("make build"); got != PolicyAllow {
+		t.Errorf("after allowing for the session, make build = %s, want allow", got)
+	}
+	if got := manager.CheckCommand("make clean"); got != PolicyAsk {
+		t.Errorf("after allowing make build for the session, make clean = %s, want ask", got)
+	}
+}
+
+func TestCheckCommandAllowedByUser(t *testing.T) {
+	manager := NewManager(nil)
+	manager.allowedCommands = append(slices.Clone(defaultAllowedCommands), "go test", "go build")
+
+	for command, want := range map[string]Policy{
+		"go test ./...":            PolicyAllow,
+		"go build ./cmd/x":         PolicyAllow,
+		"go test -exec ./x ./...":  PolicyAsk,
+		"go build -o /tmp/x ./...": PolicyAsk,
+		"go run .":                 PolicyAsk,
+	} {
+		if got := manager.CheckCommand(command); got != want {
+			t.Errorf("with go test and go build allowed, CheckCommand(%q) = %s, want %s", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package permission
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// Policy decides what happens when the model asks to run a tool
+type Policy string
+
+const (
+	PolicyAllow Policy = "allow" // Run without asking
+	PolicyAsk   Policy = "ask"   // Ask the user every time
+	PolicyDeny  Policy = "deny"  // Never run
+)
+
+// Decision is the user's answer to an approval request
+type Decision int
+
+const (
+	AllowOnce Decision = iota
+	AllowSession
+	Deny
+	DenyWithFeedback
+)
+
+// defaultPolicy auto-approves tools that only read and asks before anything that writes, including unknown tools
+func defaultPolicy(toolName string) Policy {
+	if tools.IsReadOnly(toolName) {
+		return PolicyAllow
+	}
+	return PolicyAsk
+}
+
+// Manager tracks the configured policies and the tools the user allowed for this session
+type Manager struct {
+	mu             sync.Mutex
+	policies       map[string]Policy
+	sessionAllowed map[string]bool
+	outsideRoot    Policy // Applies to tool calls that use paths outside the workspace
+	// Command prefixes that run_command may run without asking, and exact commands allowed for the session
+	allowedCommands []string
+	sessionCommands map[string]bool
+}
+
+// NewManager returns a manager with the default policies, replaced by any overrides per tool name
+func NewManager(overrides map[string]Policy) *Manager {
+	return &Manager{
+		policies:        maps.Clone(overrides),
+		sessionAllowed:  map[string]bool{},
+		outsideRoot:     PolicyAsk,
+		allowedCommands: defaultAllowedCommands,
+		sessionCommands: map[string]bool{},
+	}
+}
+
+// policy returns the configured policy for a tool, or its default; the caller holds m.mu
+func (m *Manager) policy(toolName string) Policy {
+	if policy, ok := m.policies[toolName]; ok {
+		return policy
+	}
+	return defaultPolicy(toolName)
+}
+
+// Check returns the policy for a tool; unknown tools are asked about
+func (m *Manager) Check(toolName string) Policy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policy := m.policy(toolName)
+	if policy == PolicyAsk && m.sessionAllowed[toolName] {
+		return PolicyAllow
+	}
+	return policy
+}
+
+// CheckOutsideRoot returns the policy for tool calls that use paths outside the workspace
+func (m *Manager) CheckOutsideRoot() Policy {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.outsideRoot
+}
+
+// Record remembers a decision that outlives the current call
+func (m *Manager) Record(toolName string, decision Decision) {
+	if decision != AllowSession {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessionAllowed[toolName] = true
+}
+
+// ParsePolicy converts a configured value to a Policy
+func ParsePolicy(value string) (Policy, bool) {
+	switch Policy(value) {
+	case PolicyAllow, PolicyAsk, PolicyDeny:
+		return Policy(value), true
+	default:
+		return "", false
+	}
+}
+
+// NewManagerFromConfig returns a manager with the overrides from config.ToolPolicies and
+// the outside-root policy from config.OutsideRootPolicy; invalid values are ignored
+func NewManagerFromConfig() *Manager {
+	overrides := map[string]Policy{}
+	for name, value := range config.ToolPolicies() {
+		if policy, ok := ParsePolicy(value); ok {
+			overrides[name] = policy
+		}
+	}
+
+	manager := NewManager(overrides)
+	manager.allowedCommands = allowedCommandsFromConfig()
+	if policy, ok := ParsePolicy(config.OutsideRootPolicy()); ok {
+		manager.outsideRoot = policy
+	}
+	return manager
+}
@@ -0,0 +1,412 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+const (
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 8192
+	// With thinking the answer gets the same room as without, on top of the thinking budget
+	anthropicThinkingBudget = 8192
+)
+
+// Anthropic talks to the Anthropic Messages API
+type Anthropic struct {
+	apiKey  string
+	baseURL string
+}
+
+// NewAnthropic returns a backend for the Anthropic Messages API at baseURL, e.g. https://api.anthropic.com/v1
+func NewAnthropic(apiKey string, baseURL string) *Anthropic {
+	return &Anthropic{apiKey: apiKey, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	Data      string          `json:"data,omitempty"` // Of redacted_thinking blocks
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	Thinking  *anthropicThinking `json:"thinking,omitempty"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// anthropicThinkingCacheSize bounds the thinking kept for tool calls that are never answered, e.g. of stopped turns
+const anthropicThinkingCacheSize = 32
+
+// thinkingCache keeps the signed thinking blocks of the responses that called tools, by the ID of their first
+// tool call. The API wants them back unchanged while the tools are answered, and the history has no place
+// for the signatures. Backends are made per call, so one cache serves them all; tool call IDs don't repeat
+// across sessions.
+type thinkingCache struct {
+	mu     sync.Mutex
+	blocks map[string][]anthropicBlock
+	order  []string // Keys from oldest to newest
+}
+
+var anthropicThinkingCache = &thinkingCache{blocks: map[string][]anthropicBlock{}}
+
+// remember keeps the thinking blocks of a response that called tools, dropping the oldest entries beyond
+// anthropicThinkingCacheSize
+func (c *thinkingCache) remember(blocks []anthropicBlock) {
+	var thinking []anthropicBlock
+	firstToolID := ""
+	for _, block := range blocks {
+		switch block.Type {
+		case "thinking", "redacted_thinking":
+			thinking = append(thinking, block)
+		case "tool_use":
+			if firstToolID == "" {
+				firstToolID = block.ID
+			}
+		}
+	}
+	if len(thinking) == 0 || firstToolID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.blocks[firstToolID]; !ok {
+		c.order = append(c.order, firstToolID)
+	}
+	c.blocks[firstToolID] = thinking
+	for len(c.order) > anthropicThinkingCacheSize {
+		delete(c.blocks, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// lookup returns the thinking blocks kept for an assistant message that called tools
+func (c *thinkingCache) lookup(message openrouter.ChatCompletionMessage) []anthropicBlock {
+	if len(message.ToolCalls) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.blocks[message.ToolCalls[0].ID]
+}
+
+// forget drops the thinking of the tool calls answered in a request that went through. Only the last
+// assistant message needs its thinking, and the next one has its own.
+func (c *thinkingCache) forget(messages []openrouter.ChatCompletionMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, message := range messages {
+		if message.Role != openrouter.ChatMessageRoleAssistant || len(message.ToolCalls) == 0 {
+			continue
+		}
+		id := message.ToolCalls[0].ID
+		if _, ok := c.blocks[id]; ok {
+			delete(c.blocks, id)
+			c.order = slices.DeleteFunc(c.order, func(key string) bool { return key == id })
+		}
+	}
+}
+
+type anthropicResponse struct {
+	Content []anthropicBlock `json:"content"`
+	Usage   anthropicUsage   `json:"usage"`
+}
+
+func (p *Anthropic) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// request translates the history: system messages become the system prompt, tool calls become
+// tool_use blocks and tool results become tool_result blocks in the following user turn. Models that can
+// reason think first, except while answering tool calls whose thinking blocks weren't kept, e.g. in a
+// resumed session, which the API would refuse.
+func (p *Anthropic) request(req Request) anthropicRequest {
+	var system []string
+	var messages []anthropicMessage
+	thinking, missingThinking := false, false
+	if model, ok := config.FindModel(config.SelectedModel{Provider: config.ProviderAnthropic.ID, Model: req.Model}); ok {
+		thinking = model.Reasoning
+	}
+
+	appendBlocks := func(role string, blocks ...anthropicBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		// The API expects alternating roles, so consecutive turns of one role are merged
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, blocks...)
+			return
+		}
+		messages = append(messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, message := range req.Messages {
+		switch message.Role {
+		case openrouter.ChatMessageRoleSystem:
+			system = append(system, message.Content.Text)
+		case openrouter.ChatMessageRoleUser:
+			appendBlocks("user", anthropicBlock{Type: "text", Text: message.Content.Text})
+		case openrouter.ChatMessageRoleAssistant:
+			blocks := append([]anthropicBlock(nil), anthropicThinkingCache.lookup(message)...)
+			// Only the last message counts, the thinking of finished turns may be left out
+			missingThinking = len(message.ToolCalls) > 0 && len(blocks) == 0
+			if message.Content.Text != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: message.Content.Text})
+			}
+			for _, call := range message.ToolCalls {
+				input, _ := json.Marshal(toolArguments(call.Function.Arguments))
+				blocks = append(blocks, anthropicBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: input,
+				})
+			}
+			appendBlocks("assistant", blocks...)
+		case openrouter.ChatMessageRoleTool:
+			appendBlocks("user", anthropicBlock{
+				Type:      "tool_result",
+				ToolUseID: message.ToolCallID,
+				Content:   message.Content.Text,
+			})
+		}
+	}
+
+	var tools []anthropicTool
+	for _, tool := range req.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		tools = append(tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: toolParameters(tool),
+		})
+	}
+
+	request := anthropicRequest{
+		Model:     req.Model,
+		MaxTokens: anthropicMaxTokens,
+		System:    strings.Join(system, "\n\n"),
+		Messages:  messages,
+		Tools:     tools,
+	}
+	if thinking && !missingThinking {
+		request.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: anthropicThinkingBudget}
+		request.MaxTokens += anthropicThinkingBudget
+	}
+	return request
+}
+
+// toUsage converts the usage; input_tokens leaves out the tokens read from and written to the prompt cache,
+// which are part of the prompt all the same
+func (u anthropicUsage) toUsage() *openrouter.Usage {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return &openrouter.Usage{
+		PromptTokens:       prompt,
+		CompletionTokens:   u.OutputTokens,
+		TotalTokens:        prompt + u.OutputTokens,
+		PromptTokenDetails: openrouter.PromptTokenDetails{CachedTokens: u.CacheReadInputTokens},
+	}
+}
+
+func (p *Anthropic) Chat(ctx context.Context, req Request) (Response, error) {
+	resp, err := postJSON(ctx, p.baseURL+"/messages", p.headers(), p.request(req))
+	if err != nil {
+		return Response{}, fmt.Errorf("Anthropic error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Response{}, fmt.Errorf("Anthropic error: %v", err)
+	}
+
+	anthropicThinkingCache.forget(req.Messages)
+	anthropicThinkingCache.remember(result.Content)
+	var acc StreamAccumulator
+	toolIndex := 0
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			acc.Add(StreamMessage{Content: block.Text})
+		case "thinking":
+			acc.Add(StreamMessage{Reasoning: block.Thinking})
+		case "tool_use":
+			acc.Add(StreamMessage{ToolCalls: []openrouter.ToolCall{{
+				Index:    intPtr(toolIndex),
+				ID:       block.ID,
+				Type:     openrouter.ToolTypeFunction,
+				Function: openrouter.FunctionCall{Name: block.Name, Arguments: string(block.Input)},
+			}}})
+			toolIndex++
+		}
+	}
+
+	return Response{Message: acc.Message(), Usage: result.Usage.toUsage()}, nil
+}
+
+func (p *Anthropic) Stream(ctx context.Context, req Request) (<-chan StreamMessage, error) {
+	request := p.request(req)
+	request.Stream = true
+
+	resp, err := postJSON(ctx, p.baseURL+"/messages", p.headers(), request)
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic error: %v", err)
+	}
+
+	chunks := make(chan StreamMessage)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		var usage anthropicUsage
+		// Content block indexes cover text and thinking too, tool calls are numbered separately
+		toolIndexes := map[int]int{}
+		toolHasInput := map[int]bool{}
+		// The blocks as they arrive, to keep the signed thinking for the tool results
+		var blocks []anthropicBlock
+
+		err := readSSE(resp.Body, func(event string, data []byte) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var payload struct {
+				Index   int `json:"index"`
+				Message struct {
+					Usage anthropicUsage `json:"usage"`
+				} `json:"message"`
+				ContentBlock anthropicBlock `json:"content_block"`
+				Delta        struct {
+					Type        string `json:"type"`
+					Text        string `json:"text"`
+					Thinking    string `json:"thinking"`
+					Signature   string `json:"signature"`
+					PartialJSON string `json:"partial_json"`
+				} `json:"delta"`
+				Usage anthropicUsage `json:"usage"`
+				Error struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return err
+			}
+
+			switch event {
+			case "message_start":
+				usage = payload.Message.Usage
+			case "content_block_start":
+				blocks = append(blocks, payload.ContentBlock)
+				if payload.ContentBlock.Type == "tool_use" {
+					toolIndexes[payload.Index] = len(toolIndexes)
+					send(ctx, chunks, StreamMessage{ToolCalls: []openrouter.ToolCall{{
+						Index:    intPtr(toolIndexes[payload.Index]),
+						ID:       payload.ContentBlock.ID,
+						Type:     openrouter.ToolTypeFunction,
+						Function: openrouter.FunctionCall{Name: payload.ContentBlock.Name},
+					}}})
+				}
+			case "content_block_delta":
+				switch payload.Delta.Type {
+				case "text_delta":
+					send(ctx, chunks, StreamMessage{Content: payload.Delta.Text})
+				case "thinking_delta":
+					if block := lastBlock(blocks, "thinking"); block != nil {
+						block.Thinking += payload.Delta.Thinking
+					}
+					send(ctx, chunks, StreamMessage{Reasoning: payload.Delta.Thinking})
+				case "signature_delta":
+					if block := lastBlock(blocks, "thinking"); block != nil {
+						block.Signature += payload.Delta.Signature
+					}
+				case "input_json_delta":
+					if payload.Delta.PartialJSON == "" {
+						return nil
+					}
+					toolHasInput[payload.Index] = true
+					send(ctx, chunks, StreamMessage{ToolCalls: []openrouter.ToolCall{{
+						Index:    intPtr(toolIndexes[payload.Index]),
+						Function: openrouter.FunctionCall{Arguments: payload.Delta.PartialJSON},
+					}}})
+				}
+			case "content_block_stop":
+				if toolIndex, ok := toolIndexes[payload.Index]; ok && !toolHasInput[payload.Index] {
+					// Tools called without arguments never receive an input delta
+					send(ctx, chunks, StreamMessage{ToolCalls: []openrouter.ToolCall{{
+						Index:    intPtr(toolIndex),
+						Function: openrouter.FunctionCall{Arguments: "{}"},
+					}}})
+				}
+			case "message_delta":
+				usage.OutputTokens = payload.Usage.OutputTokens
+			case "error":
+				return fmt.Errorf("%s", payload.Error.Message)
+			}
+			return nil
+		})
+		if err != nil {
+			send(ctx, chunks, StreamMessage{Error: fmt.Errorf("Anthropic error: %v", err)})
+			return
+		}
+
+		anthropicThinkingCache.forget(req.Messages)
+		anthropicThinkingCache.remember(blocks)
+		send(ctx, chunks, StreamMessage{Usage: usage.toUsage(), Done: true})
+	}()
+
+	return chunks, nil
+}
+
+// lastBlock returns the last block if it has the type, the one the deltas of a stream add to
+func lastBlock(blocks []anthropicBlock, blockType string) *anthropicBlock {
+	if len(blocks) == 0 || blocks[len(blocks)-1].Type != blockType {
+		return nil
+	}
+	return &blocks[len(blocks)-1]
+}
@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+func TestAnthropicRequest(t *testing.T) {
+	server, requests := newTestServer(t, "application/json", `{"content":[{"type":"text","text":"ok"}],"usage":{}}`)
+	backend := NewAnthropic("sk-ant", server.URL+"/v1/")
+
+	if _, err := backend.Chat(context.Background(), Request{Model: "claude-3-5-haiku-latest", Messages: testHistory, Tools: testTools}); err != nil {
+		t.Fatal(err)
+	}
+	request := (*requests)[0]
+	if request.Path != "/v1/messages" || request.Header.Get("x-api-key") != "sk-ant" || request.Header.Get("anthropic-version") == "" {
+		t.Errorf("request to %s with headers %v", request.Path, request.Header)
+	}
+	checkFields(t, request.Body, []fieldCheck{
+		{[]any{"model"}, "claude-3-5-haiku-latest"},
+		{[]any{"max_tokens"}, anthropicMaxTokens},
+		{[]any{"system"}, "Be brief."},
+		{[]any{"messages", 0}, map[string]any{"role": "user", "content": []any{map[string]any{"type": "text", "text": "What is in go.mod?"}}}},
+		{[]any{"messages", 1, "role"}, "assistant"},
+		{[]any{"messages", 1, "content", 0}, map[string]any{"type": "text", "text": "Let me look."}},
+		{[]any{"messages", 1, "content", 1}, map[string]any{"type": "tool_use", "id": "call_1", "name": "read_file", "input": map[string]any{"path": "go.mod"}}},
+		{[]any{"messages", 2}, map[string]any{"role": "user", "content": []any{map[string]any{"type": "tool_result", "tool_use_id": "call_1", "content": `{"Result":"module x"}`}}}},
+		{[]any{"tools", 0, "name"}, "read_file"},
+		{[]any{"tools", 0, "input_schema", "required"}, []string{"path"}},
+		{[]any{"tools", 1, "input_schema", "type"}, "object"},
+		// Haiku 3.5 can't think
+		{[]any{"thinking"}, nil},
+		{[]any{"stream"}, nil},
+	})
+}
+
+func TestAnthropicThinking(t *testing.T) {
+	// The model thinks, signs its thinking and calls a tool
+	body := sse(
+		[2]string{"message_start", `{"type":"message_start","message":{"usage":{"input_tokens":10,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000,"output_tokens":1}}}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"I should read "}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"the file."}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-123"}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"opaque"}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":1}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_think","name":"read_file","input":{}}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"path\":"}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"go.mod\"}"}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":2}`},
+		[2]string{"message_delta", `{"type":"message_delta","usage":{"output_tokens":50}}`},
+		[2]string{"message_stop", `{"type":"message_stop"}`},
+	)
+	answer := sse(
+		[2]string{"message_start", `{"type":"message_start","message":{"usage":{"input_tokens":10,"output_tokens":1}}}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":"It is module x."}}`},
+		[2]string{"message_stop", `{"type":"message_stop"}`},
+	)
+	server, requests := newTestServer(t, "text/event-stream", body, answer)
+	backend := NewAnthropic("sk-ant", server.URL)
+	history := []openrouter.ChatCompletionMessage{{Role: openrouter.ChatMessageRoleUser, Content: openrouter.Content{Text: "Read go.mod"}}}
+
+	chunks, err := backend.Stream(context.Background(), Request{Model: "claude-sonnet-4-5", Messages: history})
+	message, usage := collectStream(t, chunks, err)
+	checkFields(t, (*requests)[0].Body, []fieldCheck{
+		{[]any{"thinking"}, map[string]any{"type": "enabled", "budget_tokens": anthropicThinkingBudget}},
+		{[]any{"max_tokens"}, anthropicMaxTokens + anthropicThinkingBudget},
+		{[]any{"stream"}, true},
+	})
+
+	if message.Reasoning == nil || *message.Reasoning != "I should read the file." {
+		t.Errorf("reasoning = %v, want the thinking deltas", message.Reasoning)
+	}
+	if len(message.ToolCalls) != 1 || message.ToolCalls[0].ID != "toolu_think" || message.ToolCalls[0].Function.Arguments != `{"path":"go.mod"}` {
+		t.Errorf("tool calls = %+v", message.ToolCalls)
+	}
+	// The prompt counts the tokens written to and read from the cache too
+	if usage.PromptTokens != 1110 || usage.PromptTokenDetails.CachedTokens != 1000 || usage.CompletionTokens != 50 || usage.TotalTokens != 1160 {
+		t.Errorf("usage = %+v", usage)
+	}
+
+	// Answering the tool call sends the signed thinking back first
+	history = append(history, message, openrouter.ChatCompletionMessage{
+		Role: openrouter.ChatMessageRoleTool, ToolCallID: "toolu_think", Content: openrouter.Content{Text: "module x"},
+	})
+	chunks, err = backend.Stream(context.Background(), Request{Model: "claude-sonnet-4-5", Messages: history})
+	collectStream(t, chunks, err)
+	checkFields(t, (*requests)[1].Body, []fieldCheck{
+		{[]any{"thinking", "type"}, "enabled"},
+		{[]any{"messages", 1, "content", 0}, map[string]any{"type": "thinking", "thinking": "I should read the file.", "signature": "sig-123"}},
+		{[]any{"messages", 1, "content", 1}, map[string]any{"type": "redacted_thinking", "data": "opaque"}},
+		{[]any{"messages", 1, "content", 2, "type"}, "tool_use"},
+	})
+	// Once the results were answered the thinking isn't needed anymore
+	if blocks := anthropicThinkingCache.lookup(message); blocks != nil {
+		t.Errorf("the thinking of an answered tool call is still kept: %+v", blocks)
+	}
+
+	// Without the thinking of the tool call, e.g. in a resumed session, the model answers without thinking
+	chunks, err = backend.Stream(context.Background(), Request{Model: "claude-sonnet-4-5", Messages: testHistory})
+	collectStream(t, chunks, err)
+	checkFields(t, (*requests)[2].Body, []fieldCheck{
+		{[]any{"thinking"}, nil},
+		{[]any{"max_tokens"}, anthropicMaxTokens},
+	})
+}
+
+func TestAnthropicStream(t *testing.T) {
+	body := sse(
+		[2]string{"message_start", `{"type":"message_start","message":{"usage":{"input_tokens":20,"output_tokens":1}}}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Two "}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"tools."}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":0}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_current_directory","input":{}}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":1}`},
+		[2]string{"content_block_start", `{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_2","name":"read_file","input":{}}}`},
+		[2]string{"content_block_delta", `{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"path\":\"a\"}"}}`},
+		[2]string{"content_block_stop", `{"type":"content_block_stop","index":2}`},
+		[2]string{"message_delta", `{"type":"message_delta","usage":{"output_tokens":30}}`},
+	)
+	server, _ := newTestServer(t, "text/event-stream", body)
+
+	chunks, err := NewAnthropic("sk-ant", server.URL).Stream(context.Background(), Request{Model: "claude-3-5-haiku-latest", Messages: testHistory})
+	message, usage := collectStream(t, chunks, err)
+	if message.Content.Text != "Two tools." {
+		t.Errorf("content = %q", message.Content.Text)
+	}
+	want := []openrouter.FunctionCall{{Name: "get_current_directory", Arguments: "{}"}, {Name: "read_file", Arguments: `{"path":"a"}`}}
+	if len(message.ToolCalls) != 2 {
+		t.Fatalf("tool calls = %+v, want 2", message.ToolCalls)
+	}
+	for i, call := range message.ToolCalls {
+		if call.Function != want[i] {
+			t.Errorf("tool call %d = %+v, want %+v", i, call.Function, want[i])
+		}
+	}
+	if usage.PromptTokens != 20 || usage.CompletionTokens != 30 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	body := sse([2]string{"error", `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`})
+	server, _ := newTestServer(t, "text/event-stream", body)
+
+	chunks, err := NewAnthropic("sk-ant", server.URL).Stream(context.Background(), Request{Model: "m", Messages: testHistory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamErr error
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			streamErr = chunk.Error
+		}
+	}
+	if streamErr == nil || streamErr.Error() != "Anthropic error: Overloaded" {
+		t.Errorf("stream error = %v, want the error event", streamErr)
+	}
+}
+
+func TestThinkingCache(t *testing.T) {
+	cache := &thinkingCache{blocks: map[string][]anthropicBlock{}}
+	response := func(id string) []anthropicBlock {
+		return []anthropicBlock{{Type: "thinking", Thinking: "about " + id, Signature: "sig"}, {Type: "tool_use", ID: id}}
+	}
+	call := func(id string) openrouter.ChatCompletionMessage {
+		return openrouter.ChatCompletionMessage{Role: openrouter.ChatMessageRoleAssistant, ToolCalls: []openrouter.ToolCall{{ID: id}}}
+	}
+
+	// Answers without tool calls or without thinking aren't kept
+	cache.remember([]anthropicBlock{{Type: "thinking", Thinking: "x"}, {Type: "text", Text: "done"}})
+	cache.remember([]anthropicBlock{{Type: "tool_use", ID: "toolu_plain"}})
+	if len(cache.blocks) != 0 {
+		t.Errorf("kept %v, want nothing", cache.blocks)
+	}
+
+	// Tool calls that are never answered, e.g. of stopped turns, only fill the cache up to its size
+	for i := range anthropicThinkingCacheSize + 5 {
+		cache.remember(response(fmt.Sprint("toolu_", i)))
+	}
+	if len(cache.blocks) != anthropicThinkingCacheSize || len(cache.order) != anthropicThinkingCacheSize {
+		t.Errorf("the cache holds %d entries in %d keys, want %d", len(cache.blocks), len(cache.order), anthropicThinkingCacheSize)
+	}
+	if cache.lookup(call("toolu_0")) != nil || cache.lookup(call(fmt.Sprint("toolu_", anthropicThinkingCacheSize+4))) == nil {
+		t.Error("the cache didn't drop the oldest entries first")
+	}
+
+	last := call(fmt.Sprint("toolu_", anthropicThinkingCacheSize+4))
+	cache.forget([]openrouter.ChatCompletionMessage{{Role: openrouter.ChatMessageRoleUser}, last})
+	if cache.lookup(last) != nil || len(cache.order) != anthropicThinkingCacheSize-1 {
+		t.Errorf("forget kept the entry: %d keys", len(cache.order))
+	}
+}
+
+func TestThinkingCacheConcurrent(t *testing.T) {
+	cache := &thinkingCache{blocks: map[string][]anthropicBlock{}}
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				id := fmt.Sprintf("toolu_%d_%d", i, j)
+				message := openrouter.ChatCompletionMessage{Role: openrouter.ChatMessageRoleAssistant, ToolCalls: []openrouter.ToolCall{{ID: id}}}
+				cache.remember([]anthropicBlock{{Type: "thinking", Signature: "sig"}, {Type: "tool_use", ID: id}})
+				cache.lookup(message)
+				cache.forget([]openrouter.ChatCompletionMessage{message})
+			}
+		}()
+	}
+	wg.Wait()
+	if len(cache.blocks) != 0 || len(cache.order) != 0 {
+		t.Errorf("%d entries left after every call was answered", len(cache.blocks))
+	}
+}
@@ -0,0 +1,323 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// Gemini talks to the Gemini generateContent API
+type Gemini struct {
+	apiKey  string
+	baseURL string
+}
+
+// NewGemini returns a backend for the Gemini API at baseURL, e.g. https://generativelanguage.googleapis.com/v1beta
+func NewGemini(apiKey string, baseURL string) *Gemini {
+	return &Gemini{apiKey: apiKey, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+type geminiFunctionCall struct {
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent `json:"contents"`
+	Tools             []geminiTool    `json:"tools,omitempty"`
+	GenerationConfig  map[string]any  `json:"generationConfig,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func (p *Gemini) url(model string, method string) string {
+	return p.baseURL + "/models/" + url.PathEscape(model) + ":" + method
+}
+
+func (p *Gemini) headers() map[string]string {
+	return map[string]string{"x-goog-api-key": p.apiKey}
+}
+
+// request translates the history: Gemini has no tool call IDs, so tool results are matched
+// to their calls by function name and sent back as functionResponse parts.
+func (p *Gemini) request(req Request) geminiRequest {
+	var system []geminiPart
+	var contents []geminiContent
+
+	appendParts := func(role string, parts ...geminiPart) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	for _, message := range req.Messages {
+		switch message.Role {
+		case openrouter.ChatMessageRoleSystem:
+			system = append(system, geminiPart{Text: message.Content.Text})
+		case openrouter.ChatMessageRoleUser:
+			appendParts("user", geminiPart{Text: message.Content.Text})
+		case openrouter.ChatMessageRoleAssistant:
+			var parts []geminiPart
+			if message.Content.Text != "" {
+				parts = append(parts, geminiPart{Text: message.Content.Text})
+			}
+			for _, call := range message.ToolCalls {
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: call.Function.Name,
+					Args: toolArguments(call.Function.Arguments),
+				}})
+			}
+			appendParts("model", parts...)
+		case openrouter.ChatMessageRoleTool:
+			response := map[string]any{}
+			if err := json.Unmarshal([]byte(message.Content.Text), &response); err != nil {
+				response = map[string]any{"result": message.Content.Text}
+			}
+			appendParts("user", geminiPart{FunctionResponse: &geminiFunctionResponse{
+				Name:     toolNameForCall(req.Messages, message.ToolCallID),
+				Response: response,
+			}})
+		}
+	}
+
+	var declarations []geminiFunctionDeclaration
+	for _, tool := range req.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		declaration := geminiFunctionDeclaration{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+		}
+		// Gemini rejects object schemas without properties, so parameterless tools omit them
+		if params := toolParameters(tool); hasProperties(params) {
+			declaration.Parameters = geminiSchema(params)
+		}
+		declarations = append(declarations, declaration)
+	}
+
+	request := geminiRequest{
+		Contents: contents,
+		GenerationConfig: map[string]any{
+			"thinkingConfig": map[string]any{"includeThoughts": true},
+		},
+	}
+	if len(system) > 0 {
+		request.SystemInstruction = &geminiContent{Parts: system}
+	}
+	if len(declarations) > 0 {
+		request.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+	}
+	return request
+}
+
+// geminiSchemaKeys are the parts of a JSON schema Gemini accepts in function parameters
+var geminiSchemaKeys = map[string]bool{
+	"type": true, "format": true, "title": true, "description": true, "nullable": true, "enum": true,
+	"properties": true, "required": true, "items": true, "anyOf": true, "minItems": true, "maxItems": true,
+	"minimum": true, "maximum": true, "minLength": true, "maxLength": true, "pattern": true,
+}
+
+// geminiSchema drops the parts of a schema Gemini rejects, such as additionalProperties or $schema, which
+// schemas of MCP servers often have. A type list with null becomes nullable.
+func geminiSchema(schema json.RawMessage) json.RawMessage {
+	var value map[string]any
+	if err := json.Unmarshal(schema, &value); err != nil {
+		return schema
+	}
+	encoded, err := json.Marshal(cleanGeminiSchema(value))
+	if err != nil {
+		return schema
+	}
+	return encoded
+}
+
+func cleanGeminiSchema(schema map[string]any) map[string]any {
+	cleaned := map[string]any{}
+	for key, value := range schema {
+		if !geminiSchemaKeys[key] {
+			continue
+		}
+		switch key {
+		case "type":
+			if types, ok := value.([]any); ok {
+				var others []any
+				for _, name := range types {
+					if name == "null" {
+						cleaned["nullable"] = true
+					} else {
+						others = append(others, name)
+					}
+				}
+				if len(others) != 1 {
+					// Gemini needs one type; leaving it out accepts any
+					continue
+				}
+				value = others[0]
+			}
+		case "properties":
+			properties, _ := value.(map[string]any)
+			cleanedProperties := map[string]any{}
+			for name, property := range properties {
+				if property, ok := property.(map[string]any); ok {
+					cleanedProperties[name] = cleanGeminiSchema(property)
+				}
+			}
+			value = cleanedProperties
+		case "items":
+			if items, ok := value.(map[string]any); ok {
+				value = cleanGeminiSchema(items)
+			}
+		case "anyOf":
+			alternatives, _ := value.([]any)
+			var cleanedAlternatives []any
+			for _, alternative := range alternatives {
+				if alternative, ok := alternative.(map[string]any); ok {
+					cleanedAlternatives = append(cleanedAlternatives, cleanGeminiSchema(alternative))
+				}
+			}
+			value = cleanedAlternatives
+		}
+		cleaned[key] = value
+	}
+	return cleaned
+}
+
+// chunk converts a (partial) response into a stream chunk, numbering tool calls from toolIndex
+func (r geminiResponse) chunk(toolIndex *int) StreamMessage {
+	var chunk StreamMessage
+	if len(r.Candidates) > 0 {
+		for _, part := range r.Candidates[0].Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				arguments, _ := json.Marshal(part.FunctionCall.Args)
+				chunk.ToolCalls = append(chunk.ToolCalls, openrouter.ToolCall{
+					Index:    intPtr(*toolIndex),
+					ID:       newToolCallID(),
+					Type:     openrouter.ToolTypeFunction,
+					Function: openrouter.FunctionCall{Name: part.FunctionCall.Name, Arguments: string(arguments)},
+				})
+				*toolIndex++
+			case part.Thought:
+				chunk.Reasoning += part.Text
+			default:
+				chunk.Content += part.Text
+			}
+		}
+	}
+
+	usage := r.UsageMetadata
+	if usage.PromptTokenCount > 0 {
+		completion := usage.CandidatesTokenCount + usage.ThoughtsTokenCount
+		chunk.Usage = &openrouter.Usage{
+			PromptTokens:           usage.PromptTokenCount,
+			CompletionTokens:       completion,
+			TotalTokens:            usage.PromptTokenCount + completion,
+			CompletionTokenDetails: openrouter.CompletionTokenDetails{ReasoningTokens: usage.ThoughtsTokenCount},
+			PromptTokenDetails:     openrouter.PromptTokenDetails{CachedTokens: usage.CachedContentTokenCount},
+		}
+	}
+	return chunk
+}
+
+func (p *Gemini) Chat(ctx context.Context, req Request) (Response, error) {
+	resp, err := postJSON(ctx, p.url(req.Model, "generateContent"), p.headers(), p.request(req))
+	if err != nil {
+		return Response{}, fmt.Errorf("Gemini error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Response{}, fmt.Errorf("Gemini error: %v", err)
+	}
+	if len(result.Candidates) == 0 {
+		return Response{}, fmt.Errorf("API returned no choices")
+	}
+
+	var acc StreamAccumulator
+	toolIndex := 0
+	acc.Add(result.chunk(&toolIndex))
+	return Response{Message: acc.Message(), Usage: acc.Usage}, nil
+}
+
+func (p *Gemini) Stream(ctx context.Context, req Request) (<-chan StreamMessage, error) {
+	resp, err := postJSON(ctx, p.url(req.Model, "streamGenerateContent")+"?alt=sse", p.headers(), p.request(req))
+	if err != nil {
+		return nil, fmt.Errorf("Gemini error: %v", err)
+	}
+
+	chunks := make(chan StreamMessage)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		toolIndex := 0
+		err := readSSE(resp.Body, func(_ string, data []byte) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var result geminiResponse
+			if err := json.Unmarshal(data, &result); err != nil {
+				return err
+			}
+			send(ctx, chunks, result.chunk(&toolIndex))
+			return nil
+		})
+		if err != nil {
+			send(ctx, chunks, StreamMessage{Error: fmt.Errorf("Gemini error: %v", err)})
+			return
+		}
+
+		send(ctx, chunks, StreamMessage{Done: true})
+	}()
+
+	return chunks, nil
+}
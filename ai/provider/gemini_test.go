@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+func TestGeminiRequest(t *testing.T) {
+	server, requests := newTestServer(t, "application/json", `{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]}}]}`)
+
+	response, err := NewGemini("g-key", server.URL+"/").Chat(context.Background(), Request{Model: "gemini-2.5-pro", Messages: testHistory, Tools: testTools})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Message.Content.Text != "ok" {
+		t.Errorf("content = %q", response.Message.Content.Text)
+	}
+	request := (*requests)[0]
+	if request.Path != "/models/gemini-2.5-pro:generateContent" || request.Header.Get("x-goog-api-key") != "g-key" {
+		t.Errorf("request to %s with headers %v", request.Path, request.Header)
+	}
+	checkFields(t, request.Body, []fieldCheck{
+		{[]any{"systemInstruction"}, map[string]any{"parts": []any{map[string]any{"text": "Be brief."}}}},
+		{[]any{"contents", 0}, map[string]any{"role": "user", "parts": []any{map[string]any{"text": "What is in go.mod?"}}}},
+		{[]any{"contents", 1}, map[string]any{"role": "model", "parts": []any{
+			map[string]any{"text": "Let me look."},
+			map[string]any{"functionCall": map[string]any{"name": "read_file", "args": map[string]any{"path": "go.mod"}}},
+		}}},
+		// The result goes back under the name of the call it answers
+		{[]any{"contents", 2}, map[string]any{"role": "user", "parts": []any{
+			map[string]any{"functionResponse": map[string]any{"name": "read_file", "response": map[string]any{"Result": "module x"}}},
+		}}},
+		{[]any{"tools", 0, "functionDeclarations", 0, "parameters", "required"}, []string{"path"}},
+		// Gemini refuses objects without properties, so those tools have no parameters
+		{[]any{"tools", 0, "functionDeclarations", 1}, map[string]any{"name": "get_current_directory"}},
+		{[]any{"generationConfig", "thinkingConfig", "includeThoughts"}, true},
+	})
+}
+
+func TestGeminiToolResultText(t *testing.T) {
+	history := append(append([]openrouter.ChatCompletionMessage(nil), testHistory[:3]...), openrouter.ChatCompletionMessage{
+		Role: openrouter.ChatMessageRoleTool, ToolCallID: "call_1", Content: openrouter.Content{Text: "Error: not found"},
+	})
+	request := (&Gemini{}).request(Request{Messages: history})
+	if got := request.Contents[2].Parts[0].FunctionResponse; got == nil || got.Response["result"] != "Error: not found" {
+		t.Errorf("function response = %+v, want the text under result", got)
+	}
+}
+
+func TestGeminiSchema(t *testing.T) {
+	schema := geminiSchema([]byte(`{"$schema":"x","type":"object","additionalProperties":false,` +
+		`"properties":{"tags":{"type":["array","null"],"items":{"type":"string","default":"a"}}}}`))
+	want := `{"properties":{"tags":{"items":{"type":"string"},"nullable":true,"type":"array"}},"type":"object"}`
+	if string(schema) != want {
+		t.Errorf("geminiSchema = %s, want %s", schema, want)
+	}
+}
+
+func TestGeminiStream(t *testing.T) {
+	body := sse(
+		[2]string{"", `{"candidates":[{"content":{"role":"model","parts":[{"text":"Looking at ","thought":true}]}}]}`},
+		[2]string{"", `{"candidates":[{"content":{"role":"model","parts":[{"text":"the files.","thought":true},{"text":"Reading "}]}}]}`},
+		[2]string{"", `{"candidates":[{"content":{"role":"model","parts":[{"text":"two."},` +
+			`{"functionCall":{"name":"read_file","args":{"path":"a"}}},{"functionCall":{"name":"read_file","args":{"path":"b"}}}]}}],` +
+			`"usageMetadata":{"promptTokenCount":100,"candidatesTokenCount":20,"thoughtsTokenCount":30,"cachedContentTokenCount":60}}`},
+	)
+	server, requests := newTestServer(t, "text/event-stream", body)
+	backend := NewGemini("g-key", server.URL)
+	history := []openrouter.ChatCompletionMessage{{Role: openrouter.ChatMessageRoleUser, Content: openrouter.Content{Text: "Read a and b"}}}
+
+	chunks, err := backend.Stream(context.Background(), Request{Model: "gemini-2.5-flash", Messages: history})
+	message, usage := collectStream(t, chunks, err)
+	if request := (*requests)[0]; request.Path != "/models/gemini-2.5-flash:streamGenerateContent" || request.Query != "alt=sse" {
+		t.Errorf("request to %s?%s", request.Path, request.Query)
+	}
+	if message.Content.Text != "Reading two." || message.Reasoning == nil || *message.Reasoning != "Looking at the files." {
+		t.Errorf("message = %q with reasoning %v", message.Content.Text, message.Reasoning)
+	}
+	if len(message.ToolCalls) != 2 || message.ToolCalls[0].Function.Arguments != `{"path":"a"}` || message.ToolCalls[1].Function.Arguments != `{"path":"b"}` {
+		t.Fatalf("tool calls = %+v", message.ToolCalls)
+	}
+	if message.ToolCalls[0].ID == "" || message.ToolCalls[0].ID == message.ToolCalls[1].ID {
+		t.Errorf("tool call IDs %q and %q, want two different ones", message.ToolCalls[0].ID, message.ToolCalls[1].ID)
+	}
+	want := openrouter.Usage{
+		PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150,
+		CompletionTokenDetails: openrouter.CompletionTokenDetails{ReasoningTokens: 30},
+		PromptTokenDetails:     openrouter.PromptTokenDetails{CachedTokens: 60},
+	}
+	if usage == nil || *usage != want {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
+
+	// The results of both calls go back together, under the names of their calls
+	history = append(history, message,
+		openrouter.ChatCompletionMessage{Role: openrouter.ChatMessageRoleTool, ToolCallID: message.ToolCalls[0].ID, Content: openrouter.Content{Text: `{"Result":"A"}`}},
+		openrouter.ChatCompletionMessage{Role: openrouter.ChatMessageRoleTool, ToolCallID: message.ToolCalls[1].ID, Content: openrouter.Content{Text: `{"Result":"B"}`}},
+	)
+	chunks, err = backend.Stream(context.Background(), Request{Model: "gemini-2.5-flash", Messages: history})
+	collectStream(t, chunks, err)
+	checkFields(t, (*requests)[1].Body, []fieldCheck{
+		{[]any{"contents", 1, "parts", 0}, map[string]any{"text": "Reading two."}},
+		{[]any{"contents", 1, "parts", 2, "functionCall", "args"}, map[string]any{"path": "b"}},
+		{[]any{"contents", 2, "role"}, "user"},
+		{[]any{"contents", 2, "parts", 0, "functionResponse"}, map[string]any{"name": "read_file", "response": map[string]any{"Result": "A"}}},
+		{[]any{"contents", 2, "parts", 1, "functionResponse"}, map[string]any{"name": "read_file", "response": map[string]any{"Result": "B"}}},
+	})
+}
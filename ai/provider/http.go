@@ -0,0 +1,156 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// statusError is a response with a non-2xx status
+type statusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// postJSON sends body as JSON and returns the response, turning non-2xx statuses into errors
+func postJSON(ctx context.Context, url string, headers map[string]string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return do(req, headers)
+}
+
+// get sends a GET request and returns the response, turning non-2xx statuses into errors
+func get(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(req, headers)
+}
+
+// do sets the headers and sends req, turning non-2xx statuses into errors
+func do(req *http.Request, headers map[string]string) (*http.Response, error) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		errorBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &statusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(errorBody))}
+	}
+	return resp, nil
+}
+
+// readSSE calls fn for every server-sent event in body until it ends or fn returns an error
+func readSSE(body io.Reader, fn func(event string, data []byte) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var event string
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				if err := fn(event, data.Bytes()); err != nil {
+					return err
+				}
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if data.Len() > 0 {
+		return fn(event, data.Bytes())
+	}
+	return nil
+}
+
+// toolParameters returns the JSON schema of a tool's parameters
+func toolParameters(tool openrouter.Tool) json.RawMessage {
+	if tool.Function == nil || tool.Function.Parameters == nil {
+		return json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	schema, err := json.Marshal(tool.Function.Parameters)
+	if err != nil {
+		return json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	return schema
+}
+
+// hasProperties reports whether an object schema declares any properties
+func hasProperties(schema json.RawMessage) bool {
+	var object struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	return json.Unmarshal(schema, &object) == nil && len(object.Properties) > 0
+}
+
+// toolArguments decodes the JSON arguments of a tool call into an object
+func toolArguments(arguments string) map[string]any {
+	args := map[string]any{}
+	if arguments != "" {
+		_ = json.Unmarshal([]byte(arguments), &args)
+	}
+	return args
+}
+
+// toolNameForCall finds the name of the tool call a tool result answers
+func toolNameForCall(messages []openrouter.ChatCompletionMessage, toolCallID string) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		for _, call := range messages[i].ToolCalls {
+			if call.ID == toolCallID {
+				return call.Function.Name
+			}
+		}
+	}
+	return ""
+}
+
+// newToolCallID makes an ID for backends that don't assign one to tool calls
+func newToolCallID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
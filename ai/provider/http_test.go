@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	openrouter "github.com/revrost/go-openrouter"
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+// recordedRequest is what a test server received
+type recordedRequest struct {
+	Path   string
+	Query  string
+	Header http.Header
+	Body   map[string]any
+}
+
+// newTestServer answers the requests with the bodies in turn, repeating the last one, and records the requests it gets
+func newTestServer(t *testing.T, contentType string, bodies ...string) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		recorded := recordedRequest{Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header.Clone()}
+		if err := json.Unmarshal(data, &recorded.Body); err != nil {
+			t.Errorf("the request body isn't JSON: %v\n%s", err, data)
+		}
+		requests = append(requests, recorded)
+		w.Header().Set("Content-Type", contentType)
+		io.WriteString(w, bodies[min(len(requests), len(bodies))-1])
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// sse writes server-sent events, each given as event name and data; an empty name leaves out the event line
+func sse(events ...[2]string) string {
+	var b strings.Builder
+	for _, event := range events {
+		if event[0] != "" {
+			b.WriteString("event: " + event[0] + "\n")
+		}
+		b.WriteString("data: " + event[1] + "\n\n")
+	}
+	return b.String()
+}
+
+// testHistory is a conversation with a system prompt, a tool call and its result
+var testHistory = []openrouter.ChatCompletionMessage{
+	{Role: openrouter.ChatMessageRoleSystem, Content: openrouter.Content{Text: "Be brief."}},
+	{Role: openrouter.ChatMessageRoleUser, Content: openrouter.Content{Text: "What is in go.mod?"}},
+	{
+		Role:    openrouter.ChatMessageRoleAssistant,
+		Content: openrouter.Content{Text: "Let me look."},
+		ToolCalls: []openrouter.ToolCall{{
+			ID:       "call_1",
+			Type:     openrouter.ToolTypeFunction,
+			Function: openrouter.FunctionCall{Name: "read_file", Arguments: `{"path":"go.mod"}`},
+		}},
+	},
+	{Role: openrouter.ChatMessageRoleTool, ToolCallID: "call_1", Content: openrouter.Content{Text: `{"Result":"module x"}`}},
+}
+
+// testTools are a tool with arguments and one without
+var testTools = []openrouter.Tool{
+	{Type: openrouter.ToolTypeFunction, Function: &openrouter.FunctionDefinition{
+		Name:        "read_file",
+		Description: "Read a file",
+		Parameters: jsonschema.Definition{
+			Type:       jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{"path": {Type: jsonschema.String}},
+			Required:   []string{"path"},
+		},
+	}},
+	{Type: openrouter.ToolTypeFunction, Function: &openrouter.FunctionDefinition{
+		Name:       "get_current_directory",
+		Parameters: jsonschema.Definition{Type: jsonschema.Object, Properties: map[string]jsonschema.Definition{}},
+	}},
+}
+
+// collectStream reads a stream to the end into a message, failing the test on a stream error
+func collectStream(t *testing.T, chunks <-chan StreamMessage, err error) (openrouter.ChatCompletionMessage, *openrouter.Usage) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	var acc StreamAccumulator
+	done := false
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			t.Fatalf("stream error: %v", chunk.Error)
+		}
+		acc.Add(chunk)
+		done = done || chunk.Done
+	}
+	if !done {
+		t.Error("the stream ended without a Done chunk")
+	}
+	return acc.Message(), acc.Usage
+}
+
+// field walks into decoded JSON along keys and array indexes
+func field(value any, path ...any) any {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			object, _ := value.(map[string]any)
+			value = object[step]
+		case int:
+			array, _ := value.([]any)
+			if step >= len(array) {
+				return nil
+			}
+			value = array[step]
+		}
+	}
+	return value
+}
+
+// checkFields compares fields of decoded JSON, given as path and expected value, after a JSON round trip of the
+// expected value so numbers and maps compare equal
+func checkFields(t *testing.T, body map[string]any, checks []fieldCheck) {
+	t.Helper()
+	for _, check := range checks {
+		encoded, _ := json.Marshal(check.want)
+		var want any
+		json.Unmarshal(encoded, &want)
+		if got := field(body, check.path...); !reflect.DeepEqual(got, want) {
+			gotJSON, _ := json.Marshal(got)
+			t.Errorf("%v = %s, want %s", check.path, gotJSON, encoded)
+		}
+	}
+}
+
+type fieldCheck struct {
+	path []any
+	want any
+}
+
+func TestReadSSE(t *testing.T) {
+	input := "event: one\ndata: {\"a\":1}\n\n: a comment\ndata: line 1\ndata: line 2\n\nevent: last\ndata: end"
+	var got [][2]string
+	err := readSSE(strings.NewReader(input), func(event string, data []byte) error {
+		got = append(got, [2]string{event, string(data)})
+		return nil
+	})
+	want := [][2]string{{"one", `{"a":1}`}, {"", "line 1\nline 2"}, {"last", "end"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("readSSE = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewOllama(server.URL).Stream(context.Background(), Request{Model: "m"})
+	if err == nil || !strings.Contains(err.Error(), `status 401: {"error":"invalid api key"}`) {
+		t.Errorf("Stream returned %v, want the status and body", err)
+	}
+}
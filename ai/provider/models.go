@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// ErrNoCatalog is returned by ListModels for providers whose model list doesn't tell enough about the models,
+// such as their context window, so the built-in list is used for them
+var ErrNoCatalog = errors.New("the provider has no model catalog")
+
+// ListModels fetches the models a provider offers: the OpenRouter catalog, or the models installed on the Ollama server
+func ListModels(ctx context.Context, providerID string) ([]config.Model, error) {
+	switch providerID {
+	case config.ProviderOpenRouter.ID:
+		return listOpenRouterModels(ctx)
+	case config.ProviderOllama.ID:
+		return NewOllama(config.BaseURL(providerID)).listModels(ctx)
+	default:
+		return nil, ErrNoCatalog
+	}
+}
+
+func listOpenRouterModels(ctx context.Context) ([]config.Model, error) {
+	// The catalog is public, so the key is only sent when there is one
+	apiKey, _ := config.APIKey(config.ProviderOpenRouter.ID)
+	listed, err := openrouter.NewClient(apiKey).ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing OpenRouter models: %w", err)
+	}
+
+	models := make([]config.Model, 0, len(listed))
+	for _, model := range listed {
+		converted := config.Model{
+			ID:          model.ID,
+			Name:        model.Name,
+			Description: model.Description,
+			Tools:       slices.Contains(model.SupportedParameters, "tools"),
+			Reasoning:   slices.Contains(model.SupportedParameters, "reasoning"),
+		}
+		if model.ContextLength != nil {
+			converted.ContextWindow = int(*model.ContextLength)
+		} else if model.TopProvider.ContextLength != nil {
+			converted.ContextWindow = int(*model.TopProvider.ContextLength)
+		}
+		// Prices are strings of dollars per token, e.g. "0.0000003"
+		prompt, promptErr := strconv.ParseFloat(model.Pricing.Prompt, 64)
+		completion, completionErr := strconv.ParseFloat(model.Pricing.Completion, 64)
+		if promptErr == nil && completionErr == nil && prompt >= 0 && completion >= 0 {
+			converted.Pricing = &config.Pricing{Prompt: prompt * 1_000_000, Completion: completion * 1_000_000}
+		}
+		models = append(models, converted)
+	}
+	return models, nil
+}
+
+type ollamaTags struct {
+	Models []struct {
+		Name    string `json:"name"`
+		Details struct {
+			Family        string `json:"family"`
+			ParameterSize string `json:"parameter_size"`
+		} `json:"details"`
+	} `json:"models"`
+}
+
+type ollamaShow struct {
+	Capabilities []string       `json:"capabilities"`
+	ModelInfo    map[string]any `json:"model_info"`
+}
+
+// listModels returns the models pulled to the server, with the capabilities /api/show reports for each
+func (p *Ollama) listModels(ctx context.Context) ([]config.Model, error) {
+	resp, err := get(ctx, p.host+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing Ollama models: %w", err)
+	}
+	defer resp.Body.Close()
+	var tags ollamaTags
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("listing Ollama models: %w", err)
+	}
+
+	models := make([]config.Model, 0, len(tags.Models))
+	for _, tag := range tags.Models {
+		model := config.Model{
+			ID:          tag.Name,
+			Name:        tag.Name,
+			Description: strings.TrimSpace(tag.Details.Family + " " + tag.Details.ParameterSize + " running locally through Ollama"),
+			Pricing:     &config.Pricing{},
+		}
+		if show, err := p.show(ctx, tag.Name); err == nil {
+			model.Tools = slices.Contains(show.Capabilities, "tools")
+			model.Reasoning = slices.Contains(show.Capabilities, "thinking")
+			// The key is prefixed with the architecture, e.g. llama.context_length
+			for key, value := range show.ModelInfo {
+				if length, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+					model.ContextWindow = int(length)
+				}
+			}
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+func (p *Ollama) show(ctx context.Context, name string) (ollamaShow, error) {
+	resp, err := postJSON(ctx, p.host+"/api/show", nil, map[string]string{"model": name})
+	if err != nil {
+		return ollamaShow{}, err
+	}
+	defer resp.Body.Close()
+	var show ollamaShow
+	err = json.NewDecoder(resp.Body).Decode(&show)
+	return show, err
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// Ollama talks to a local Ollama server through its native chat API
+type Ollama struct {
+	host string
+}
+
+// NewOllama returns a backend for the Ollama server at host, e.g. http://localhost:11434
+func NewOllama(host string) *Ollama {
+	return &Ollama{host: strings.TrimSuffix(host, "/")}
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string            `json:"model"`
+	Messages []ollamaMessage   `json:"messages"`
+	Tools    []openrouter.Tool `json:"tools,omitempty"`
+	Stream   bool              `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// request translates the history; Ollama accepts the OpenAI tool schema as is
+func (p *Ollama) request(req Request, stream bool) ollamaRequest {
+	messages := make([]ollamaMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		converted := ollamaMessage{
+			Role:    message.Role,
+			Content: message.Content.Text,
+		}
+		for _, call := range message.ToolCalls {
+			var toolCall ollamaToolCall
+			toolCall.Function.Name = call.Function.Name
+			toolCall.Function.Arguments = toolArguments(call.Function.Arguments)
+			converted.ToolCalls = append(converted.ToolCalls, toolCall)
+		}
+		if message.Role == openrouter.ChatMessageRoleTool {
+			converted.ToolName = toolNameForCall(req.Messages, message.ToolCallID)
+		}
+		messages = append(messages, converted)
+	}
+
+	return ollamaRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Tools:    req.Tools,
+		Stream:   stream,
+	}
+}
+
+// chunk converts a (partial) response into a stream chunk, numbering tool calls from toolIndex
+func (r ollamaResponse) chunk(toolIndex *int) StreamMessage {
+	chunk := StreamMessage{
+		Content:   r.Message.Content,
+		Reasoning: r.Message.Thinking,
+	}
+	for _, call := range r.Message.ToolCalls {
+		arguments, _ := json.Marshal(call.Function.Arguments)
+		chunk.ToolCalls = append(chunk.ToolCalls, openrouter.ToolCall{
+			Index:    intPtr(*toolIndex),
+			ID:       newToolCallID(),
+			Type:     openrouter.ToolTypeFunction,
+			Function: openrouter.FunctionCall{Name: call.Function.Name, Arguments: string(arguments)},
+		})
+		*toolIndex++
+	}
+	if r.Done {
+		chunk.Usage = &openrouter.Usage{
+			PromptTokens:     r.PromptEvalCount,
+			CompletionTokens: r.EvalCount,
+			TotalTokens:      r.PromptEvalCount + r.EvalCount,
+		}
+	}
+	return chunk
+}
+
+func (p *Ollama) Chat(ctx context.Context, req Request) (Response, error) {
+	resp, err := postJSON(ctx, p.host+"/api/chat", nil, p.request(req, false))
+	if err != nil {
+		return Response{}, fmt.Errorf("Ollama error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Response{}, fmt.Errorf("Ollama error: %v", err)
+	}
+	if result.Error != "" {
+		return Response{}, fmt.Errorf("Ollama error: %s", result.Error)
+	}
+
+	var acc StreamAccumulator
+	toolIndex := 0
+	acc.Add(result.chunk(&toolIndex))
+	return Response{Message: acc.Message(), Usage: acc.Usage}, nil
+}
+
+func (p *Ollama) Stream(ctx context.Context, req Request) (<-chan StreamMessage, error) {
+	resp, err := postJSON(ctx, p.host+"/api/chat", nil, p.request(req, true))
+	if err != nil {
+		return nil, fmt.Errorf("Ollama error: %v", err)
+	}
+
+	chunks := make(chan StreamMessage)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		// The stream is newline-delimited JSON, one response object per line
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		toolIndex := 0
+		for scanner.Scan() {
+			if len(strings.TrimSpace(scanner.Text())) == 0 {
+				continue
+			}
+			var result ollamaResponse
+			if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+				send(ctx, chunks, StreamMessage{Error: fmt.Errorf("Ollama error: %v", err)})
+				return
+			}
+			if result.Error != "" {
+				send(ctx, chunks, StreamMessage{Error: fmt.Errorf("Ollama error: %s", result.Error)})
+				return
+			}
+			if !send(ctx, chunks, result.chunk(&toolIndex)) {
+				return
+			}
+			if result.Done {
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(ctx, chunks, StreamMessage{Error: fmt.Errorf("Ollama error: %v", err)})
+			return
+		}
+
+		send(ctx, chunks, StreamMessage{Done: true})
+	}()
+
+	return chunks, nil
+}
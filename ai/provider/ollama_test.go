@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+func TestOllamaRequest(t *testing.T) {
+	server, requests := newTestServer(t, "application/json",
+		`{"message":{"role":"assistant","content":"ok"},"done":true,"prompt_eval_count":12,"eval_count":3}`)
+
+	response, err := NewOllama(server.URL+"/").Chat(context.Background(), Request{Model: "qwen3:8b", Messages: testHistory, Tools: testTools})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Message.Content.Text != "ok" || response.Usage == nil || response.Usage.TotalTokens != 15 {
+		t.Errorf("response = %q with usage %+v", response.Message.Content.Text, response.Usage)
+	}
+	request := (*requests)[0]
+	if request.Path != "/api/chat" {
+		t.Errorf("request to %s", request.Path)
+	}
+	checkFields(t, request.Body, []fieldCheck{
+		{[]any{"model"}, "qwen3:8b"},
+		{[]any{"stream"}, false},
+		{[]any{"messages", 0}, map[string]any{"role": "system", "content": "Be brief."}},
+		{[]any{"messages", 2}, map[string]any{"role": "assistant", "content": "Let me look.", "tool_calls": []any{
+			map[string]any{"function": map[string]any{"name": "read_file", "arguments": map[string]any{"path": "go.mod"}}},
+		}}},
+		// Ollama has no tool call IDs, the result names its tool
+		{[]any{"messages", 3}, map[string]any{"role": "tool", "content": `{"Result":"module x"}`, "tool_name": "read_file"}},
+		{[]any{"tools", 0, "type"}, "function"},
+		{[]any{"tools", 0, "function", "name"}, "read_file"},
+		{[]any{"tools", 0, "function", "parameters", "required"}, []string{"path"}},
+	})
+}
+
+func TestOllamaStream(t *testing.T) {
+	body := strings.Join([]string{
+		`{"message":{"role":"assistant","content":"","thinking":"Which file? "}}`,
+		`{"message":{"role":"assistant","content":"","thinking":"go.mod."}}`,
+		``,
+		`{"message":{"role":"assistant","content":"Reading it."}}`,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"read_file","arguments":{"path":"go.mod"}}}]}}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":40,"eval_count":9}`,
+	}, "\n")
+	server, requests := newTestServer(t, "application/x-ndjson", body)
+	backend := NewOllama(server.URL)
+	history := []openrouter.ChatCompletionMessage{{Role: openrouter.ChatMessageRoleUser, Content: openrouter.Content{Text: "Read go.mod"}}}
+
+	chunks, err := backend.Stream(context.Background(), Request{Model: "qwen3:8b", Messages: history})
+	message, usage := collectStream(t, chunks, err)
+	if message.Content.Text != "Reading it." || message.Reasoning == nil || *message.Reasoning != "Which file? go.mod." {
+		t.Errorf("message = %q with reasoning %v", message.Content.Text, message.Reasoning)
+	}
+	if len(message.ToolCalls) != 1 || message.ToolCalls[0].ID == "" || message.ToolCalls[0].Function.Arguments != `{"path":"go.mod"}` {
+		t.Fatalf("tool calls = %+v", message.ToolCalls)
+	}
+	if usage == nil || usage.PromptTokens != 40 || usage.CompletionTokens != 9 || usage.TotalTokens != 49 {
+		t.Errorf("usage = %+v", usage)
+	}
+
+	history = append(history, message, openrouter.ChatCompletionMessage{
+		Role: openrouter.ChatMessageRoleTool, ToolCallID: message.ToolCalls[0].ID, Content: openrouter.Content{Text: "module x"},
+	})
+	chunks, err = backend.Stream(context.Background(), Request{Model: "qwen3:8b", Messages: history})
+	collectStream(t, chunks, err)
+	checkFields(t, (*requests)[1].Body, []fieldCheck{
+		{[]any{"stream"}, true},
+		{[]any{"messages", 1, "tool_calls", 0, "function", "arguments"}, map[string]any{"path": "go.mod"}},
+		{[]any{"messages", 2}, map[string]any{"role": "tool", "content": "module x", "tool_name": "read_file"}},
+	})
+}
+
+func TestOllamaStreamError(t *testing.T) {
+	body := `{"message":{"role":"assistant","content":"Hi"}}` + "\n" + `{"error":"model runner has unexpectedly stopped"}`
+	server, _ := newTestServer(t, "application/x-ndjson", body)
+
+	chunks, err := NewOllama(server.URL).Stream(context.Background(), Request{Model: "qwen3:8b", Messages: testHistory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamErr error
+	done := false
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			streamErr = chunk.Error
+		}
+		done = done || chunk.Done
+	}
+	if streamErr == nil || streamErr.Error() != "Ollama error: model runner has unexpectedly stopped" || done {
+		t.Errorf("stream error = %v, done = %v, want the error and no Done chunk", streamErr, done)
+	}
+}
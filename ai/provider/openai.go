@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// OpenAICompatible talks to any chat completions API that follows the OpenAI wire format.
+// OpenRouter is one of them, with extensions for reasoning and usage accounting.
+type OpenAICompatible struct {
+	client     *openrouter.Client
+	openRouter bool
+}
+
+// NewOpenAI returns a backend for the OpenAI API or any server compatible with it
+func NewOpenAI(apiKey string, baseURL string) *OpenAICompatible {
+	clientConfig := openrouter.DefaultConfig(apiKey)
+	clientConfig.BaseURL = baseURL
+
+	return &OpenAICompatible{
+		client: openrouter.NewClientWithConfig(*clientConfig),
+	}
+}
+
+func (p *OpenAICompatible) request(req Request) openrouter.ChatCompletionRequest {
+	messages := req.Messages
+	if !p.openRouter {
+		// The reasoning fields are OpenRouter extensions that other servers reject
+		messages = make([]openrouter.ChatCompletionMessage, len(req.Messages))
+		for i, message := range req.Messages {
+			message.Reasoning = nil
+			message.ReasoningContent = nil
+			messages[i] = message
+		}
+	}
+
+	return openrouter.ChatCompletionRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Tools:    req.Tools,
+	}
+}
+
+func (p *OpenAICompatible) Chat(ctx context.Context, req Request) (Response, error) {
+	request := p.request(req)
+	if p.openRouter {
+		request.Usage = &openrouter.IncludeUsage{Include: true}
+	}
+
+	resp, err := p.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return Response{}, fmt.Errorf("ChatCompletion error: %v", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return Response{}, fmt.Errorf("API returned no choices")
+	}
+
+	return Response{Message: resp.Choices[0].Message, Usage: resp.Usage}, nil
+}
+
+func (p *OpenAICompatible) Stream(ctx context.Context, req Request) (<-chan StreamMessage, error) {
+	request := p.request(req)
+	request.Stream = true
+	if p.openRouter {
+		request.Usage = &openrouter.IncludeUsage{Include: true}
+	} else {
+		request.StreamOptions = &openrouter.StreamOptions{IncludeUsage: true}
+	}
+
+	stream, err := p.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("ChatCompletionStream error: %v", err)
+	}
+
+	chunks := make(chan StreamMessage)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) && ctx.Err() != nil {
+				// The client ends the stream quietly when the context is cancelled
+				send(ctx, chunks, StreamMessage{Error: ctx.Err()})
+				return
+			}
+			if errors.Is(err, io.EOF) {
+				send(ctx, chunks, StreamMessage{Done: true})
+				return
+			}
+			if err != nil {
+				send(ctx, chunks, StreamMessage{Error: fmt.Errorf("ChatCompletionStream error: %v", err)})
+				return
+			}
+
+			chunk := StreamMessage{Usage: resp.Usage}
+			if len(resp.Choices) > 0 {
+				delta := resp.Choices[0].Delta
+				chunk.Content = delta.Content
+				chunk.ToolCalls = delta.ToolCalls
+				if delta.Reasoning != nil {
+					chunk.Reasoning = *delta.Reasoning
+				} else {
+					chunk.Reasoning = delta.ReasoningContent
+				}
+			}
+			if !send(ctx, chunks, chunk) {
+				return
+			}
+		}
+	}()
+
+	return chunks, nil
+}
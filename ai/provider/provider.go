@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// Provider is a chat backend. Messages, tools and usage use the OpenRouter wire types
+// as the common format; each backend translates them to and from its own API.
+type Provider interface {
+	// Chat sends the history and waits for the complete assistant message
+	Chat(ctx context.Context, req Request) (Response, error)
+	// Stream sends the history and returns a channel of content, reasoning and tool call deltas
+	Stream(ctx context.Context, req Request) (<-chan StreamMessage, error)
+}
+
+// Request is a single model call
+type Request struct {
+	Model    string
+	Messages []openrouter.ChatCompletionMessage
+	Tools    []openrouter.Tool
+}
+
+// Response is the complete result of a model call
+type Response struct {
+	Message openrouter.ChatCompletionMessage
+	Usage   *openrouter.Usage
+}
+
+// Get returns the backend for a provider ID from config.GetAllProviders
+func Get(providerID string) (Provider, error) {
+	switch providerID {
+	case config.ProviderOllama.ID:
+		return NewOllama(config.BaseURL(providerID)), nil
+	case config.ProviderOpenRouter.ID, config.ProviderOpenAI.ID, config.ProviderAnthropic.ID, config.ProviderGemini.ID:
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", providerID)
+	}
+
+	apiKey, err := config.APIKey(providerID)
+	if err != nil {
+		return nil, err
+	}
+	switch providerID {
+	case config.ProviderOpenRouter.ID:
+		return NewOpenRouter(apiKey), nil
+	case config.ProviderOpenAI.ID:
+		return NewOpenAI(apiKey, config.BaseURL(providerID)), nil
+	case config.ProviderAnthropic.ID:
+		return NewAnthropic(apiKey, config.BaseURL(providerID)), nil
+	default:
+		return NewGemini(apiKey, config.BaseURL(providerID)), nil
+	}
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// StreamAccumulator rebuilds a complete assistant message from streamed chunks.
+type StreamAccumulator struct {
+	content   strings.Builder
+	reasoning strings.Builder
+	toolCalls []openrouter.ToolCall
+	Usage     *openrouter.Usage
+}
+
+// Add merges a chunk into the accumulated message.
+func (a *StreamAccumulator) Add(chunk StreamMessage) {
+	a.content.WriteString(chunk.Content)
+	a.reasoning.WriteString(chunk.Reasoning)
+	if chunk.Usage != nil {
+		a.Usage = chunk.Usage
+	}
+
+	for _, delta := range chunk.ToolCalls {
+		// Providers send the id and name once, then stream the arguments in pieces
+		index := len(a.toolCalls)
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		for len(a.toolCalls) <= index {
+			a.toolCalls = append(a.toolCalls, openrouter.ToolCall{Type: openrouter.ToolTypeFunction})
+		}
+
+		call := &a.toolCalls[index]
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		call.Function.Name += delta.Function.Name
+		call.Function.Arguments += delta.Function.Arguments
+	}
+}
+
+// Content returns the text received so far.
+func (a *StreamAccumulator) Content() string {
+	return a.content.String()
+}
+
+// Reasoning returns the reasoning text received so far.
+func (a *StreamAccumulator) Reasoning() string {
+	return a.reasoning.String()
+}
+
+// ToolCalls returns the tool calls received so far; arguments may still be incomplete.
+func (a *StreamAccumulator) ToolCalls() []openrouter.ToolCall {
+	return a.toolCalls
+}
+
+// Message returns the accumulated assistant message in the shape of a non-streamed response.
+func (a *StreamAccumulator) Message() openrouter.ChatCompletionMessage {
+	message := openrouter.ChatCompletionMessage{
+		Role:      openrouter.ChatMessageRoleAssistant,
+		Content:   openrouter.Content{Text: a.content.String()},
+		ToolCalls: a.toolCalls,
+	}
+	if a.reasoning.Len() > 0 {
+		reasoning := a.reasoning.String()
+		message.Reasoning = &reasoning
+	}
+	return message
+}
+
+// send delivers a chunk unless the caller gave up on the stream
+func send(ctx context.Context, chunks chan<- StreamMessage, chunk StreamMessage) bool {
+	select {
+	case chunks <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -1,10 +1,15 @@
 package provider
 
+import openrouter "github.com/revrost/go-openrouter"
+
 // StreamMessage represents a chunk of response or an error
 type StreamMessage struct {
-	Content string
-	Error   error
-	Done    bool
+	Content   string
+	Reasoning string
+	ToolCalls []openrouter.ToolCall // Incremental tool call deltas, keyed by Index
+	Usage     *openrouter.Usage     // Only set on the last chunk
+	Error     error
+	Done      bool
 }
 
 type AIResponseMessage struct {
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// ErrInvalidAPIKey is returned by ValidateKey when the provider rejects the key
+var ErrInvalidAPIKey = errors.New("the provider rejected the API key")
+
+// ValidateKey checks an API key with a cheap request that needs the key but uses no tokens:
+// the key info of OpenRouter and the model list of the other providers
+func ValidateKey(ctx context.Context, providerID string, apiKey string) error {
+	var url string
+	var headers map[string]string
+	switch providerID {
+	case config.ProviderOpenRouter.ID:
+		url = openrouter.DefaultConfig(apiKey).BaseURL + "/key"
+		headers = map[string]string{"Authorization": "Bearer " + apiKey}
+	case config.ProviderOpenAI.ID:
+		url = config.BaseURL(providerID) + "/models"
+		headers = map[string]string{"Authorization": "Bearer " + apiKey}
+	case config.ProviderAnthropic.ID:
+		url = config.BaseURL(providerID) + "/models"
+		headers = NewAnthropic(apiKey, "").headers()
+	case config.ProviderGemini.ID:
+		url = config.BaseURL(providerID) + "/models"
+		headers = NewGemini(apiKey, "").headers()
+	default:
+		// Ollama and unknown providers have no key to check
+		return nil
+	}
+
+	resp, err := get(ctx, url, headers)
+	if err != nil {
+		var status *statusError
+		if errors.As(err, &status) && (status.StatusCode == http.StatusUnauthorized || status.StatusCode == http.StatusForbidden) {
+			return ErrInvalidAPIKey
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
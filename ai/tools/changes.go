@@ -0,0 +1,28 @@
+package tools
+
+import "context"
+
+// ChangeRecorder is told about every path a tool is about to change, before it changes it
+type ChangeRecorder func(path string)
+
+type changeRecorderKey struct{}
+
+// WithChangeRecorder reports the paths changed by the tools run with the returned context to recorder,
+// e.g. to snapshot the files so the change can be undone
+func WithChangeRecorder(ctx context.Context, recorder ChangeRecorder) context.Context {
+	return context.WithValue(ctx, changeRecorderKey{}, recorder)
+}
+
+// recordChanges passes the resolved paths of a call to the recorder of ctx. Paths outside the workspace
+// are left out, the tool refuses them.
+func recordChanges(ctx context.Context, paths []string) {
+	recorder, _ := ctx.Value(changeRecorderKey{}).(ChangeRecorder)
+	if recorder == nil {
+		return
+	}
+	for _, path := range paths {
+		if resolved, err := CurrentWorkspace().Resolve(ctx, path); err == nil {
+			recorder(resolved)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
@@ -31,6 +31,7 @@ var CreateToolParams = jsonschema.Definition{
 		"TypeOfCreate": {
 			Type:        jsonschema.String,
 			Description: "Type of creation: file or folder, only this 2 is allowed",
+			Enum:        []string{"file", "folder"},
 		},
 		"Name": {
 			Type:        jsonschema.String,
@@ -44,20 +45,36 @@ var CreateToolParams = jsonschema.Definition{
 	},
 }
 
-var OpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "create_file_or_folder",
-	Description: "Create file or folder in the system",
-	Parameters:  CreateToolParams,
-}
-
-var CreateTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &OpenrouterFn,
+func init() {
+	Register(Spec[CreateParams, CreateSuccess]{
+		Name:        "create_file_or_folder",
+		Description: "Create file or folder in the system",
+		Parameters:  CreateToolParams,
+		Class:       Mutating,
+		Handler:     CreateFileOrFolder,
+		Display: func(params CreateParams) (string, string) {
+			title := "Creating folder"
+			if params.TypeOfCreate == "file" {
+				title = "Creating file"
+			}
+			if params.BasePath == "" || params.Name == "" {
+				return title, "unknown path"
+			}
+			return title, params.BasePath + "/" + params.Name
+		},
+		Paths: func(params CreateParams) []string {
+			return []string{filepath.Join(params.BasePath, params.Name)}
+		},
+		Undoable: true,
+	})
 }
 
-func CreateFileOrFolder(params CreateParams) (CreateSuccess, ToolError) {
+func CreateFileOrFolder(ctx context.Context, params CreateParams) (CreateSuccess, ToolError) {
 	// Join the base path with the name to get full path
-	fullPath := filepath.Join(params.BasePath, params.Name)
+	fullPath, toolErr := resolveToolPath(ctx, filepath.Join(params.BasePath, params.Name))
+	if toolErr.Err != nil {
+		return CreateSuccess{}, toolErr
+	}
 
 	switch params.TypeOfCreate {
 	case "folder":
@@ -79,7 +96,7 @@ func CreateFileOrFolder(params CreateParams) (CreateSuccess, ToolError) {
 		return toolSuc, ToolError{}
 
 	case "file":
-		err := os.MkdirAll(params.BasePath, 0755)
+		err := os.MkdirAll(filepath.Dir(fullPath), 0755)
 		if err != nil {
 			toolErr := ToolError{
 				Success: false,
@@ -1,12 +1,12 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
@@ -35,6 +35,7 @@ var EditToolParams = jsonschema.Definition{
 		"EditMode": {
 			Type:        jsonschema.String,
 			Description: "Edit mode: 'replace', 'insert_at_line', 'append_line', 'prepend_line', 'replace_line'",
+			Enum:        []string{"replace", "insert_at_line", "append_line", "prepend_line", "replace_line"},
 		},
 		"SearchText": {
 			Type:        jsonschema.String,
@@ -46,7 +47,7 @@ var EditToolParams = jsonschema.Definition{
 		},
 		"LineNumber": {
 			Type:        jsonschema.Integer,
-			Description: "Line number (required for 'insert_at_line' and 'replace_line' modes)",
+			Description: "Line number, starting at 1 (required for 'insert_at_line' and 'replace_line' modes)",
 		},
 	},
 	Required: []string{
@@ -55,35 +56,68 @@ var EditToolParams = jsonschema.Definition{
 	},
 }
 
-var EditOpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "edit_content",
-	Description: "Edit file content with various editing modes",
-	Parameters:  EditToolParams,
+func init() {
+	Register(Spec[EditParams, EditResult]{
+		Name:        "edit_content",
+		Description: "Edit file content with various editing modes",
+		Parameters:  EditToolParams,
+		Class:       Mutating,
+		Handler:     EditFileContent,
+		Validate: func(params EditParams) []ArgumentProblem {
+			// 0 means the line number was left out, which editedContent reports for the modes that need it
+			if params.LineNumber < 0 {
+				return []ArgumentProblem{{Property: "LineNumber", Problem: fmt.Sprintf("must be 1 or more, got %d", params.LineNumber)}}
+			}
+			return nil
+		},
+		Display: func(params EditParams) (string, string) {
+			return "Editing file", orUnknown(params.FilePath, "file")
+		},
+		Paths: func(params EditParams) []string {
+			return []string{params.FilePath}
+		},
+		Undoable: true,
+		Preview:  PreviewEdit,
+	})
 }
 
-var EditTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &EditOpenrouterFn,
-}
+func EditFileContent(ctx context.Context, params EditParams) (EditResult, ToolError) {
+	filePath, toolErr := resolveToolPath(ctx, params.FilePath)
+	if toolErr.Err != nil {
+		return EditResult{}, toolErr
+	}
+
+	change, toolErr := PreviewEdit(ctx, params)
+	if toolErr.Err != nil {
+		return EditResult{}, toolErr
+	}
 
-func EditFileContent(params EditParams) (EditResult, ToolError) {
-	// Read the current file content
-	currentContentBytes, err := os.ReadFile(params.FilePath)
+	// Write the modified content back to the file
+	err := os.WriteFile(filePath, []byte(change.NewContent), 0644)
 	if err != nil {
 		return EditResult{}, ToolError{
 			Success: false,
-			Message: fmt.Sprintf("Error reading file: %s", err.Error()),
+			Message: fmt.Sprintf("Error writing file: %s", err.Error()),
 			Err:     err,
 		}
 	}
 
-	currentContent := string(currentContentBytes)
+	return EditResult{
+		Success:  true,
+		Message:  fmt.Sprintf("File edited successfully using %s mode", params.EditMode),
+		Path:     params.FilePath,
+		EditMode: params.EditMode,
+	}, ToolError{}
+}
+
+// editedContent applies the edit to the current file content
+func editedContent(params EditParams, currentContent string) (string, ToolError) {
 	var newContent string
 
 	switch params.EditMode {
 	case "replace":
 		if params.SearchText == "" {
-			return EditResult{}, ToolError{
+			return "", ToolError{
 				Success: false,
 				Message: "search_text is required for replace mode",
 				Err:     fmt.Errorf("search_text is required for replace mode"),
@@ -95,19 +129,15 @@ func EditFileContent(params EditParams) (EditResult, ToolError) {
 		newContent = re.ReplaceAllString(currentContent, params.ReplacementText)
 
 	case "insert_at_line":
-		if params.LineNumber == 0 {
-			return EditResult{}, ToolError{
-				Success: false,
-				Message: "line_number is required for insert_at_line mode",
-				Err:     fmt.Errorf("line_number is required for insert_at_line mode"),
-			}
+		if toolErr := checkLineNumber(params); toolErr.Err != nil {
+			return "", toolErr
 		}
 
 		lines := strings.Split(currentContent, "\n")
 
 		// Check if line number is valid
 		if params.LineNumber > len(lines)+1 {
-			return EditResult{}, ToolError{
+			return "", ToolError{
 				Success: false,
 				Message: fmt.Sprintf("Line %d is out of range. File has %d lines.", params.LineNumber, len(lines)),
 				Err:     fmt.Errorf("line number out of range"),
@@ -116,9 +146,6 @@ func EditFileContent(params EditParams) (EditResult, ToolError) {
 
 		// Insert at specified line (1-based indexing)
 		insertIndex := params.LineNumber - 1
-		if insertIndex < 0 {
-			insertIndex = 0
-		}
 
 		// Create new slice with inserted line
 		newLines := make([]string, 0, len(lines)+1)
@@ -135,18 +162,14 @@ func EditFileContent(params EditParams) (EditResult, ToolError) {
 		newContent = params.ReplacementText + "\n" + currentContent
 
 	case "replace_line":
-		if params.LineNumber == 0 {
-			return EditResult{}, ToolError{
-				Success: false,
-				Message: "line_number is required for replace_line mode",
-				Err:     fmt.Errorf("line_number is required for replace_line mode"),
-			}
+		if toolErr := checkLineNumber(params); toolErr.Err != nil {
+			return "", toolErr
 		}
 
 		lines := strings.Split(currentContent, "\n")
 
 		if params.LineNumber > len(lines) {
-			return EditResult{}, ToolError{
+			return "", ToolError{
 				Success: false,
 				Message: fmt.Sprintf("Line %d does not exist. File has %d lines.", params.LineNumber, len(lines)),
 				Err:     fmt.Errorf("line number out of range"),
@@ -158,27 +181,32 @@ func EditFileContent(params EditParams) (EditResult, ToolError) {
 		newContent = strings.Join(lines, "\n")
 
 	default:
-		return EditResult{}, ToolError{
+		return "", ToolError{
 			Success: false,
 			Message: fmt.Sprintf("Unknown edit mode: %s", params.EditMode),
 			Err:     fmt.Errorf("unknown edit mode"),
 		}
 	}
 
-	// Write the modified content back to the file
-	err = os.WriteFile(params.FilePath, []byte(newContent), 0644)
-	if err != nil {
-		return EditResult{}, ToolError{
+	return newContent, ToolError{}
+}
+
+// checkLineNumber reports a missing or negative line number for the modes that need one. The preview runs
+// this too, so a bad line number can't index outside the file before the call is validated.
+func checkLineNumber(params EditParams) ToolError {
+	switch {
+	case params.LineNumber == 0:
+		return ToolError{
 			Success: false,
-			Message: fmt.Sprintf("Error writing file: %s", err.Error()),
-			Err:     err,
+			Message: fmt.Sprintf("line_number is required for %s mode", params.EditMode),
+			Err:     fmt.Errorf("line_number is required for %s mode", params.EditMode),
+		}
+	case params.LineNumber < 0:
+		return ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Line %d does not exist, lines are numbered from 1.", params.LineNumber),
+			Err:     fmt.Errorf("line number out of range"),
 		}
 	}
-
-	return EditResult{
-		Success:  true,
-		Message:  fmt.Sprintf("File edited successfully using %s mode", params.EditMode),
-		Path:     params.FilePath,
-		EditMode: params.EditMode,
-	}, ToolError{}
+	return ToolError{}
 }
@@ -1,158 +1,38 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 )
 
-func ExecuteTool(toolName string, arguements string) string {
-	var response ToolResponse
-	switch toolName {
-	case "create_file_or_folder":
-		createPar := CreateParams{}
-		if err := json.Unmarshal([]byte(arguements), &createPar); err != nil {
-			response = ToolResponse{
-				Result: nil,
-				Error: ToolError{
-					Success: false,
-					Message: "Unknown tool or invalid parameters",
-					Err:     err,
-				},
-			}
-		} else {
-			result, err := CreateFileOrFolder(createPar)
-			response = ToolResponse{Result: result, Error: err}
-		}
-
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
-
-	case "edit_content":
-		editPar := EditParams{}
-		if err := json.Unmarshal([]byte(arguements), &editPar); err != nil {
-			response = ToolResponse{
-				Result: nil,
-				Error: ToolError{
-					Success: false,
-					Message: "Unknown tool or invalid parameters",
-					Err:     err,
-				},
-			}
-		} else {
-			result, err := EditFileContent(editPar)
-			response = ToolResponse{Result: result, Error: err}
-		}
-
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
-
-	case "write_content":
-		writePar := WriteParams{}
-		if err := json.Unmarshal([]byte(arguements), &writePar); err != nil {
-			response = ToolResponse{
-				Result: nil,
-				Error: ToolError{
-					Success: false,
-					Message: "Unknown tool or invalid parameters",
-					Err:     err,
-				},
-			}
-		} else {
-			result, err := WriteContent(writePar)
-			response = ToolResponse{Result: result, Error: err}
-		}
-
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
-
-	case "list_directory":
-		listPar := ListDirectoryParams{}
-		if err := json.Unmarshal([]byte(arguements), &listPar); err != nil {
-			response = ToolResponse{
-				Result: nil,
-				Error: ToolError{
-					Success: false,
-					Message: "Unknown tool or invalid parameters",
-					Err:     err,
-				},
-			}
-		} else {
-			result, err := ListDirectory(listPar)
-			response = ToolResponse{Result: result, Error: err}
-		}
-
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
-
-	case "search_files":
-		searchPar := SearchFilesParams{}
-		if err := json.Unmarshal([]byte(arguements), &searchPar); err != nil {
-			response = ToolResponse{
-				Result: nil,
-				Error: ToolError{
-					Success: false,
-					Message: "Unknown tool or invalid parameters",
-					Err:     err,
-				},
-			}
-		} else {
-			result, err := SearchFiles(searchPar)
-			response = ToolResponse{Result: result, Error: err}
-		}
-
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
-
-	case "read_file":
-		readPar := ReadFileParams{}
-		if err := json.Unmarshal([]byte(arguements), &readPar); err != nil {
-			response = ToolResponse{
-				Result: nil,
-				Error: ToolError{
-					Success: false,
-					Message: "Unknown tool or invalid parameters",
-					Err:     err,
-				},
-			}
-		} else {
-			result, err := ReadFile(readPar)
-			response = ToolResponse{Result: result, Error: err}
-		}
-
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
-
-	case "get_current_directory":
-		result, err := GetCurrentDirectory()
-		response = ToolResponse{Result: result, Error: err}
-
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
+// CancelledResponse is the result recorded for a tool call that was stopped by the user
+func CancelledResponse() string {
+	response := ToolResponse{
+		Result: nil,
+		Error: ToolError{
+			Success: false,
+			Message: "Tool call cancelled by the user",
+			Err:     nil,
+		},
+	}
 
-	case "web_search":
-		webSearchPar := WebSearchParams{}
-		if err := json.Unmarshal([]byte(arguements), &webSearchPar); err != nil {
-			response = ToolResponse{
-				Result: nil,
-				Error: ToolError{
-					Success: false,
-					Message: "Unknown tool or invalid parameters",
-					Err:     err,
-				},
-			}
-		} else {
-			result, err := WebSearch(webSearchPar)
-			response = ToolResponse{Result: result, Error: err}
-		}
+	responseStr, _ := json.Marshal(response)
+	return string(responseStr)
+}
 
-		responseStr, _ := json.Marshal(response)
-		return string(responseStr)
+// DeniedResponse is the result recorded for a tool call the user refused, with their feedback if any
+func DeniedResponse(feedback string) string {
+	message := "The user denied this tool call"
+	if feedback != "" {
+		message += ". User feedback: " + feedback
 	}
 
-	response = ToolResponse{
+	response := ToolResponse{
 		Result: nil,
 		Error: ToolError{
 			Success: false,
-			Message: "Unknown tool or invalid parameters",
+			Message: message,
 			Err:     nil,
 		},
 	}
@@ -160,3 +40,21 @@ func ExecuteTool(toolName string, arguements string) string {
 	responseStr, _ := json.Marshal(response)
 	return string(responseStr)
 }
+
+// ExecuteTool runs a tool call and returns its result as JSON for the model
+func ExecuteTool(ctx context.Context, toolName string, arguments string) string {
+	if ctx.Err() != nil {
+		return CancelledResponse()
+	}
+
+	response := ToolResponse{Error: ToolError{
+		Success: false,
+		Message: fmt.Sprintf("Unknown tool %q", toolName),
+	}}
+	if definition, ok := Lookup(toolName); ok {
+		response = definition.execute(ctx, arguments)
+	}
+
+	responseStr, _ := json.Marshal(response)
+	return string(responseStr)
+}
@@ -1,13 +1,15 @@
 package tools
 
 import (
+	"context"
 	"fmt"
-	"os"
 
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
+// GetCurrentDirectoryParams is empty, the tool takes no arguments
+type GetCurrentDirectoryParams struct{}
+
 type GetCurrentDirectoryResult struct {
 	Success          bool
 	Message          string
@@ -20,26 +22,22 @@ var GetCurrentDirectoryToolParams = jsonschema.Definition{
 	Required:   []string{},
 }
 
-var GetCurrentDirectoryOpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "get_current_directory",
-	Description: "Get the current working directory path",
-	Parameters:  GetCurrentDirectoryToolParams,
-}
-
-var GetCurrentDirectoryTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &GetCurrentDirectoryOpenrouterFn,
+func init() {
+	Register(Spec[GetCurrentDirectoryParams, GetCurrentDirectoryResult]{
+		Name:        "get_current_directory",
+		Description: "Get the current working directory path: the workspace root, which relative paths resolve against",
+		Parameters:  GetCurrentDirectoryToolParams,
+		Class:       ReadOnly,
+		Handler:     GetCurrentDirectory,
+		Display: func(GetCurrentDirectoryParams) (string, string) {
+			return "Getting current directory", ""
+		},
+	})
 }
 
-func GetCurrentDirectory() (GetCurrentDirectoryResult, ToolError) {
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return GetCurrentDirectoryResult{}, ToolError{
-			Success: false,
-			Message: fmt.Sprintf("Error getting current directory: %s", err.Error()),
-			Err:     err,
-		}
-	}
+// GetCurrentDirectory returns the workspace root, which may differ from the launch directory when workspace.root is set
+func GetCurrentDirectory(_ context.Context, _ GetCurrentDirectoryParams) (GetCurrentDirectoryResult, ToolError) {
+	currentDir := CurrentWorkspace().Root()
 
 	return GetCurrentDirectoryResult{
 		Success:          true,
@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignorePattern is one line of a .gitignore file, or one include/exclude glob
+type ignorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules are the patterns of one .gitignore file, relative to the directory holding it
+type ignoreRules struct {
+	dir      string // Slash separated and relative to the search root, "" for the root and above
+	prefix   string // For a .gitignore above the search root, the path from its directory to the search root
+	patterns []ignorePattern
+}
+
+// parseIgnorePattern converts a .gitignore line to a pattern; ok is false for blank lines and comments
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	// Trailing spaces are dropped unless the last one is escaped, e.g. "name\ "
+	line = strings.TrimRight(line, "\r")
+	trimmed := strings.TrimRight(line, " \t")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+		trimmed = line[:len(trimmed)+1]
+	}
+	line = trimmed
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	var pattern ignorePattern
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// Patterns with a slash are relative to the .gitignore's directory, others match at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "(^|/)" + expr + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	pattern.re = re
+	return pattern, true
+}
+
+// globToRegexp translates gitignore glob syntax, including "**", to a regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// matches reports whether relPath, slash separated and relative to the pattern's base, matches
+func (p ignorePattern) matches(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return p.re.MatchString(relPath)
+}
+
+// loadIgnoreRules reads the .gitignore in dir, if there is one
+func loadIgnoreRules(dir string) *ignoreRules {
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	rules := &ignoreRules{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if pattern, ok := parseIgnorePattern(scanner.Text()); ok {
+			rules.patterns = append(rules.patterns, pattern)
+		}
+	}
+	return rules
+}
+
+// gitignore decides which paths under a search root are ignored by the .gitignore files on the way
+type gitignore struct {
+	rules []*ignoreRules // Outermost first, so later rules take precedence
+}
+
+// newGitignore loads the .gitignore files from the workspace root down to the search root
+func newGitignore(searchRoot string, workspaceRoot string) *gitignore {
+	g := &gitignore{}
+
+	var parents []string
+	for dir := filepath.Dir(searchRoot); within(dir, workspaceRoot) && dir != searchRoot; dir = filepath.Dir(dir) {
+		parents = append([]string{dir}, parents...)
+		if dir == workspaceRoot || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	for _, dir := range parents {
+		// Rules from above the search root see paths from their own directory
+		prefix, err := filepath.Rel(dir, searchRoot)
+		if err != nil {
+			continue
+		}
+		if rules := loadIgnoreRules(dir); rules != nil {
+			rules.prefix = filepath.ToSlash(prefix)
+			g.rules = append(g.rules, rules)
+		}
+	}
+	return g
+}
+
+// enter loads the .gitignore of a directory the walk is about to descend into
+func (g *gitignore) enter(dir string, relDir string) {
+	if rules := loadIgnoreRules(dir); rules != nil {
+		rules.dir = relDir
+		g.rules = append(g.rules, rules)
+	}
+}
+
+// ignored reports whether relPath, relative to the search root, is ignored; the last matching pattern wins
+func (g *gitignore) ignored(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rules := range g.rules {
+		rel, ok := relativeTo(relPath, rules)
+		if !ok {
+			continue
+		}
+		for _, pattern := range rules.patterns {
+			if pattern.matches(rel, isDir) {
+				ignored = !pattern.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// relativeTo returns relPath, relative to the search root, as seen from the directory of the rules
+func relativeTo(relPath string, rules *ignoreRules) (string, bool) {
+	switch {
+	case rules.prefix != "":
+		return rules.prefix + "/" + relPath, true
+	case rules.dir == "" || rules.dir == ".":
+		return relPath, true
+	case strings.HasPrefix(relPath, rules.dir+"/"):
+		return strings.TrimPrefix(relPath, rules.dir+"/"), true
+	default:
+		return "", false
+	}
+}
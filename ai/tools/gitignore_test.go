@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIgnorePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		// A name without a slash matches at any depth
+		{"*.log", "debug.log", false, true},
+		{"*.log", "logs/debug.log", false, true},
+		{"*.log", "debug.log.txt", false, false},
+		{"node_modules", "web/node_modules", true, true},
+
+		// A slash at the start or in the middle anchors the pattern to the .gitignore's directory
+		{"/build", "build", true, true},
+		{"/build", "src/build", true, false},
+		{"doc/*.txt", "doc/notes.txt", false, true},
+		{"doc/*.txt", "doc/api/notes.txt", false, false},
+		{"doc/*.txt", "src/doc/notes.txt", false, false},
+
+		// A slash at the end only matches directories
+		{"build/", "build", true, true},
+		{"build/", "src/build", true, true},
+		{"build/", "build", false, false},
+
+		// ** matches any number of directories
+		{"**/foo", "foo", false, true},
+		{"**/foo", "a/b/foo", false, true},
+		{"abc/**", "abc/x", false, true},
+		{"abc/**", "abc/x/y", false, true},
+		{"abc/**", "abc", true, false},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**/b", "x/a/b", false, false},
+
+		// * and ? don't match a slash
+		{"a*c", "abbc", false, true},
+		{"a*c", "a/c", false, false},
+		{"file?.go", "file1.go", false, true},
+		{"file?.go", "file10.go", false, false},
+
+		// Character classes, also negated with !
+		{"[abc].txt", "b.txt", false, true},
+		{"[abc].txt", "d.txt", false, false},
+		{"[!abc].txt", "d.txt", false, true},
+		{"[a-c]x", "bx", false, true},
+
+		// Escapes and trailing spaces
+		{`\#notes`, "#notes", false, true},
+		{`\!important`, "!important", false, true},
+		{"trailing   ", "trailing", false, true},
+		{`space\ `, "space ", false, true},
+		{`space\ `, "space", false, false},
+		{`what\?`, "what?", false, true},
+		{`what\?`, "whats", false, false},
+	}
+	for _, test := range tests {
+		pattern, ok := parseIgnorePattern(test.pattern)
+		if !ok {
+			t.Errorf("%q wasn't parsed as a pattern", test.pattern)
+			continue
+		}
+		if got := pattern.matches(test.path, test.isDir); got != test.want {
+			t.Errorf("%q matching %q (dir %v) = %v, want %v", test.pattern, test.path, test.isDir, got, test.want)
+		}
+	}
+}
+
+func TestIgnorePatternSkipsBlankLinesAndComments(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "!", "/"} {
+		if _, ok := parseIgnorePattern(line); ok {
+			t.Errorf("%q was parsed as a pattern", line)
+		}
+	}
+	if pattern, ok := parseIgnorePattern("!keep.log"); !ok || !pattern.negate {
+		t.Error("!keep.log isn't a negated pattern")
+	}
+}
+
+func TestGitignoreLastMatchWins(t *testing.T) {
+	g := &gitignore{rules: []*ignoreRules{{patterns: parsePatterns(t, "*.log", "!keep.log", "keep.log.old", "logs/", "!logs/")}}}
+	for path, want := range map[string]bool{"debug.log": true, "keep.log": false, "keep.log.old": true, "main.go": false} {
+		if got := g.ignored(path, false); got != want {
+			t.Errorf("ignored(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if g.ignored("logs", true) {
+		t.Error("logs/ is ignored although a later pattern includes it again")
+	}
+}
+
+// TestGrepRespectsGitignore searches a tree with .gitignore files above the search root, at it and below it
+func TestGrepRespectsGitignore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":             "*.log\n!keep.log\n/build/\nsrc/gen/\n",
+		"main.go":                "needle",
+		"debug.log":              "needle",
+		"keep.log":               "needle",
+		"build/out.go":           "needle",
+		"src/.gitignore":         "*.tmp\n!important.tmp\n/local.go\n",
+		"src/app.go":             "needle",
+		"src/build/app.go":       "needle", // /build only applies next to the root .gitignore
+		"src/gen/generated.go":   "needle",
+		"src/scratch.tmp":        "needle",
+		"src/important.tmp":      "needle",
+		"src/local.go":           "needle",
+		"src/nested/local.go":    "needle", // /local.go is anchored to src
+		"src/nested/trace.log":   "needle",
+		"docs/.gitignore":        "!*.log\n",
+		"docs/changes.log":       "needle", // Included again by the deeper .gitignore
+		"docs/drafts/notes.md":   "needle",
+		"docs/drafts/.gitignore": "*\n!.gitignore\n",
+		".git/config":            "needle",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workspace, err := NewWorkspace(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := CurrentWorkspace()
+	SetWorkspace(workspace)
+	defer SetWorkspace(previous)
+
+	tests := []struct {
+		searchPath string
+		want       []string
+	}{
+		{".", []string{
+			"docs/changes.log", "keep.log", "main.go", "src/app.go", "src/build/app.go",
+			"src/important.tmp", "src/nested/local.go",
+		}},
+		// The root .gitignore still applies when searching below it
+		{"src", []string{
+			"src/app.go", "src/build/app.go", "src/important.tmp", "src/nested/local.go",
+		}},
+	}
+	for _, test := range tests {
+		result, toolErr := Grep(context.Background(), GrepParams{Pattern: "needle", SearchPath: test.searchPath})
+		if toolErr.Err != nil {
+			t.Fatalf("Grep %s: %v", test.searchPath, toolErr.Err)
+		}
+		var got []string
+		for _, match := range result.Matches {
+			got = append(got, filepath.ToSlash(match.File))
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Grep in %s found\n  %q\nwant\n  %q", test.searchPath, got, test.want)
+		}
+	}
+}
+
+func parsePatterns(t *testing.T, lines ...string) []ignorePattern {
+	t.Helper()
+	var patterns []ignorePattern
+	for _, line := range lines {
+		pattern, ok := parseIgnorePattern(line)
+		if !ok {
+			t.Fatalf("%q wasn't parsed as a pattern", line)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
@@ -0,0 +1,292 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+const (
+	defaultGrepResults = 100
+	maxGrepResults     = 500
+	maxGrepContext     = 10
+	// Files larger than this are skipped, they are rarely source code
+	maxGrepFileSize = 2 * 1024 * 1024
+	// Matched lines are cut to this many characters so minified files don't flood the result
+	maxGrepLineLength = 500
+)
+
+type GrepParams struct {
+	Pattern         string
+	SearchPath      string
+	Include         []string
+	Exclude         []string
+	ContextLines    int
+	CaseInsensitive bool
+	MaxResults      int
+}
+
+type GrepMatch struct {
+	File   string
+	Line   int
+	Text   string
+	Before []string
+	After  []string
+}
+
+type GrepResult struct {
+	Success       bool
+	Message       string
+	Pattern       string
+	SearchPath    string
+	Matches       []GrepMatch
+	FilesSearched int
+	Truncated     bool
+}
+
+var GrepToolParams = jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"Pattern": {
+			Type:        jsonschema.String,
+			Description: "Regular expression (Go RE2 syntax) to search for in file contents, e.g. 'func\\s+ExecuteTool'",
+		},
+		"SearchPath": {
+			Type:        jsonschema.String,
+			Description: "File or directory to search in, defaults to the workspace root",
+		},
+		"Include": {
+			Type:        jsonschema.Array,
+			Items:       &jsonschema.Definition{Type: jsonschema.String},
+			Description: "Only search files matching one of these .gitignore style globs, e.g. ['*.go', 'cmd/**']",
+		},
+		"Exclude": {
+			Type:        jsonschema.Array,
+			Items:       &jsonschema.Definition{Type: jsonschema.String},
+			Description: "Skip files and directories matching one of these .gitignore style globs, e.g. ['*_test.go', 'vendor/']",
+		},
+		"ContextLines": {
+			Type:        jsonschema.Integer,
+			Description: "Lines of context to include before and after each match, up to 10",
+		},
+		"CaseInsensitive": {
+			Type:        jsonschema.Boolean,
+			Description: "Match without regard to case",
+		},
+		"MaxResults": {
+			Type:        jsonschema.Integer,
+			Description: "Maximum number of matching lines to return, defaults to 100 and is capped at 500",
+		},
+	},
+	Required: []string{
+		"Pattern",
+	},
+}
+
+func init() {
+	Register(Spec[GrepParams, GrepResult]{
+		Name:        "grep",
+		Description: "Search file contents with a regular expression and return matching lines with file, line number and context. Files ignored by .gitignore are skipped",
+		Parameters:  GrepToolParams,
+		Class:       ReadOnly,
+		Handler:     Grep,
+		Display: func(params GrepParams) (string, string) {
+			if params.Pattern == "" {
+				return "Searching file contents", "unknown pattern"
+			}
+			return "Searching file contents", "/" + params.Pattern + "/" + inPath(params.SearchPath)
+		},
+		Paths: func(params GrepParams) []string {
+			return []string{params.SearchPath}
+		},
+	})
+}
+
+// errGrepLimit stops the walk once enough matches were found
+var errGrepLimit = errors.New("result limit reached")
+
+func Grep(ctx context.Context, params GrepParams) (GrepResult, ToolError) {
+	expr := params.Pattern
+	if params.CaseInsensitive {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return GrepResult{}, ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Invalid regular expression: %s", err.Error()),
+			Err:     err,
+		}
+	}
+
+	searchPath := params.SearchPath
+	if searchPath == "" {
+		searchPath = "."
+	}
+	resolvedPath, toolErr := resolveToolPath(ctx, searchPath)
+	if toolErr.Err != nil {
+		return GrepResult{}, toolErr
+	}
+
+	maxResults := defaultGrepResults
+	if params.MaxResults > 0 {
+		maxResults = min(params.MaxResults, maxGrepResults)
+	}
+	contextLines := min(max(params.ContextLines, 0), maxGrepContext)
+
+	include := parseGlobs(params.Include)
+	exclude := parseGlobs(params.Exclude)
+	ignore := newGitignore(resolvedPath, CurrentWorkspace().Root())
+
+	result := GrepResult{
+		Pattern:    params.Pattern,
+		SearchPath: searchPath,
+	}
+
+	err = filepath.WalkDir(resolvedPath, func(path string, entry fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil // Keep searching past unreadable entries
+		}
+
+		relPath, _ := filepath.Rel(resolvedPath, path)
+		relPath = filepath.ToSlash(relPath)
+
+		if entry.IsDir() {
+			if path == resolvedPath {
+				ignore.enter(path, "")
+				return nil
+			}
+			if entry.Name() == ".git" || ignore.ignored(relPath, true) || matchesAny(exclude, relPath, true) {
+				return filepath.SkipDir
+			}
+			ignore.enter(path, relPath)
+			return nil
+		}
+
+		// A single file as search path is searched even if ignored
+		if path != resolvedPath {
+			if !entry.Type().IsRegular() || ignore.ignored(relPath, false) || matchesAny(exclude, relPath, false) {
+				return nil
+			}
+			if len(include) > 0 && !matchesAny(include, relPath, false) {
+				return nil
+			}
+		}
+
+		displayPath := filepath.Join(searchPath, filepath.FromSlash(relPath))
+		if path == resolvedPath {
+			displayPath = searchPath
+		}
+
+		matches, searched := grepFile(path, displayPath, re, contextLines, maxResults-len(result.Matches))
+		if searched {
+			result.FilesSearched++
+		}
+		result.Matches = append(result.Matches, matches...)
+		if len(result.Matches) >= maxResults {
+			result.Truncated = true
+			return errGrepLimit
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errGrepLimit) {
+		return GrepResult{}, ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Error searching files: %s", err.Error()),
+			Err:     err,
+		}
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Found %d matches in %d files searched", len(result.Matches), result.FilesSearched)
+	if result.Truncated {
+		result.Message = fmt.Sprintf("Showing the first %d matches, narrow the pattern or the search path to see more", len(result.Matches))
+	}
+	return result, ToolError{}
+}
+
+// grepFile returns up to limit matches in one file; binary and oversized files are skipped
+func grepFile(path string, displayPath string, re *regexp.Regexp, contextLines int, limit int) ([]GrepMatch, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxGrepFileSize {
+		return nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+		return nil, false
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxGrepFileSize)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	var matches []GrepMatch
+	for i, line := range lines {
+		if !re.MatchString(line) {
+			continue
+		}
+
+		match := GrepMatch{
+			File: displayPath,
+			Line: i + 1,
+			Text: truncateLine(line),
+		}
+		if contextLines > 0 {
+			for _, before := range lines[max(i-contextLines, 0):i] {
+				match.Before = append(match.Before, truncateLine(before))
+			}
+			for _, after := range lines[i+1 : min(i+1+contextLines, len(lines))] {
+				match.After = append(match.After, truncateLine(after))
+			}
+		}
+
+		matches = append(matches, match)
+		if len(matches) >= limit {
+			break
+		}
+	}
+	return matches, true
+}
+
+func truncateLine(line string) string {
+	if len(line) <= maxGrepLineLength {
+		return line
+	}
+	return strings.ToValidUTF8(line[:maxGrepLineLength], "") + "…"
+}
+
+// parseGlobs converts include/exclude globs to patterns; globs without a slash match at any depth
+func parseGlobs(globs []string) []ignorePattern {
+	var patterns []ignorePattern
+	for _, glob := range globs {
+		if pattern, ok := parseIgnorePattern(strings.TrimSpace(glob)); ok && !pattern.negate {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func matchesAny(patterns []ignorePattern, relPath string, isDir bool) bool {
+	for _, pattern := range patterns {
+		if pattern.matches(relPath, isDir) {
+			return true
+		}
+	}
+	return false
+}
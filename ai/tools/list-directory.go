@@ -1,12 +1,12 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
@@ -42,30 +42,44 @@ var ListDirectoryToolParams = jsonschema.Definition{
 		},
 		"FilterType": {
 			Type:        jsonschema.String,
-			Description: "Filter items by type: 'files', 'folders', or empty for all",
+			Description: "Filter items by type: 'files' or 'folders', leave out for all",
+			Enum:        []string{"files", "folders"},
 		},
 	},
 	Required: []string{},
 }
 
-var ListDirectoryOpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "list_directory",
-	Description: "List contents of a directory with optional filtering",
-	Parameters:  ListDirectoryToolParams,
-}
-
-var ListDirectoryTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &ListDirectoryOpenrouterFn,
+func init() {
+	Register(Spec[ListDirectoryParams, ListDirectoryResult]{
+		Name:        "list_directory",
+		Description: "List contents of a directory with optional filtering",
+		Parameters:  ListDirectoryToolParams,
+		Class:       ReadOnly,
+		Handler:     ListDirectory,
+		Display: func(params ListDirectoryParams) (string, string) {
+			if params.DirectoryPath == "" || params.DirectoryPath == "." {
+				return "Listing directory", "current directory"
+			}
+			return "Listing directory", params.DirectoryPath
+		},
+		Paths: func(params ListDirectoryParams) []string {
+			return []string{params.DirectoryPath}
+		},
+	})
 }
 
-func ListDirectory(params ListDirectoryParams) (ListDirectoryResult, ToolError) {
+func ListDirectory(ctx context.Context, params ListDirectoryParams) (ListDirectoryResult, ToolError) {
 	directoryPath := params.DirectoryPath
 	if directoryPath == "" {
 		directoryPath = "."
 	}
 
-	entries, err := os.ReadDir(directoryPath)
+	resolvedPath, toolErr := resolveToolPath(ctx, directoryPath)
+	if toolErr.Err != nil {
+		return ListDirectoryResult{}, toolErr
+	}
+
+	entries, err := os.ReadDir(resolvedPath)
 	if err != nil {
 		return ListDirectoryResult{}, ToolError{
 			Success: false,
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// FileChange is what a file tool would write, computed before anything touches the disk
+type FileChange struct {
+	Path       string
+	OldContent string
+	NewContent string
+	IsNewFile  bool
+}
+
+// Diff returns the change as a unified diff, empty when the content is unchanged
+func (c FileChange) Diff() string {
+	path := strings.TrimPrefix(filepath.ToSlash(c.Path), "/")
+	oldLabel := "a/" + path
+	if c.IsNewFile {
+		oldLabel = "/dev/null"
+	}
+	return udiff.Unified(oldLabel, "b/"+path, c.OldContent, c.NewContent)
+}
+
+// PreviewEdit computes the content EditFileContent would write
+func PreviewEdit(ctx context.Context, params EditParams) (FileChange, ToolError) {
+	filePath, toolErr := resolveToolPath(ctx, params.FilePath)
+	if toolErr.Err != nil {
+		return FileChange{}, toolErr
+	}
+
+	currentContentBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return FileChange{}, ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Error reading file: %s", err.Error()),
+			Err:     err,
+		}
+	}
+
+	currentContent := string(currentContentBytes)
+	newContent, toolErr := editedContent(params, currentContent)
+	if toolErr.Err != nil {
+		return FileChange{}, toolErr
+	}
+
+	return FileChange{
+		Path:       params.FilePath,
+		OldContent: currentContent,
+		NewContent: newContent,
+	}, ToolError{}
+}
+
+// PreviewWrite computes the content WriteContent would leave in the file
+func PreviewWrite(ctx context.Context, params WriteParams) (FileChange, ToolError) {
+	filePath, toolErr := resolveToolPath(ctx, params.FilePath)
+	if toolErr.Err != nil {
+		return FileChange{}, toolErr
+	}
+	change := FileChange{Path: params.FilePath}
+
+	currentContentBytes, err := os.ReadFile(filePath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		change.IsNewFile = true
+	case err != nil:
+		return FileChange{}, ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Error reading file: %s", err.Error()),
+			Err:     err,
+		}
+	default:
+		change.OldContent = string(currentContentBytes)
+	}
+
+	switch params.Mode {
+	case "overwrite":
+		change.NewContent = params.Content
+	case "append":
+		change.NewContent = change.OldContent + params.Content
+	default:
+		return FileChange{}, ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Invalid mode: %s", params.Mode),
+			Err:     fmt.Errorf("invalid mode: %s", params.Mode),
+		}
+	}
+
+	return change, ToolError{}
+}
@@ -1,11 +1,11 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
@@ -45,19 +45,29 @@ var ReadFileToolParams = jsonschema.Definition{
 	},
 }
 
-var ReadFileOpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "read_file",
-	Description: "Read content from a file with optional line limiting",
-	Parameters:  ReadFileToolParams,
+func init() {
+	Register(Spec[ReadFileParams, ReadFileResult]{
+		Name:        "read_file",
+		Description: "Read content from a file with optional line limiting",
+		Parameters:  ReadFileToolParams,
+		Class:       ReadOnly,
+		Handler:     ReadFile,
+		Display: func(params ReadFileParams) (string, string) {
+			return "Reading file", orUnknown(params.FilePath, "file")
+		},
+		Paths: func(params ReadFileParams) []string {
+			return []string{params.FilePath}
+		},
+	})
 }
 
-var ReadFileTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &ReadFileOpenrouterFn,
-}
+func ReadFile(ctx context.Context, params ReadFileParams) (ReadFileResult, ToolError) {
+	filePath, toolErr := resolveToolPath(ctx, params.FilePath)
+	if toolErr.Err != nil {
+		return ReadFileResult{}, toolErr
+	}
 
-func ReadFile(params ReadFileParams) (ReadFileResult, ToolError) {
-	content, err := os.ReadFile(params.FilePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return ReadFileResult{}, ToolError{
 			Success: false,
@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/revrost/go-openrouter"
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+// Class tells whether a tool changes anything
+type Class int
+
+const (
+	// ReadOnly tools only look at files or the web, so several calls may run at the same time and they are allowed by default
+	ReadOnly Class = iota
+	// Mutating tools change files or the system, so calls run one after another and ask first by default
+	Mutating
+)
+
+// Spec describes a tool once: what the model sees, how it runs and how it is shown. P is the type the JSON
+// arguments are decoded into and R the result returned to the model.
+type Spec[P any, R any] struct {
+	Name        string
+	Description string
+	Parameters  jsonschema.Definition
+	// Schema is sent to the model instead of Parameters when set, for schemas written elsewhere that say more
+	// than jsonschema.Definition can hold; arguments are still checked against Parameters
+	Schema  json.RawMessage
+	Class   Class
+	Handler func(ctx context.Context, params P) (R, ToolError)
+	// Validate checks what the schema can't say, e.g. that a line number is at least 1; optional
+	Validate func(params P) []ArgumentProblem
+	// Display describes a call for the user: what it does, e.g. "Reading file", and what it applies to
+	Display func(params P) (string, string)
+	// Paths returns the paths a call uses, so the workspace can check them before it runs; optional
+	Paths func(params P) []string
+	// Undoable tools change nothing but the paths Paths returns, which are recorded before each call so it can
+	// be undone. Tools that change other things, like run_command, leave it unset.
+	Undoable bool
+	// Preview computes the file change a call would make before it runs; optional
+	Preview func(ctx context.Context, params P) (FileChange, ToolError)
+}
+
+// Definition is a registered tool with its arguments still encoded as JSON
+type Definition struct {
+	Name    string
+	Tool    openrouter.Tool
+	Class   Class
+	execute func(ctx context.Context, arguments string) ToolResponse
+	display func(arguments string) (string, string, bool)
+	paths   func(arguments string) []string
+	preview func(ctx context.Context, arguments string) (FileChange, bool)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Definition
+)
+
+// Register adds a tool, or replaces the tool that has the same name
+func Register[P any, R any](spec Spec[P, R]) {
+	decode := func(arguments string) (P, error) {
+		var params P
+		if strings.TrimSpace(arguments) == "" {
+			// Tools without parameters may be called with no arguments at all
+			arguments = "{}"
+		}
+		err := json.Unmarshal([]byte(arguments), &params)
+		return params, err
+	}
+
+	var parameters any = spec.Parameters
+	if spec.Schema != nil {
+		parameters = spec.Schema
+	}
+
+	definition := Definition{
+		Name: spec.Name,
+		Tool: openrouter.Tool{
+			Type: openrouter.ToolTypeFunction,
+			Function: &openrouter.FunctionDefinition{
+				Name:        spec.Name,
+				Description: spec.Description,
+				Parameters:  parameters,
+			},
+		},
+		Class: spec.Class,
+		execute: func(ctx context.Context, arguments string) ToolResponse {
+			if strings.TrimSpace(arguments) == "" {
+				arguments = "{}"
+			}
+			// Checked against the schema first, since decoding ignores unknown properties and wrong enum values
+			if problems := validateArguments(spec.Parameters, arguments); len(problems) > 0 {
+				return ToolResponse{Error: invalidArguments(&ArgumentsError{Tool: spec.Name, Problems: problems})}
+			}
+			params, err := decode(arguments)
+			if err != nil {
+				return ToolResponse{Error: ToolError{
+					Success: false,
+					Message: fmt.Sprintf("Invalid parameters for %s: %s", spec.Name, err.Error()),
+					Err:     err,
+				}}
+			}
+			if spec.Validate != nil {
+				if problems := spec.Validate(params); len(problems) > 0 {
+					return ToolResponse{Error: invalidArguments(&ArgumentsError{Tool: spec.Name, Problems: problems})}
+				}
+			}
+			if spec.Undoable && spec.Paths != nil {
+				recordChanges(ctx, spec.Paths(params))
+			}
+			result, toolErr := spec.Handler(ctx, params)
+			return ToolResponse{Result: result, Error: toolErr}
+		},
+		display: func(arguments string) (string, string, bool) {
+			params, err := decode(arguments)
+			if err != nil || spec.Display == nil {
+				return "", "", false
+			}
+			title, detail := spec.Display(params)
+			return title, detail, true
+		},
+	}
+	if spec.Paths != nil {
+		definition.paths = func(arguments string) []string {
+			params, err := decode(arguments)
+			if err != nil {
+				return nil
+			}
+			return spec.Paths(params)
+		}
+	}
+	if spec.Preview != nil {
+		definition.preview = func(ctx context.Context, arguments string) (FileChange, bool) {
+			params, err := decode(arguments)
+			if err != nil {
+				return FileChange{}, false
+			}
+			change, toolErr := spec.Preview(ctx, params)
+			return change, toolErr.Err == nil
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for i, registered := range registry {
+		if registered.Name == spec.Name {
+			registry[i] = definition
+			return
+		}
+	}
+	registry = append(registry, definition)
+}
+
+// Unregister removes a tool, e.g. one an MCP server no longer offers
+func Unregister(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = slices.DeleteFunc(registry, func(definition Definition) bool {
+		return definition.Name == name
+	})
+}
+
+// Lookup returns the registered tool with a name
+func Lookup(name string) (Definition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, definition := range registry {
+		if definition.Name == name {
+			return definition, true
+		}
+	}
+	return Definition{}, false
+}
+
+// GetAllTools returns the schemas of the registered tools, in the order they were registered
+func GetAllTools() []openrouter.Tool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	all := make([]openrouter.Tool, 0, len(registry))
+	for _, definition := range registry {
+		all = append(all, definition.Tool)
+	}
+	return all
+}
+
+// IsReadOnly reports whether a tool leaves files and the system unchanged; unknown tools are assumed to change them
+func IsReadOnly(toolName string) bool {
+	definition, ok := Lookup(toolName)
+	return ok && definition.Class == ReadOnly
+}
+
+// DisplayCall describes a tool call for the user: what it does, e.g. "Reading file", and what it applies to.
+// Arguments that can't be decoded are shown as they are.
+func DisplayCall(toolName string, arguments string) (string, string) {
+	definition, ok := Lookup(toolName)
+	if ok {
+		if title, detail, ok := definition.display(arguments); ok {
+			return title, detail
+		}
+	}
+	if !json.Valid([]byte(arguments)) {
+		return toolName, arguments
+	}
+
+	// Tools without a formatter get their name in words, e.g. "Fetch Issue" for fetch_issue
+	words := strings.Fields(strings.ReplaceAll(toolName, "_", " "))
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " "), "executing..."
+}
+
+// PreviewChange returns the file change a tool call would make, for the tools that modify file content
+func PreviewChange(ctx context.Context, toolName string, arguments string) (FileChange, bool) {
+	definition, ok := Lookup(toolName)
+	if !ok || definition.preview == nil {
+		return FileChange{}, false
+	}
+	return definition.preview(ctx, arguments)
+}
+
+// toolPaths returns the paths a tool call would touch
+func toolPaths(toolName string, arguments string) []string {
+	definition, ok := Lookup(toolName)
+	if !ok || definition.paths == nil {
+		return nil
+	}
+	return definition.paths(arguments)
+}
+
+// orUnknown returns value, or a placeholder like "unknown file" when the model left it out
+func orUnknown(value string, what string) string {
+	if value == "" {
+		return "unknown " + what
+	}
+	return value
+}
+
+// inPath returns " in <path>" for a search outside the current directory
+func inPath(path string) string {
+	if path == "" || path == "." {
+		return ""
+	}
+	return " in " + path
+}
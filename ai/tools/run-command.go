@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+const (
+	defaultCommandTimeout = 120 * time.Second
+	maxCommandTimeout     = 10 * time.Minute
+	// Bytes of stdout and of stderr kept for the model; the rest is dropped
+	maxCommandOutput = 32 * 1024
+)
+
+type RunCommandParams struct {
+	Command          string
+	WorkingDirectory string
+	TimeoutSeconds   int
+}
+
+type RunCommandResult struct {
+	Success          bool
+	Message          string
+	Command          string
+	WorkingDirectory string
+	ExitCode         int
+	Stdout           string
+	Stderr           string
+	StdoutTruncated  bool
+	StderrTruncated  bool
+	TimedOut         bool
+}
+
+var RunCommandToolParams = jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"Command": {
+			Type:        jsonschema.String,
+			Description: "Shell command to run, e.g. 'go test ./...'",
+		},
+		"WorkingDirectory": {
+			Type:        jsonschema.String,
+			Description: "Directory to run the command in, defaults to the workspace root",
+		},
+		"TimeoutSeconds": {
+			Type:        jsonschema.Integer,
+			Description: "Seconds before the command is killed, defaults to 120 and is capped at 600",
+		},
+	},
+	Required: []string{
+		"Command",
+	},
+}
+
+func init() {
+	Register(Spec[RunCommandParams, RunCommandResult]{
+		Name:        "run_command",
+		Description: "Run a shell command in the workspace and return its exit code, stdout and stderr. Use it to build, test, lint and inspect the project",
+		Parameters:  RunCommandToolParams,
+		Class:       Mutating,
+		Handler:     RunCommand,
+		Display: func(params RunCommandParams) (string, string) {
+			if params.Command == "" {
+				return "Running command", "unknown command"
+			}
+			detail := "$ " + params.Command
+			if params.WorkingDirectory != "" && params.WorkingDirectory != "." {
+				detail += "\n(in " + params.WorkingDirectory + ")"
+			}
+			return "Running command", detail
+		},
+		Paths: func(params RunCommandParams) []string {
+			return []string{params.WorkingDirectory}
+		},
+	})
+}
+
+// OutputHandler receives the output of a tool as it is produced
+type OutputHandler func(chunk string)
+
+type outputHandlerKey struct{}
+
+// WithOutputHandler streams the output of the tools run with the returned context to handler
+func WithOutputHandler(ctx context.Context, handler OutputHandler) context.Context {
+	return context.WithValue(ctx, outputHandlerKey{}, handler)
+}
+
+// cappedOutput keeps the first maxCommandOutput bytes of a stream and passes everything on to the handler
+type cappedOutput struct {
+	buf       bytes.Buffer
+	truncated bool
+	handler   OutputHandler
+}
+
+func (o *cappedOutput) Write(p []byte) (int, error) {
+	if remaining := maxCommandOutput - o.buf.Len(); remaining < len(p) {
+		o.buf.Write(p[:max(remaining, 0)])
+		o.truncated = true
+	} else {
+		o.buf.Write(p)
+	}
+
+	if o.handler != nil {
+		o.handler(string(p))
+	}
+	return len(p), nil
+}
+
+func RunCommand(ctx context.Context, params RunCommandParams) (RunCommandResult, ToolError) {
+	if params.Command == "" {
+		return RunCommandResult{}, ToolError{
+			Success: false,
+			Message: "Command is required",
+			Err:     fmt.Errorf("command is required"),
+		}
+	}
+
+	workingDirectory, toolErr := resolveToolPath(ctx, params.WorkingDirectory)
+	if toolErr.Err != nil {
+		return RunCommandResult{}, toolErr
+	}
+
+	timeout := defaultCommandTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(params.TimeoutSeconds)*time.Second, maxCommandTimeout)
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(runCtx, "cmd", "/C", params.Command)
+	} else {
+		cmd = exec.CommandContext(runCtx, "sh", "-c", params.Command)
+	}
+	cmd.Dir = workingDirectory
+	// Don't wait forever on pipes held open by background children after the command is killed
+	cmd.WaitDelay = 2 * time.Second
+
+	handler, _ := ctx.Value(outputHandlerKey{}).(OutputHandler)
+	stdout := &cappedOutput{handler: handler}
+	stderr := &cappedOutput{handler: handler}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return RunCommandResult{}, ToolError{
+			Success: false,
+			Message: "Command cancelled by the user",
+			Err:     ctx.Err(),
+		}
+	}
+
+	result := RunCommandResult{
+		Command:          params.Command,
+		WorkingDirectory: workingDirectory,
+		Stdout:           stdout.buf.String(),
+		Stderr:           stderr.buf.String(),
+		StdoutTruncated:  stdout.truncated,
+		StderrTruncated:  stderr.truncated,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+		result.Message = fmt.Sprintf("Command timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Message = fmt.Sprintf("Command exited with code %d", result.ExitCode)
+	case err != nil:
+		return RunCommandResult{}, ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Error running command: %s", err.Error()),
+			Err:     err,
+		}
+	default:
+		result.Success = true
+		result.Message = "Command completed successfully"
+	}
+
+	return result, ToolError{}
+}
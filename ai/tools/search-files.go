@@ -1,11 +1,11 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
@@ -48,7 +48,8 @@ var SearchFilesToolParams = jsonschema.Definition{
 		},
 		"Type": {
 			Type:        jsonschema.String,
-			Description: "Filter by type: 'files', 'folders', or empty for all",
+			Description: "Filter by type: 'files' or 'folders', leave out for all",
+			Enum:        []string{"files", "folders"},
 		},
 	},
 	Required: []string{
@@ -56,40 +57,53 @@ var SearchFilesToolParams = jsonschema.Definition{
 	},
 }
 
-var SearchFilesOpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "search_files",
-	Description: "Search for files and folders by pattern with optional filtering",
-	Parameters:  SearchFilesToolParams,
-}
-
-var SearchFilesTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &SearchFilesOpenrouterFn,
+func init() {
+	Register(Spec[SearchFilesParams, SearchFilesResult]{
+		Name:        "search_files",
+		Description: "Search for files and folders by pattern with optional filtering",
+		Parameters:  SearchFilesToolParams,
+		Class:       ReadOnly,
+		Handler:     SearchFiles,
+		Display: func(params SearchFilesParams) (string, string) {
+			if params.Pattern == "" {
+				return "Searching for files", "unknown pattern"
+			}
+			return "Searching for files", "pattern: " + params.Pattern + inPath(params.SearchPath)
+		},
+		Paths: func(params SearchFilesParams) []string {
+			return []string{params.SearchPath}
+		},
+	})
 }
 
-func SearchFiles(params SearchFilesParams) (SearchFilesResult, ToolError) {
+func SearchFiles(ctx context.Context, params SearchFilesParams) (SearchFilesResult, ToolError) {
 	searchPath := params.SearchPath
 	if searchPath == "" {
 		searchPath = "."
 	}
 
+	resolvedPath, toolErr := resolveToolPath(ctx, searchPath)
+	if toolErr.Err != nil {
+		return SearchFilesResult{}, toolErr
+	}
+
 	var results []SearchResult
 
-	err := filepath.Walk(searchPath, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(resolvedPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Continue walking even if there's an error with one file
 		}
 
 		// If not recursive, only check the immediate directory
-		if !params.Recursive && filepath.Dir(path) != searchPath {
-			if info.IsDir() && path != searchPath {
+		if !params.Recursive && filepath.Dir(path) != resolvedPath {
+			if info.IsDir() && path != resolvedPath {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 
 		// Skip the root directory itself
-		if path == searchPath {
+		if path == resolvedPath {
 			return nil
 		}
 
@@ -119,10 +133,13 @@ func SearchFiles(params SearchFilesParams) (SearchFilesResult, ToolError) {
 				size = &fileSize
 			}
 
+			// Report paths the way the search path was given
+			relPath, _ := filepath.Rel(resolvedPath, path)
+
 			results = append(results, SearchResult{
 				Name: info.Name(),
 				Type: itemType,
-				Path: path,
+				Path: filepath.Join(searchPath, relPath),
 				Size: size,
 			})
 		}
@@ -1,9 +1,27 @@
 package tools
 
+import "encoding/json"
+
 type ToolError struct {
 	Success bool
 	Message string
 	Err     error
+	// Problems lists every argument that didn't match the tool's schema, so the model can fix them all in one go
+	Problems []ArgumentProblem
+}
+
+// MarshalJSON writes Err as its message; an error value would otherwise be encoded as {} and tell the model nothing
+func (e ToolError) MarshalJSON() ([]byte, error) {
+	var errText string
+	if e.Err != nil {
+		errText = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		Success  bool
+		Message  string
+		Err      string            `json:",omitempty"`
+		Problems []ArgumentProblem `json:",omitempty"`
+	}{e.Success, e.Message, errText, e.Problems})
 }
 
 type ToolResponse struct {
@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+// ArgumentProblem is one way the arguments of a tool call break the tool's schema
+type ArgumentProblem struct {
+	// Property is the path of the argument, e.g. "EditMode" or "Include[1]", empty for the arguments as a whole
+	Property string `json:",omitempty"`
+	Problem  string
+}
+
+func (p ArgumentProblem) String() string {
+	if p.Property == "" {
+		return p.Problem
+	}
+	return p.Property + " " + p.Problem
+}
+
+// ArgumentsError is returned for a tool call whose arguments don't match the tool's schema
+type ArgumentsError struct {
+	Tool     string
+	Problems []ArgumentProblem
+}
+
+func (e *ArgumentsError) Error() string {
+	return fmt.Sprintf("invalid arguments for %s: %s", e.Tool, strings.Join(problemTexts(e.Problems), "; "))
+}
+
+// invalidArguments returns the tool error for arguments that don't match the schema, telling the model how to fix them
+func invalidArguments(err *ArgumentsError) ToolError {
+	return ToolError{
+		Success:  false,
+		Message:  fmt.Sprintf("The arguments don't match the schema of %s, fix them and call it again: %s", err.Tool, strings.Join(problemTexts(err.Problems), "; ")),
+		Err:      err,
+		Problems: err.Problems,
+	}
+}
+
+func problemTexts(problems []ArgumentProblem) []string {
+	texts := make([]string, len(problems))
+	for i, problem := range problems {
+		texts[i] = problem.String()
+	}
+	return texts
+}
+
+// validateArguments checks the JSON arguments of a call against the tool's schema: required properties, unknown
+// properties, types and enum values. It returns every problem found, none when the arguments are valid.
+func validateArguments(schema jsonschema.Definition, arguments string) []ArgumentProblem {
+	decoder := json.NewDecoder(strings.NewReader(arguments))
+	// Numbers are kept as written, so 1.5 can be told apart from an integer
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []ArgumentProblem{{Problem: fmt.Sprintf("the arguments are not valid JSON: %s", err.Error())}}
+	}
+	if decoder.More() {
+		return []ArgumentProblem{{Problem: "the arguments must be a single JSON object"}}
+	}
+
+	var problems []ArgumentProblem
+	validateValue(schema, value, "", &problems)
+	return problems
+}
+
+func validateValue(schema jsonschema.Definition, value any, path string, problems *[]ArgumentProblem) {
+	report := func(format string, args ...any) {
+		problem := fmt.Sprintf(format, args...)
+		if path == "" {
+			problem = "the arguments " + problem
+		}
+		*problems = append(*problems, ArgumentProblem{Property: path, Problem: problem})
+	}
+
+	if value == nil {
+		if !schema.Nullable && schema.Type != jsonschema.Null && schema.Type != "" {
+			report("must be %s, got null", article(schema.Type))
+		}
+		return
+	}
+
+	switch schema.Type {
+	case jsonschema.Object:
+		object, ok := value.(map[string]any)
+		if !ok {
+			report("must be an object, got %s", describe(value))
+			return
+		}
+		validateObject(schema, object, path, problems)
+
+	case jsonschema.Array:
+		items, ok := value.([]any)
+		if !ok {
+			report("must be an array, got %s", describe(value))
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				validateValue(*schema.Items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+
+	case jsonschema.String:
+		text, ok := value.(string)
+		if !ok {
+			report("must be a string, got %s", describe(value))
+			return
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, text) {
+			report("must be one of %s, got %q", quoteAll(schema.Enum), text)
+		}
+
+	case jsonschema.Integer:
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			report("must be an integer, got %s", describe(value))
+		}
+
+	case jsonschema.Number:
+		if _, ok := value.(json.Number); !ok {
+			report("must be a number, got %s", describe(value))
+		}
+
+	case jsonschema.Boolean:
+		if _, ok := value.(bool); !ok {
+			report("must be true or false, got %s", describe(value))
+		}
+
+	case jsonschema.Null:
+		report("must be null, got %s", describe(value))
+	}
+}
+
+func validateObject(schema jsonschema.Definition, object map[string]any, path string, problems *[]ArgumentProblem) {
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	for _, name := range schema.Required {
+		if value, ok := object[name]; !ok || value == nil {
+			*problems = append(*problems, ArgumentProblem{Property: join(name), Problem: "is required"})
+		}
+	}
+
+	// Sorted so the problems come out in the same order every time
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := object[name]
+		property, ok := schema.Properties[name]
+		if !ok {
+			switch additional := schema.AdditionalProperties.(type) {
+			case bool:
+				if additional {
+					continue
+				}
+			case jsonschema.Definition:
+				validateValue(additional, value, join(name), problems)
+				continue
+			}
+			*problems = append(*problems, ArgumentProblem{Property: join(name), Problem: unknownProperty(schema, name)})
+			continue
+		}
+
+		// A required property sent as null was reported above. Models often send null or "" for an optional
+		// property they mean to leave out, which the tools treat the same way.
+		if value == nil || value == "" && property.Type == jsonschema.String && !slices.Contains(schema.Required, name) {
+			continue
+		}
+		validateValue(property, value, join(name), problems)
+	}
+}
+
+// unknownProperty names the property the model probably meant, or lists the ones the tool takes
+func unknownProperty(schema jsonschema.Definition, name string) string {
+	known := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		known = append(known, property)
+	}
+	if len(known) == 0 {
+		return "is not a known property, this takes no arguments"
+	}
+	sort.Strings(known)
+
+	// A different case, or a typo of up to two letters in a name that is long enough to tell them apart
+	best, bestDistance := "", 3
+	for _, property := range known {
+		if strings.EqualFold(property, name) {
+			best, bestDistance = property, 0
+			break
+		}
+		distance := editDistance(strings.ToLower(property), strings.ToLower(name))
+		if distance < bestDistance && distance < len(property)/2 {
+			best, bestDistance = property, distance
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("is not a known property, did you mean %q?", best)
+	}
+	return fmt.Sprintf("is not a known property, use one of %s", quoteAll(known))
+}
+
+// editDistance counts the letters to insert, delete or replace to turn a into b
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// describe names the JSON type of a value for an error message, with the value itself when it is short
+func describe(value any) string {
+	switch value := value.(type) {
+	case string:
+		if len(value) > 40 {
+			return "a string"
+		}
+		return fmt.Sprintf("the string %q", value)
+	case json.Number:
+		return "the number " + value.String()
+	case bool:
+		return fmt.Sprintf("%t", value)
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func article(dataType jsonschema.DataType) string {
+	switch dataType {
+	case jsonschema.Object, jsonschema.Array, jsonschema.Integer:
+		return "an " + string(dataType)
+	case jsonschema.Boolean:
+		return "true or false"
+	}
+	return "a " + string(dataType)
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+// testSchema has a property of every kind the validator checks
+var testSchema = jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"path":      {Type: jsonschema.String},
+		"mode":      {Type: jsonschema.String, Enum: []string{"replace", "insert"}},
+		"line":      {Type: jsonschema.Integer},
+		"ratio":     {Type: jsonschema.Number},
+		"recursive": {Type: jsonschema.Boolean},
+		"include":   {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+		"comment":   {Type: jsonschema.String, Nullable: true},
+		"replacement": {
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"start": {Type: jsonschema.Integer},
+				"lines": {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+			},
+			Required: []string{"start"},
+		},
+	},
+	Required: []string{"path", "mode"},
+}
+
+func TestValidateArguments(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      []ArgumentProblem
+	}{
+		{"valid", `{"path":"a.go","mode":"insert","line":3,"ratio":0.5,"recursive":true,"include":["*.go"]}`, nil},
+		// The tools decode integers with encoding/json, which refuses 3.0 as well
+		{"integer written with a fraction", `{"path":"a.go","mode":"insert","line":3.0}`,
+			[]ArgumentProblem{{"line", "must be an integer, got the number 3.0"}}},
+
+		// Required properties
+		{"missing required", `{"mode":"insert"}`, []ArgumentProblem{{"path", "is required"}}},
+		{"required null", `{"path":null,"mode":"insert"}`, []ArgumentProblem{{"path", "is required"}}},
+		{"all missing", `{}`, []ArgumentProblem{{"path", "is required"}, {"mode", "is required"}}},
+
+		// Enums and types
+		{"outside the enum", `{"path":"a.go","mode":"append"}`,
+			[]ArgumentProblem{{"mode", `must be one of "replace", "insert", got "append"`}}},
+		{"fractional integer", `{"path":"a.go","mode":"insert","line":1.5}`,
+			[]ArgumentProblem{{"line", "must be an integer, got the number 1.5"}}},
+		{"integer as a string", `{"path":"a.go","mode":"insert","line":"3"}`,
+			[]ArgumentProblem{{"line", `must be an integer, got the string "3"`}}},
+		{"number as a string", `{"path":"a.go","mode":"insert","ratio":"half"}`,
+			[]ArgumentProblem{{"ratio", `must be a number, got the string "half"`}}},
+		{"boolean as a string", `{"path":"a.go","mode":"insert","recursive":"yes"}`,
+			[]ArgumentProblem{{"recursive", `must be true or false, got the string "yes"`}}},
+		{"string as a number", `{"path":7,"mode":"insert"}`,
+			[]ArgumentProblem{{"path", "must be a string, got the number 7"}}},
+
+		// Optional properties the model means to leave out
+		{"null optional", `{"path":"a.go","mode":"insert","line":null,"include":null,"replacement":null}`, nil},
+		{"empty optional string", `{"path":"a.go","mode":"insert","comment":""}`, nil},
+		{"empty string for an optional integer", `{"path":"a.go","mode":"insert","line":""}`,
+			[]ArgumentProblem{{"line", `must be an integer, got the string ""`}}},
+		{"empty required string is checked", `{"path":"a.go","mode":""}`,
+			[]ArgumentProblem{{"mode", `must be one of "replace", "insert", got ""`}}},
+
+		// Nested objects and arrays
+		{"valid nested", `{"path":"a.go","mode":"replace","replacement":{"start":1,"lines":["a","b"]}}`, nil},
+		{"nested required", `{"path":"a.go","mode":"replace","replacement":{"lines":[]}}`,
+			[]ArgumentProblem{{"replacement.start", "is required"}}},
+		{"nested item", `{"path":"a.go","mode":"replace","replacement":{"start":1,"lines":["a",2]}}`,
+			[]ArgumentProblem{{"replacement.lines[1]", "must be a string, got the number 2"}}},
+		{"array item", `{"path":"a.go","mode":"insert","include":["*.go",true]}`,
+			[]ArgumentProblem{{"include[1]", "must be a string, got true"}}},
+		{"object for an array", `{"path":"a.go","mode":"insert","include":{"a":1}}`,
+			[]ArgumentProblem{{"include", "must be an array, got an object"}}},
+		{"array for an object", `{"path":"a.go","mode":"replace","replacement":[1]}`,
+			[]ArgumentProblem{{"replacement", "must be an object, got an array"}}},
+		{"unknown nested", `{"path":"a.go","mode":"replace","replacement":{"start":1,"end":2}}`,
+			[]ArgumentProblem{{"replacement.end", `is not a known property, use one of "lines", "start"`}}},
+
+		// Unknown properties, with a suggestion when one is close
+		{"other case", `{"Path":"a.go","mode":"insert"}`,
+			[]ArgumentProblem{{"path", "is required"}, {"Path", `is not a known property, did you mean "path"?`}}},
+		{"misspelled", `{"path":"a.go","mode":"insert","recursve":true}`,
+			[]ArgumentProblem{{"recursve", `is not a known property, did you mean "recursive"?`}}},
+		{"swapped letters", `{"path":"a.go","mode":"insert","incldue":["x"]}`,
+			[]ArgumentProblem{{"incldue", `is not a known property, did you mean "include"?`}}},
+		{"nothing close", `{"path":"a.go","mode":"insert","verbose":true}`,
+			[]ArgumentProblem{{"verbose", `is not a known property, use one of "comment", "include", "line", "mode", "path", "ratio", "recursive", "replacement"`}}},
+
+		// The arguments as a whole
+		{"not JSON", `{"path":`, []ArgumentProblem{{"", "the arguments are not valid JSON: unexpected EOF"}}},
+		{"two values", `{} {}`, []ArgumentProblem{{"", "the arguments must be a single JSON object"}}},
+		{"not an object", `["a.go"]`, []ArgumentProblem{{"", "the arguments must be an object, got an array"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := validateArguments(testSchema, test.arguments)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("validateArguments(%s)\n got %q\nwant %q", test.arguments, got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateArgumentsWithoutProperties(t *testing.T) {
+	schema := jsonschema.Definition{Type: jsonschema.Object, Properties: map[string]jsonschema.Definition{}}
+	want := []ArgumentProblem{{"path", "is not a known property, this takes no arguments"}}
+	if got := validateArguments(schema, `{"path":"."}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := validateArguments(schema, `{}`); got != nil {
+		t.Errorf("no arguments gave %q", got)
+	}
+}
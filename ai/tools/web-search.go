@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,7 +9,6 @@ import (
 	"net/url"
 
 	"github.com/krishkalaria12/nyron-ai-cli/config"
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
@@ -105,23 +105,28 @@ var WebSearchToolParams = jsonschema.Definition{
 	},
 }
 
-var WebSearchOpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "web_search",
-	Description: "Search the web using Serper API",
-	Parameters:  WebSearchToolParams,
-}
-
-var WebSearchTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &WebSearchOpenrouterFn,
+func init() {
+	Register(Spec[WebSearchParams, WebSearchResult]{
+		Name:        "web_search",
+		Description: "Search the web using Serper API",
+		Parameters:  WebSearchToolParams,
+		Class:       ReadOnly,
+		Handler:     WebSearch,
+		Display: func(params WebSearchParams) (string, string) {
+			if params.Query == "" {
+				return "Searching web", "unknown query"
+			}
+			return "Searching web", "\"" + params.Query + "\""
+		},
+	})
 }
 
-func WebSearch(params WebSearchParams) (WebSearchResult, ToolError) {
-	apiKey := config.Config("SERPER_API_KEY")
+func WebSearch(ctx context.Context, params WebSearchParams) (WebSearchResult, ToolError) {
+	apiKey := config.SerperAPIKey()
 	if apiKey == "" {
 		return WebSearchResult{}, ToolError{
 			Success: false,
-			Message: "SERPER_API_KEY environment variable not set",
+			Message: "Web search is not configured: SERPER_API_KEY is not set",
 			Err:     nil,
 		}
 	}
@@ -164,7 +169,7 @@ func WebSearch(params WebSearchParams) (WebSearchResult, ToolError) {
 
 	// Create HTTP client and request
 	client := &http.Client{}
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return WebSearchResult{}, ToolError{
 			Success: false,
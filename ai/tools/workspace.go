@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// Workspace confines the file tools to the project root, the configured extra roots
+// and the paths the user approved for this session
+type Workspace struct {
+	root       string
+	extraRoots []string
+
+	mu      sync.RWMutex
+	granted []string
+}
+
+// OutsideRootError is returned for a path that resolves outside every allowed root
+type OutsideRootError struct {
+	Path string
+	Root string
+}
+
+func (e *OutsideRootError) Error() string {
+	return fmt.Sprintf("%s is outside the workspace root %s", e.Path, e.Root)
+}
+
+var (
+	workspaceMu      sync.Mutex
+	currentWorkspace *Workspace
+)
+
+type grantedPathsKey struct{}
+
+// NewWorkspace returns a workspace rooted at root; relative tool paths resolve against it
+func NewWorkspace(root string, extraRoots []string) (*Workspace, error) {
+	realRoot, err := realPath(root)
+	if err != nil {
+		return nil, fmt.Errorf("workspace root %s: %w", root, err)
+	}
+
+	w := &Workspace{root: realRoot}
+	for _, extra := range extraRoots {
+		realExtra, err := realPath(extra)
+		if err != nil {
+			return nil, fmt.Errorf("extra root %s: %w", extra, err)
+		}
+		w.extraRoots = append(w.extraRoots, realExtra)
+	}
+	return w, nil
+}
+
+// NewWorkspaceFromConfig returns a workspace rooted at the configured root, or the launch directory,
+// with the configured extra roots
+func NewWorkspaceFromConfig() (*Workspace, error) {
+	root := config.WorkspaceRoot()
+	if root == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		root = cwd
+	}
+	return NewWorkspace(root, config.ExtraRoots())
+}
+
+// SetWorkspace replaces the workspace used by the file tools
+func SetWorkspace(w *Workspace) {
+	workspaceMu.Lock()
+	defer workspaceMu.Unlock()
+	currentWorkspace = w
+}
+
+// CurrentWorkspace returns the workspace used by the file tools, rooted at the launch directory if none was set
+func CurrentWorkspace() *Workspace {
+	workspaceMu.Lock()
+	defer workspaceMu.Unlock()
+
+	if currentWorkspace == nil {
+		cwd, _ := os.Getwd()
+		w, err := NewWorkspace(cwd, nil)
+		if err != nil {
+			w = &Workspace{root: filepath.Clean(cwd)}
+		}
+		currentWorkspace = w
+	}
+	return currentWorkspace
+}
+
+// Root returns the resolved workspace root
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Grant allows access to the given paths, and everything below them, for the rest of the session
+func (w *Workspace) Grant(paths ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, path := range paths {
+		if real, err := realPath(w.abs(path)); err == nil {
+			w.granted = append(w.granted, real)
+		}
+	}
+}
+
+// WithGrantedPaths allows access to the given paths for the tool calls run with the returned context
+func WithGrantedPaths(ctx context.Context, paths []string) context.Context {
+	return context.WithValue(ctx, grantedPathsKey{}, paths)
+}
+
+// Resolve returns the absolute path a tool should use for path, or an *OutsideRootError when the path,
+// after following symlinks, leaves every allowed root
+func (w *Workspace) Resolve(ctx context.Context, path string) (string, error) {
+	absPath := w.abs(path)
+	real, err := realPath(absPath)
+	if err != nil {
+		return "", err
+	}
+
+	if w.allows(ctx, real) {
+		return absPath, nil
+	}
+	return "", &OutsideRootError{Path: path, Root: w.root}
+}
+
+// OutsidePaths returns the paths of a tool call that resolve outside the allowed roots
+func (w *Workspace) OutsidePaths(ctx context.Context, toolName string, arguments string) []string {
+	var outside []string
+	for _, path := range toolPaths(toolName, arguments) {
+		var outsideErr *OutsideRootError
+		if _, err := w.Resolve(ctx, path); errors.As(err, &outsideErr) {
+			outside = append(outside, path)
+		}
+	}
+	return outside
+}
+
+func (w *Workspace) abs(path string) string {
+	if path == "" {
+		path = "."
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(w.root, path)
+	}
+	return filepath.Clean(path)
+}
+
+func (w *Workspace) allows(ctx context.Context, real string) bool {
+	if within(real, w.root) {
+		return true
+	}
+	for _, extra := range w.extraRoots {
+		if within(real, extra) {
+			return true
+		}
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, granted := range w.granted {
+		if within(real, granted) {
+			return true
+		}
+	}
+
+	paths, _ := ctx.Value(grantedPathsKey{}).([]string)
+	for _, path := range paths {
+		if granted, err := realPath(w.abs(path)); err == nil && within(real, granted) {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath resolves every symlink in path; for paths that don't exist yet the nearest existing parent is resolved
+func realPath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	real, err := filepath.EvalSymlinks(absPath)
+	if err == nil {
+		return real, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	parent := filepath.Dir(absPath)
+	if parent == absPath {
+		return absPath, nil
+	}
+	realParent, err := realPath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(realParent, filepath.Base(absPath)), nil
+}
+
+// within reports whether path is root or below it
+func within(path string, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveToolPath resolves a path argument against the current workspace for a file tool
+func resolveToolPath(ctx context.Context, path string) (string, ToolError) {
+	resolved, err := CurrentWorkspace().Resolve(ctx, path)
+	if err == nil {
+		return resolved, ToolError{}
+	}
+
+	var outsideErr *OutsideRootError
+	if errors.As(err, &outsideErr) {
+		return "", ToolError{
+			Success: false,
+			Message: fmt.Sprintf("Access denied: %s. File tools can only use paths inside the workspace root or the configured extra roots", outsideErr.Error()),
+			Err:     err,
+		}
+	}
+	return "", ToolError{
+		Success: false,
+		Message: fmt.Sprintf("Error resolving path %s: %s", path, err.Error()),
+		Err:     err,
+	}
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestWorkspace returns a workspace in a temporary project, next to an outside folder and an extra root
+func newTestWorkspace(t *testing.T) (w *Workspace, root string, outside string, extra string) {
+	t.Helper()
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root = filepath.Join(base, "project")
+	outside = filepath.Join(base, "outside")
+	extra = filepath.Join(base, "shared")
+	for _, dir := range []string{filepath.Join(root, "src"), outside, extra} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{filepath.Join(root, "src", "main.go"), filepath.Join(outside, "secret.txt"), filepath.Join(extra, "lib.go")} {
+		if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err = NewWorkspace(root, []string{extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, root, outside, extra
+}
+
+func TestWorkspaceResolve(t *testing.T) {
+	w, root, outside, extra := newTestWorkspace(t)
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skip("symlinks aren't available:", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "src"), filepath.Join(root, "code")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		want    string // Empty when the path is outside
+		outside bool
+	}{
+		{path: "src/main.go", want: filepath.Join(root, "src", "main.go")},
+		{path: "", want: root},
+		{path: ".", want: root},
+		{path: filepath.Join(root, "src"), want: filepath.Join(root, "src")},
+		{path: "src/new/file.go", want: filepath.Join(root, "src", "new", "file.go")},
+		// .. that stays inside the root is fine
+		{path: "src/../src/main.go", want: filepath.Join(root, "src", "main.go")},
+		// A symlink that stays inside the root is fine too, and the path keeps the link
+		{path: "code/main.go", want: filepath.Join(root, "code", "main.go")},
+		{path: filepath.Join(extra, "lib.go"), want: filepath.Join(extra, "lib.go")},
+		{path: "../shared/lib.go", want: filepath.Join(extra, "lib.go")},
+
+		{path: "../outside/secret.txt", outside: true},
+		{path: "src/../../outside", outside: true},
+		{path: filepath.Join(outside, "secret.txt"), outside: true},
+		{path: "escape/secret.txt", outside: true},
+		// Also for files that don't exist yet below the symlink
+		{path: "escape/new.txt", outside: true},
+		{path: "/", outside: true},
+	}
+	for _, test := range tests {
+		got, err := w.Resolve(context.Background(), test.path)
+		var outsideErr *OutsideRootError
+		if test.outside {
+			if !errors.As(err, &outsideErr) {
+				t.Errorf("Resolve(%q) = %q, %v, want an OutsideRootError", test.path, got, err)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", test.path, got, err, test.want)
+		}
+	}
+}
+
+func TestOutsideRootError(t *testing.T) {
+	w, root, _, _ := newTestWorkspace(t)
+
+	_, err := w.Resolve(context.Background(), "../outside/secret.txt")
+	var outsideErr *OutsideRootError
+	if !errors.As(err, &outsideErr) {
+		t.Fatalf("Resolve returned %v, want an OutsideRootError", err)
+	}
+	if outsideErr.Path != "../outside/secret.txt" || outsideErr.Root != root {
+		t.Errorf("error = %+v, want the path as given and the root", outsideErr)
+	}
+	if want := "../outside/secret.txt is outside the workspace root " + root; err.Error() != want {
+		t.Errorf("error message = %q, want %q", err.Error(), want)
+	}
+
+	// The file tools report it to the model as an access error
+	_, toolErr := resolveToolPathIn(w, "../outside/secret.txt")
+	if !strings.HasPrefix(toolErr.Message, "Access denied: ") || !errors.As(toolErr.Err, &outsideErr) {
+		t.Errorf("tool error = %+v, want access denied", toolErr)
+	}
+}
+
+func TestWorkspaceGrants(t *testing.T) {
+	w, _, outside, _ := newTestWorkspace(t)
+	secret := filepath.Join(outside, "secret.txt")
+
+	// Granted for one call through the context
+	ctx := WithGrantedPaths(context.Background(), []string{outside})
+	if _, err := w.Resolve(ctx, secret); err != nil {
+		t.Errorf("a path granted for the call was refused: %v", err)
+	}
+	if _, err := w.Resolve(context.Background(), secret); err == nil {
+		t.Error("a path granted for one call was allowed for others")
+	}
+
+	// Granted for the session
+	w.Grant(outside)
+	if _, err := w.Resolve(context.Background(), secret); err != nil {
+		t.Errorf("a path granted for the session was refused: %v", err)
+	}
+}
+
+func TestGetCurrentDirectoryReturnsWorkspaceRoot(t *testing.T) {
+	w, root, _, _ := newTestWorkspace(t)
+	previous := CurrentWorkspace()
+	SetWorkspace(w)
+	defer SetWorkspace(previous)
+
+	result, toolErr := GetCurrentDirectory(context.Background(), GetCurrentDirectoryParams{})
+	if toolErr.Err != nil || result.CurrentDirectory != root {
+		t.Errorf("GetCurrentDirectory = %q, %v, want the workspace root %q", result.CurrentDirectory, toolErr.Err, root)
+	}
+}
+
+// resolveToolPathIn runs resolveToolPath with w as the current workspace
+func resolveToolPathIn(w *Workspace, path string) (string, ToolError) {
+	previous := CurrentWorkspace()
+	SetWorkspace(w)
+	defer SetWorkspace(previous)
+	return resolveToolPath(context.Background(), path)
+}
@@ -1,10 +1,10 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"os"
 
-	"github.com/revrost/go-openrouter"
 	"github.com/revrost/go-openrouter/jsonschema"
 )
 
@@ -34,6 +34,7 @@ var WriteToolParams = jsonschema.Definition{
 		"Mode": {
 			Type:        jsonschema.String,
 			Description: "Write mode: 'overwrite' or 'append'",
+			Enum:        []string{"overwrite", "append"},
 		},
 	},
 	Required: []string{
@@ -43,21 +44,33 @@ var WriteToolParams = jsonschema.Definition{
 	},
 }
 
-var WriteOpenrouterFn = openrouter.FunctionDefinition{
-	Name:        "write_content",
-	Description: "Write content to a file with overwrite or append mode",
-	Parameters:  WriteToolParams,
+func init() {
+	Register(Spec[WriteParams, WriteSuccess]{
+		Name:        "write_content",
+		Description: "Write content to a file with overwrite or append mode",
+		Parameters:  WriteToolParams,
+		Class:       Mutating,
+		Handler:     WriteContent,
+		Display: func(params WriteParams) (string, string) {
+			return "Writing to file", orUnknown(params.FilePath, "file")
+		},
+		Paths: func(params WriteParams) []string {
+			return []string{params.FilePath}
+		},
+		Undoable: true,
+		Preview:  PreviewWrite,
+	})
 }
 
-var WriteContentTool = openrouter.Tool{
-	Type:     openrouter.ToolTypeFunction,
-	Function: &WriteOpenrouterFn,
-}
+func WriteContent(ctx context.Context, params WriteParams) (WriteSuccess, ToolError) {
+	filePath, toolErr := resolveToolPath(ctx, params.FilePath)
+	if toolErr.Err != nil {
+		return WriteSuccess{}, toolErr
+	}
 
-func WriteContent(params WriteParams) (WriteSuccess, ToolError) {
 	switch params.Mode {
 	case "overwrite":
-		err := os.WriteFile(params.FilePath, []byte(params.Content), 0644)
+		err := os.WriteFile(filePath, []byte(params.Content), 0644)
 		if err != nil {
 			toolErr := ToolError{
 				Success: false,
@@ -75,7 +88,7 @@ func WriteContent(params WriteParams) (WriteSuccess, ToolError) {
 		return toolSuc, ToolError{}
 
 	case "append":
-		file, err := os.OpenFile(params.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
 			toolErr := ToolError{
 				Success: false,
@@ -0,0 +1,162 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/provider"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// TTL is how long a fetched catalog is used before it is fetched again
+const TTL = 24 * time.Hour
+
+// Source tells where the models of a catalog came from
+type Source string
+
+const (
+	SourceFetched Source = "fetched"  // Fetched from the provider just now
+	SourceCache   Source = "cache"    // Read from the cache, which is younger than its TTL
+	SourceStale   Source = "stale"    // Read from an expired cache because fetching failed
+	SourceBuiltin Source = "built-in" // The models that ship with the program
+)
+
+// Catalog is the list of models of a provider
+type Catalog struct {
+	ProviderID string
+	Models     []config.Model
+	Source     Source
+	FetchedAt  time.Time // When the models were fetched, zero for the built-in models
+	Err        error     // Why the catalog couldn't be fetched, if it was tried and failed
+}
+
+// cacheFile is the layout of a cached catalog
+type cacheFile struct {
+	FetchedAt time.Time      `json:"fetched_at"`
+	Models    []config.Model `json:"models"`
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/nyron/models, or ~/.cache/nyron/models when XDG_CACHE_HOME is not set
+func DefaultDir() (string, error) {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cacheHome, "nyron", "models"), nil
+}
+
+// ttl returns how long the catalog of a provider stays fresh. The models of the local Ollama server are
+// listed again every time, so newly pulled ones show up; their cache only serves when the server is down.
+func ttl(providerID string) time.Duration {
+	if providerID == config.ProviderOllama.ID {
+		return 0
+	}
+	return TTL
+}
+
+// LoadCached makes the cached catalog of a provider the one in use, however old it is, without using the
+// network. It returns the built-in models when nothing is cached.
+func LoadCached(providerID string) Catalog {
+	cached, err := readCache(providerID)
+	if err != nil {
+		return builtin(providerID, nil)
+	}
+	return use(Catalog{ProviderID: providerID, Models: cached.Models, Source: SourceCache, FetchedAt: cached.FetchedAt})
+}
+
+// Load makes the catalog of a provider the one in use: the cached one while it is fresh, otherwise a fetched
+// one, and when fetching fails the expired cache or the built-in models
+func Load(ctx context.Context, providerID string) Catalog {
+	if cached, err := readCache(providerID); err == nil && time.Since(cached.FetchedAt) < ttl(providerID) {
+		return use(Catalog{ProviderID: providerID, Models: cached.Models, Source: SourceCache, FetchedAt: cached.FetchedAt})
+	}
+	return Refresh(ctx, providerID)
+}
+
+// Refresh fetches the catalog of a provider whatever the age of its cache
+func Refresh(ctx context.Context, providerID string) Catalog {
+	models, err := provider.ListModels(ctx, providerID)
+	if errors.Is(err, provider.ErrNoCatalog) {
+		return builtin(providerID, nil)
+	}
+	if err != nil {
+		if cached, cacheErr := readCache(providerID); cacheErr == nil {
+			return use(Catalog{ProviderID: providerID, Models: cached.Models, Source: SourceStale, FetchedAt: cached.FetchedAt, Err: err})
+		}
+		return builtin(providerID, err)
+	}
+
+	fetched := Catalog{ProviderID: providerID, Models: models, Source: SourceFetched, FetchedAt: time.Now()}
+	if err := writeCache(providerID, cacheFile{FetchedAt: fetched.FetchedAt, Models: models}); err != nil {
+		// The catalog still works without the cache, it is just fetched again next time
+		fetched.Err = err
+	}
+	return use(fetched)
+}
+
+// builtin goes back to the models that ship with the program
+func builtin(providerID string, err error) Catalog {
+	config.SetModels(providerID, config.BuiltinModels(providerID))
+	return Catalog{ProviderID: providerID, Models: config.BuiltinModels(providerID), Source: SourceBuiltin, Err: err}
+}
+
+func use(catalog Catalog) Catalog {
+	config.SetModels(catalog.ProviderID, catalog.Models)
+	return catalog
+}
+
+func cachePath(providerID string) (string, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, providerID+".json"), nil
+}
+
+func readCache(providerID string) (*cacheFile, error) {
+	path, err := cachePath(providerID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cached cacheFile
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("reading model cache: %w", err)
+	}
+	return &cached, nil
+}
+
+// writeCache replaces the cached catalog through a temporary file, so a reader never sees half of it
+func writeCache(providerID string, cached cacheFile) error {
+	path, err := cachePath(providerID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("caching models: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("caching models: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("caching models: %w", err)
+	}
+	return nil
+}
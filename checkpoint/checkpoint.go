@@ -0,0 +1,348 @@
+// Package checkpoint snapshots the files the agent changes, grouped per turn, so the workspace can be
+// taken back to the state before any turn and forward again
+package checkpoint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNothingToUndo is returned by Undo when no turn changed files, or all of them were undone
+	ErrNothingToUndo = errors.New("no file changes to undo")
+	// ErrNothingToRedo is returned by Redo when no turn was undone since the last change
+	ErrNothingToRedo = errors.New("no undone changes to redo")
+)
+
+// Checkpoint describes a turn that changed files
+type Checkpoint struct {
+	ID    int
+	Label string // What the turn was asked to do, e.g. the user's message
+	Time  time.Time
+	Paths []string // The files and folders the turn changed, in the order it changed them
+	// Applied is false once the turn was undone; Redo applies it again
+	Applied bool
+}
+
+// Result tells what restoring a checkpoint did to the workspace
+type Result struct {
+	Checkpoint Checkpoint
+	Restored   int // Files written back or folders made again
+	Removed    int // Files and folders deleted because they didn't exist on the other side
+	Err        error
+}
+
+// Store keeps the checkpoints of a session in memory. Turns [0, applied) are in effect, the turns after
+// them were undone and can be redone until a new turn changes files.
+type Store struct {
+	mu      sync.Mutex
+	turns   []*turn
+	applied int
+	current *turn // The turn in flight, added to turns once it changes something
+	nextID  int
+}
+
+type turn struct {
+	id     int
+	label  string
+	time   time.Time
+	paths  []string
+	before map[string]state // Each path as it was before the turn first changed it
+	after  map[string]state // Each path as the turn left it, taken when it is undone
+}
+
+// state is a file, folder or symlink as it was at some point, or the fact that nothing was there
+type state struct {
+	exists  bool
+	dir     bool
+	link    string // The target of a symlink, which is kept as a link rather than followed
+	mode    fs.FileMode
+	content []byte
+}
+
+// New returns an empty store
+func New() *Store {
+	return &Store{}
+}
+
+// Begin starts the checkpoint of a new turn; the paths recorded until the next Begin belong to it
+func (s *Store) Begin(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.current = &turn{id: s.nextID, label: label, time: time.Now(), before: map[string]state{}}
+}
+
+// Record snapshots a path before the current turn changes it. Only the first change of a path in a turn
+// is kept. For a path that doesn't exist yet the missing folders above it are recorded too, since the
+// tools create them, so undoing the turn removes them again. A folder that exists already is left out,
+// there is nothing in it to restore. A symlink is recorded as a link, together with the file it points to,
+// which is what the tools write.
+func (s *Store) Record(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return
+	}
+	s.record(path)
+	if target, ok := linkTarget(path); ok {
+		s.record(target)
+	}
+}
+
+// record snapshots one path and the folders missing above it; the caller holds s.mu
+func (s *Store) record(path string) {
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		missing = append(missing, dir)
+	}
+	if len(missing) == 0 {
+		if info, err := os.Lstat(path); err == nil && info.IsDir() {
+			return
+		}
+		missing = []string{path}
+	}
+
+	// Parents first, so that restoring in reverse removes the children before their folders
+	for _, p := range slices.Backward(missing) {
+		if _, ok := s.current.before[p]; ok {
+			continue
+		}
+		s.current.before[p] = capture(p)
+		s.current.paths = append(s.current.paths, p)
+	}
+
+	// The first change of a turn makes it a checkpoint, and the undone turns can no longer be redone
+	if s.applied == 0 || s.turns[s.applied-1] != s.current {
+		s.turns = append(s.turns[:s.applied], s.current)
+		s.applied++
+	}
+}
+
+// List returns the checkpoints, oldest first
+func (s *Store) List() []Checkpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoints := make([]Checkpoint, 0, len(s.turns))
+	for i, t := range s.turns {
+		checkpoints = append(checkpoints, t.checkpoint(i < s.applied))
+	}
+	return checkpoints
+}
+
+// Undo takes the files of the last applied turn back to how they were before it
+func (s *Store) Undo() (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.applied == 0 {
+		return Result{}, ErrNothingToUndo
+	}
+	return s.undo(), nil
+}
+
+// Redo applies the changes of the last undone turn again
+func (s *Store) Redo() (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.applied == len(s.turns) {
+		return Result{}, ErrNothingToRedo
+	}
+	return s.redo(), nil
+}
+
+// RestoreBefore undoes or redoes turns until the checkpoint with id is the first undone one, so the
+// files are as they were before that turn
+func (s *Store) RestoreBefore(id int) ([]Result, error) {
+	return s.restore(id, 0)
+}
+
+// RestoreAfter undoes or redoes turns until the checkpoint with id is the last applied one, so the
+// files are as that turn left them
+func (s *Store) RestoreAfter(id int) ([]Result, error) {
+	return s.restore(id, 1)
+}
+
+func (s *Store) restore(id int, offset int) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := slices.IndexFunc(s.turns, func(t *turn) bool { return t.id == id })
+	if index == -1 {
+		return nil, fmt.Errorf("no checkpoint %d", id)
+	}
+
+	target := index + offset
+	var results []Result
+	for s.applied > target {
+		results = append(results, s.undo())
+	}
+	for s.applied < target {
+		results = append(results, s.redo())
+	}
+	return results, nil
+}
+
+// undo restores the last applied turn; the caller holds s.mu
+func (s *Store) undo() Result {
+	t := s.turns[s.applied-1]
+	s.applied--
+
+	// The state the turn left is kept for redo, taken now in case the path changed again after it
+	t.after = map[string]state{}
+	for _, path := range t.paths {
+		t.after[path] = capture(path)
+	}
+
+	result := Result{Checkpoint: t.checkpoint(false)}
+	for _, path := range slices.Backward(t.paths) {
+		result.apply(path, t.before[path])
+	}
+	return result
+}
+
+// redo applies the first undone turn again; the caller holds s.mu
+func (s *Store) redo() Result {
+	t := s.turns[s.applied]
+	s.applied++
+
+	result := Result{Checkpoint: t.checkpoint(true)}
+	for _, path := range t.paths {
+		result.apply(path, t.after[path])
+	}
+	return result
+}
+
+func (t *turn) checkpoint(applied bool) Checkpoint {
+	return Checkpoint{ID: t.id, Label: t.label, Time: t.time, Paths: slices.Clone(t.paths), Applied: applied}
+}
+
+// apply puts path in a recorded state and counts what it did; the first error is kept and later paths
+// are still restored
+func (r *Result) apply(path string, want state) {
+	changed, err := want.restore(path)
+	switch {
+	case err != nil && r.Err == nil:
+		r.Err = err
+	case err != nil || !changed:
+	case want.exists:
+		r.Restored++
+	default:
+		r.Removed++
+	}
+}
+
+// linkTarget returns the file a symlink points to, following links to links, also when it doesn't exist yet.
+// It reports false for a path that isn't a symlink and for a loop of links, which no tool can write through.
+func linkTarget(path string) (string, bool) {
+	for range 40 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", false
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		if info, err := os.Lstat(target); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			return target, true
+		}
+		path = target
+	}
+	return "", false
+}
+
+// capture reads the state of a path without following a symlink; paths that can't be read are treated as missing
+func capture(path string) state {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return state{}
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return state{}
+		}
+		return state{exists: true, link: target}
+	}
+	if info.IsDir() {
+		return state{exists: true, dir: true, mode: info.Mode().Perm()}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return state{}
+	}
+	return state{exists: true, mode: info.Mode().Perm(), content: content}
+}
+
+// restore makes path match the state and reports whether anything had to change. Folders are only removed
+// when they are empty, so files put there by other means are never deleted. Symlinks are replaced rather
+// than written through, so restoring never changes a file outside the recorded paths.
+func (st state) restore(path string) (bool, error) {
+	current := capture(path)
+	if current.link != "" && (!st.exists || st.link != current.link) {
+		// Whatever replaces a link must not be written to the link's target
+		if err := os.Remove(path); err != nil {
+			return false, fmt.Errorf("removing %s: %w", path, err)
+		}
+		if !st.exists {
+			return true, nil
+		}
+		current = state{}
+	}
+	switch {
+	case !st.exists && !current.exists:
+		return false, nil
+	case !st.exists:
+		if err := os.Remove(path); err != nil {
+			return false, fmt.Errorf("removing %s: %w", path, err)
+		}
+		return true, nil
+	case st.link != "":
+		if current.link == st.link {
+			return false, nil
+		}
+		if current.exists {
+			// Only a file or an empty folder is replaced by the link
+			if err := os.Remove(path); err != nil {
+				return false, fmt.Errorf("removing %s: %w", path, err)
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return false, fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.Symlink(st.link, path); err != nil {
+			return false, fmt.Errorf("restoring %s: %w", path, err)
+		}
+		return true, nil
+	case st.dir:
+		if current.exists && current.dir {
+			return false, nil
+		}
+		if err := os.MkdirAll(path, st.mode|0o700); err != nil {
+			return false, fmt.Errorf("creating %s: %w", path, err)
+		}
+		return true, nil
+	default:
+		if current.exists && !current.dir && current.mode == st.mode && string(current.content) == string(st.content) {
+			return false, nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return false, fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, st.content, st.mode); err != nil {
+			return false, fmt.Errorf("restoring %s: %w", path, err)
+		}
+		// WriteFile keeps the mode of an existing file
+		if err := os.Chmod(path, st.mode); err != nil {
+			return false, fmt.Errorf("restoring %s: %w", path, err)
+		}
+		return true, nil
+	}
+}
@@ -0,0 +1,371 @@
+package checkpoint
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFile writes a file in a test and fails the test if it can't
+func writeFile(t *testing.T, path string, content string, mode fs.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// change records path in the current turn, then writes it like a tool would
+func change(t *testing.T, s *Store, path string, content string, mode fs.FileMode) {
+	t.Helper()
+	s.Record(path)
+	writeFile(t, path, content, mode)
+}
+
+// checkFile fails the test unless path has the content and mode
+func checkFile(t *testing.T, path string, content string, mode fs.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Errorf("%s: %v, want it with %q", filepath.Base(path), err, content)
+		return
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != content || info.Mode().Perm() != mode {
+		t.Errorf("%s = %q with mode %v, want %q with mode %v", filepath.Base(path), got, info.Mode().Perm(), content, mode)
+	}
+}
+
+// checkMissing fails the test if path exists
+func checkMissing(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("%s exists, want it removed (%v)", filepath.Base(path), err)
+	}
+}
+
+func TestUndoRemovesNewFileAndFolders(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a", "b", "new.go")
+	s := New()
+
+	s.Begin("create a file")
+	change(t, s, path, "package b", 0o644)
+
+	checkpoints := s.List()
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "a", "b"), path}
+	if len(checkpoints) != 1 || !reflect.DeepEqual(checkpoints[0].Paths, want) {
+		t.Fatalf("checkpoints = %+v, want one with the folders and the file %q", checkpoints, want)
+	}
+
+	result, err := s.Undo()
+	if err != nil || result.Err != nil {
+		t.Fatalf("Undo: %v, %v", err, result.Err)
+	}
+	if result.Removed != 3 || result.Restored != 0 {
+		t.Errorf("undo removed %d and restored %d, want 3 removed", result.Removed, result.Restored)
+	}
+	checkMissing(t, filepath.Join(root, "a"))
+
+	if _, err := s.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("a second Undo returned %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestUndoKeepsFilesAddedToNewFolders(t *testing.T) {
+	root := t.TempDir()
+	s := New()
+
+	s.Begin("create a file")
+	change(t, s, filepath.Join(root, "gen", "out.go"), "x", 0o644)
+	// Something other than a file tool puts a file next to it
+	writeFile(t, filepath.Join(root, "gen", "other.go"), "y", 0o644)
+
+	result, err := s.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Err == nil {
+		t.Error("undo didn't report that the folder couldn't be removed")
+	}
+	checkMissing(t, filepath.Join(root, "gen", "out.go"))
+	checkFile(t, filepath.Join(root, "gen", "other.go"), "y", 0o644)
+}
+
+func TestUndoRedoRestoresContentAndMode(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "run.sh")
+	writeFile(t, path, "echo one", 0o600)
+	s := New()
+
+	s.Begin("edit the script")
+	change(t, s, path, "echo two", 0o755)
+	// A second change in the same turn keeps the first snapshot
+	change(t, s, path, "echo three", 0o755)
+
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, path, "echo one", 0o600)
+
+	result, err := s.Redo()
+	if err != nil || result.Err != nil {
+		t.Fatalf("Redo: %v, %v", err, result.Err)
+	}
+	if result.Restored != 1 {
+		t.Errorf("redo restored %d paths, want 1", result.Restored)
+	}
+	checkFile(t, path, "echo three", 0o755)
+
+	if _, err := s.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("a second Redo returned %v, want ErrNothingToRedo", err)
+	}
+}
+
+func TestNewChangeDropsRedo(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "main.go")
+	writeFile(t, path, "v1", 0o644)
+	s := New()
+
+	s.Begin("first")
+	change(t, s, path, "v2", 0o644)
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A turn that only begins changes nothing, so the undone turn can still be redone
+	s.Begin("only reads")
+	if got := len(s.List()); got != 1 {
+		t.Errorf("%d checkpoints after a turn without changes, want 1", got)
+	}
+
+	s.Begin("second")
+	change(t, s, path, "v3", 0o644)
+	if _, err := s.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo after a new change returned %v, want ErrNothingToRedo", err)
+	}
+	checkpoints := s.List()
+	if len(checkpoints) != 1 || checkpoints[0].Label != "second" || !checkpoints[0].Applied {
+		t.Errorf("checkpoints = %+v, want only the second turn", checkpoints)
+	}
+
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, path, "v1", 0o644)
+}
+
+func TestRestoreAcrossTurns(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.txt")
+	b := filepath.Join(root, "b.txt")
+	writeFile(t, a, "a0", 0o644)
+	s := New()
+
+	s.Begin("turn 1")
+	change(t, s, a, "a1", 0o644)
+	s.Begin("turn 2")
+	change(t, s, b, "b2", 0o644)
+	s.Begin("turn 3")
+	change(t, s, a, "a3", 0o644)
+	change(t, s, b, "b3", 0o644)
+
+	ids := []int{}
+	for _, checkpoint := range s.List() {
+		ids = append(ids, checkpoint.ID)
+	}
+	if len(ids) != 3 {
+		t.Fatalf("checkpoints %v, want 3", ids)
+	}
+
+	results, err := s.RestoreBefore(ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Errorf("restoring before turn 2 undid %d turns, want 2", len(results))
+	}
+	checkFile(t, a, "a1", 0o644)
+	checkMissing(t, b)
+
+	results, err = s.RestoreAfter(ids[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Errorf("restoring after turn 3 redid %d turns, want 2", len(results))
+	}
+	checkFile(t, a, "a3", 0o644)
+	checkFile(t, b, "b3", 0o644)
+
+	if _, err := s.RestoreBefore(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, a, "a0", 0o644)
+	checkMissing(t, b)
+	for _, checkpoint := range s.List() {
+		if checkpoint.Applied {
+			t.Errorf("checkpoint %d is applied after restoring before the first one", checkpoint.ID)
+		}
+	}
+
+	if _, err := s.RestoreAfter(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, a, "a1", 0o644)
+	checkFile(t, b, "b2", 0o644)
+
+	if _, err := s.RestoreBefore(99); err == nil {
+		t.Error("restoring an unknown checkpoint didn't fail")
+	}
+}
+
+func TestRecordSkipsExistingFolders(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "src")
+	if err := os.Mkdir(existing, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+
+	s.Begin("make a folder")
+	s.Record(existing)
+	if got := s.List(); len(got) != 0 {
+		t.Errorf("recording an existing folder made checkpoints %+v", got)
+	}
+
+	// A new file in it records only the file, so undoing it keeps the folder
+	change(t, s, filepath.Join(existing, "main.go"), "x", 0o644)
+	if paths := s.List()[0].Paths; !reflect.DeepEqual(paths, []string{filepath.Join(existing, "main.go")}) {
+		t.Errorf("recorded %q, want only the new file", paths)
+	}
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkMissing(t, filepath.Join(existing, "main.go"))
+	if info, err := os.Stat(existing); err != nil || !info.IsDir() {
+		t.Errorf("the existing folder was removed: %v", err)
+	}
+}
+
+func TestRecordWithoutTurn(t *testing.T) {
+	s := New()
+	s.Record(filepath.Join(t.TempDir(), "x"))
+	if got := s.List(); len(got) != 0 {
+		t.Errorf("recording before Begin made checkpoints %+v", got)
+	}
+}
+
+// symlink makes a symlink in a test, skipping the test where symlinks aren't available
+func symlink(t *testing.T, target string, path string) {
+	t.Helper()
+	if err := os.Symlink(target, path); err != nil {
+		t.Skip("symlinks aren't available:", err)
+	}
+}
+
+// checkLink fails the test unless path is a symlink to target
+func checkLink(t *testing.T, path string, target string) {
+	t.Helper()
+	got, err := os.Readlink(path)
+	if err != nil || got != target {
+		t.Errorf("%s links to %q (%v), want a link to %q", filepath.Base(path), got, err, target)
+	}
+}
+
+func TestUndoWriteThroughSymlink(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "shared.txt")
+	writeFile(t, outside, "original", 0o644)
+	link := filepath.Join(root, "shared.txt")
+	symlink(t, outside, link)
+	s := New()
+
+	// The tools write through the link, into the file it points to
+	s.Begin("edit through the link")
+	change(t, s, link, "changed", 0o644)
+	if paths := s.List()[0].Paths; !reflect.DeepEqual(paths, []string{link, outside}) {
+		t.Errorf("recorded %q, want the link and its target", paths)
+	}
+
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkLink(t, link, outside)
+	checkFile(t, outside, "original", 0o644)
+
+	if _, err := s.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	checkLink(t, link, outside)
+	checkFile(t, outside, "changed", 0o644)
+}
+
+func TestUndoKeepsSymlinkTargets(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	writeFile(t, outside, "secret", 0o600)
+	path := filepath.Join(root, "config.txt")
+	s := New()
+
+	// A file the turn replaced with a link comes back as a file, without writing to the link's target
+	writeFile(t, path, "config", 0o644)
+	s.Begin("replace the file with a link")
+	s.Record(path)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	symlink(t, outside, path)
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, path, "config", 0o644)
+	checkFile(t, outside, "secret", 0o600)
+
+	// Redo puts the link back rather than writing the target's content to a file
+	if _, err := s.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	checkLink(t, path, outside)
+	checkFile(t, outside, "secret", 0o600)
+
+	// A link the turn replaced with a file comes back as the link
+	s.Begin("replace the link with a file")
+	s.Record(path)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "plain", 0o644)
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkLink(t, path, outside)
+	checkFile(t, outside, "secret", 0o600)
+}
+
+func TestUndoNewFileThroughDanglingSymlink(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "out.txt")
+	link := filepath.Join(root, "latest.txt")
+	symlink(t, "out.txt", link)
+	s := New()
+
+	// Writing through a link to a missing file creates the file, which undo removes again
+	s.Begin("write through a dangling link")
+	change(t, s, link, "x", 0o644)
+	checkFile(t, target, "x", 0o644)
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	checkLink(t, link, "out.txt")
+	checkMissing(t, target)
+}
@@ -2,23 +2,72 @@ package config
 
 import (
 	"fmt"
-	"os"
-
-	"github.com/joho/godotenv"
+	"strings"
 )
 
-func Config(envVar string) string {
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading .env file: %v\n", err)
-		panic(err)
+// APIKey returns the API key of a provider, or an error that tells where to set it
+func APIKey(providerID string) (string, error) {
+	if key := Current().Providers[providerID].APIKey; key != "" {
+		return key, nil
 	}
+	return "", fmt.Errorf("no API key for %s: paste one in the TUI, set %s or add api_key under [providers.%s] in %s",
+		ProviderName(providerID), providerEnv[providerID].apiKey, providerID, globalConfigHint())
+}
+
+// BaseURL returns the API endpoint of a provider, for Ollama the server address
+func BaseURL(providerID string) string {
+	return strings.TrimSuffix(Current().Providers[providerID].BaseURL, "/")
+}
+
+// ToolPolicies returns the per-tool permission overrides, e.g. write_content=allow
+func ToolPolicies() map[string]string {
+	return Current().Permissions.Tools
+}
+
+// AllowedCommands returns the command prefixes run_command may run without asking, on top of the built-in ones
+func AllowedCommands() []string {
+	return Current().Permissions.AllowedCommands
+}
+
+// OutsideRootPolicy returns the policy for paths outside the workspace, empty when it is not set
+func OutsideRootPolicy() string {
+	return Current().Permissions.OutsideRoot
+}
 
-	envVarValue := os.Getenv(envVar)
-	if envVarValue == "" {
-		fmt.Fprintf(os.Stderr, "%s not set\n", envVar)
-		os.Exit(1)
+// WorkspaceRoot returns the configured workspace root, empty for the launch directory
+func WorkspaceRoot() string {
+	return Current().Workspace.Root
+}
+
+// ExtraRoots returns the directories outside the workspace root that the file tools may use
+func ExtraRoots() []string {
+	return Current().Workspace.ExtraRoots
+}
+
+// MaxIterations returns the limit of model calls per message, 0 when it is not set
+func MaxIterations() int {
+	return Current().Agent.MaxIterations
+}
+
+// SerperAPIKey returns the API key of the web search, empty when it is not set
+func SerperAPIKey() string {
+	return Current().Tools.SerperAPIKey
+}
+
+// ProviderName returns the display name of a provider, e.g. OpenRouter for openrouter
+func ProviderName(providerID string) string {
+	for _, provider := range GetAllProviders() {
+		if provider.ID == providerID {
+			return provider.Name
+		}
 	}
+	return providerID
+}
 
-	return envVarValue
+// globalConfigHint names the global config file for error messages
+func globalConfigHint() string {
+	if path, err := GlobalConfigPath(); err == nil {
+		return path
+	}
+	return "~/.config/nyron/config.toml"
 }
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// CredentialsPath returns credentials.json in ConfigDir. It holds the API keys saved from the TUI, readable only
+// by the user. A project's .env can't move it, so pasted keys are never saved into the project or read from it.
+func CredentialsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.json"), nil
+}
+
+// RequiresAPIKey reports whether a provider needs an API key; Ollama runs locally without one
+func RequiresAPIKey(providerID string) bool {
+	return providerEnv[providerID].apiKey != ""
+}
+
+// readCredentials returns the saved API keys by provider ID, none when the file doesn't exist
+func readCredentials(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+	// Windows has no permission bits to check
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("%s can be read by other users, restrict it with: chmod 600 %s", path, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+	credentials := map[string]string{}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return credentials, nil
+}
+
+// applyCredentials sets the API keys saved in the credentials file
+func (s *Settings) applyCredentials() error {
+	path, err := CredentialsPath()
+	if err != nil {
+		return nil
+	}
+	credentials, err := readCredentials(path)
+	if err != nil {
+		return err
+	}
+	for id, key := range credentials {
+		if _, ok := s.Providers[id]; !ok {
+			return fmt.Errorf("%s: unknown provider %q", path, id)
+		}
+		provider := s.Providers[id]
+		provider.APIKey = key
+		s.Providers[id] = provider
+	}
+	return nil
+}
+
+// SaveAPIKey stores the API key of a provider in the credentials file, which only the user may read,
+// and uses it from now on. It returns the path of the file.
+func SaveAPIKey(providerID string, apiKey string) (string, error) {
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", fmt.Errorf("finding the config directory: %w", err)
+	}
+	credentials, err := readCredentials(path)
+	if err != nil {
+		return "", err
+	}
+	credentials[providerID] = apiKey
+	data, err := json.MarshalIndent(credentials, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("saving credentials: %w", err)
+	}
+	// Written to a temporary file first, which CreateTemp makes with mode 0600, so a failed write keeps the old keys
+	file, err := os.CreateTemp(dir, ".credentials-*")
+	if err != nil {
+		return "", fmt.Errorf("saving credentials: %w", err)
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return "", fmt.Errorf("saving credentials: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("saving credentials: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", fmt.Errorf("saving credentials: %w", err)
+	}
+
+	// The settings are replaced rather than changed, since other goroutines may be reading them
+	updated := *Current()
+	updated.Providers = maps.Clone(updated.Providers)
+	provider := updated.Providers[providerID]
+	provider.APIKey = apiKey
+	updated.Providers[providerID] = provider
+
+	currentMu.Lock()
+	current = &updated
+	currentMu.Unlock()
+	return path, nil
+}
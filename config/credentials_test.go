@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCredentialsStayInUserConfigDir(t *testing.T) {
+	project := t.TempDir()
+	t.Chdir(project)
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("OPENAI_API_KEY", "")
+	os.Unsetenv("OPENAI_API_KEY")
+
+	// The project tries to move the config directory into itself and brings its own credentials there
+	if err := os.WriteFile(".env", []byte("XDG_CONFIG_HOME=./x\nHOME=./x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join("x", "nyron"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("x", "nyron", "credentials.json"), []byte(`{"openai":"sk-project"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := current
+	t.Cleanup(func() { current = previous })
+	settings, err := load(Overrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current = settings
+	if got := settings.Providers["openai"].APIKey; got != "" {
+		t.Errorf("OpenAI key = %q, want none from the project's credentials", got)
+	}
+
+	want := filepath.Join(configHome, "nyron", "credentials.json")
+	if path, err := CredentialsPath(); err != nil || path != want {
+		t.Errorf("CredentialsPath = %q, %v, want %q", path, err, want)
+	}
+	path, err := SaveAPIKey("anthropic", "sk-ant-user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != want {
+		t.Errorf("the key was saved to %s, want %s", path, want)
+	}
+	info, err := os.Stat(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("credentials mode = %v, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(filepath.Join("x", "nyron", "credentials.json")); string(data) != `{"openai":"sk-project"}` {
+		t.Errorf("the project's credentials file was changed to %s", data)
+	}
+	if got := Current().Providers["anthropic"].APIKey; got != "sk-ant-user" {
+		t.Errorf("Anthropic key = %q after saving, want it in use", got)
+	}
+
+	credentials, err := readCredentials(want)
+	if err != nil || credentials["anthropic"] != "sk-ant-user" || len(credentials) != 1 {
+		t.Errorf("saved credentials = %v, %v", credentials, err)
+	}
+}
+
+func TestReadCredentialsRefusesReadableFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows has no permission bits to check")
+	}
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, []byte(`{"openai":"sk-x"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCredentials(path); err == nil {
+		t.Error("a credentials file other users can read was accepted")
+	}
+}
@@ -1,5 +1,7 @@
 package config
 
+import "sync"
+
 type SelectedModel struct {
 	// The model id as used by the provider API.
 	// Required.
@@ -15,9 +17,19 @@ type Provider struct {
 }
 
 type Model struct {
-	ID          string
-	Name        string
-	Description string
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	ContextWindow int      `json:"context_window,omitempty"` // Tokens the model accepts per request, history and answer together
+	Pricing       *Pricing `json:"pricing,omitempty"`        // Nil when the price isn't known
+	Tools         bool     `json:"tools"`                    // The model can call tools
+	Reasoning     bool     `json:"reasoning"`                // The model can think before it answers
+}
+
+// Pricing is what a model costs in US dollars per million tokens
+type Pricing struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
 }
 
 // Available providers
@@ -26,71 +38,291 @@ var (
 		ID:   "openrouter",
 		Name: "OpenRouter",
 	}
+	ProviderOpenAI = Provider{
+		ID:   "openai",
+		Name: "OpenAI",
+	}
+	ProviderAnthropic = Provider{
+		ID:   "anthropic",
+		Name: "Anthropic",
+	}
+	ProviderGemini = Provider{
+		ID:   "gemini",
+		Name: "Google Gemini",
+	}
+	ProviderOllama = Provider{
+		ID:   "ollama",
+		Name: "Ollama (local)",
+	}
 )
 
-// Available models by provider
+// DefaultModel is used until the user picks another model
+var DefaultModel = SelectedModel{
+	Provider: ProviderOpenRouter.ID,
+	Model:    "google/gemini-2.5-flash",
+}
+
+// Built-in models by provider, used until the catalog of the provider is loaded and whenever it can't be
 var (
 	OpenRouterModels = []Model{
 		{
-			ID:          "openai/gpt-5",
-			Name:        "GPT 5",
-			Description: "GPT-5 is OpenAI’s most advanced model, offering major improvements in reasoning, code quality, and user experience.",
+			ID:            "openai/gpt-5",
+			Name:          "GPT 5",
+			Description:   "GPT-5 is OpenAI’s most advanced model, offering major improvements in reasoning, code quality, and user experience.",
+			ContextWindow: 400_000,
+			Tools:         true,
+			Reasoning:     true,
 		},
 		{
-			ID:          "openai/gpt-5-mini",
-			Name:        "GPT 5 Mini",
-			Description: "GPT-5 Mini is a compact version of GPT-5, designed to handle lighter-weight reasoning tasks.",
+			ID:            "openai/gpt-5-mini",
+			Name:          "GPT 5 Mini",
+			Description:   "GPT-5 Mini is a compact version of GPT-5, designed to handle lighter-weight reasoning tasks.",
+			ContextWindow: 400_000,
+			Tools:         true,
+			Reasoning:     true,
 		},
 		{
-			ID:          "openai/gpt-4.1",
-			Name:        "GPT 4.1",
-			Description: "GPT-4.1 is a flagship large language model optimized for advanced instruction following, real-world software engineering, and long-context reasoning.",
+			ID:            "openai/gpt-4.1",
+			Name:          "GPT 4.1",
+			Description:   "GPT-4.1 is a flagship large language model optimized for advanced instruction following, real-world software engineering, and long-context reasoning.",
+			ContextWindow: 1_047_576,
+			Tools:         true,
 		},
 		{
-			ID:          "google/gemini-2.5-pro",
-			Name:        "Gemini 2.5 Pro",
-			Description: "Gemini 2.5 Pro is Google’s state-of-the-art AI model designed for advanced reasoning, coding, mathematics, and scientific tasks.",
+			ID:            "google/gemini-2.5-pro",
+			Name:          "Gemini 2.5 Pro",
+			Description:   "Gemini 2.5 Pro is Google’s state-of-the-art AI model designed for advanced reasoning, coding, mathematics, and scientific tasks.",
+			ContextWindow: 1_048_576,
+			Tools:         true,
+			Reasoning:     true,
 		},
 		{
-			ID:          "google/gemini-2.5-flash",
-			Name:        "Gemini 2.5 Flash",
-			Description: "Gemini 2.5 Flash is Google’s state-of-the-art AI model designed for advanced reasoning, coding, mathematics, and scientific tasks.",
+			ID:            "google/gemini-2.5-flash",
+			Name:          "Gemini 2.5 Flash",
+			Description:   "Gemini 2.5 Flash is Google’s state-of-the-art AI model designed for advanced reasoning, coding, mathematics, and scientific tasks.",
+			ContextWindow: 1_048_576,
+			Tools:         true,
+			Reasoning:     true,
 		},
 		{
-			ID:          "x-ai/grok-4-fast:free",
-			Name:        "Grok-4 Fast",
-			Description: "xAI's Grok-4 model optimized for speed",
+			ID:            "x-ai/grok-4-fast:free",
+			Name:          "Grok-4 Fast",
+			Description:   "xAI's Grok-4 model optimized for speed",
+			ContextWindow: 2_000_000,
+			Tools:         true,
+			Reasoning:     true,
 		},
 		{
-			ID:          "deepseek/deepseek-chat-v3.1:free",
-			Name:        "Deepseek V3",
-			Description: "Deepseek v3 is a large hybrid model",
+			ID:            "deepseek/deepseek-chat-v3.1:free",
+			Name:          "Deepseek V3",
+			Description:   "Deepseek v3 is a large hybrid model",
+			ContextWindow: 163_840,
+			Tools:         true,
+			Reasoning:     true,
 		},
 		{
-			ID:          "z-ai/glm-4.5-air:free",
-			Name:        "GLM 4.5 Air",
-			Description: "GLM-4.5-Air is the lightweight variant of GLM 4.5",
+			ID:            "z-ai/glm-4.5-air:free",
+			Name:          "GLM 4.5 Air",
+			Description:   "GLM-4.5-Air is the lightweight variant of GLM 4.5",
+			ContextWindow: 131_072,
+			Tools:         true,
+			Reasoning:     true,
 		},
 		{
-			ID:          "moonshotai/kimi-k2:free",
-			Name:        "Kimi K2",
-			Description: "Kimi K2 Instruct is a large-scale Mixture-of-Experts (MoE) language model",
+			ID:            "moonshotai/kimi-k2:free",
+			Name:          "Kimi K2",
+			Description:   "Kimi K2 Instruct is a large-scale Mixture-of-Experts (MoE) language model",
+			ContextWindow: 32_768,
+			Tools:         true,
+		},
+	}
+
+	OpenAIModels = []Model{
+		{
+			ID:            "gpt-5",
+			Name:          "GPT 5",
+			Description:   "GPT-5 is OpenAI’s most advanced model, offering major improvements in reasoning, code quality, and user experience.",
+			ContextWindow: 400_000,
+			Pricing:       &Pricing{Prompt: 1.25, Completion: 10},
+			Tools:         true,
+			Reasoning:     true,
+		},
+		{
+			ID:            "gpt-5-mini",
+			Name:          "GPT 5 Mini",
+			Description:   "GPT-5 Mini is a compact version of GPT-5, designed to handle lighter-weight reasoning tasks.",
+			ContextWindow: 400_000,
+			Pricing:       &Pricing{Prompt: 0.25, Completion: 2},
+			Tools:         true,
+			Reasoning:     true,
+		},
+		{
+			ID:            "gpt-4.1",
+			Name:          "GPT 4.1",
+			Description:   "GPT-4.1 is a flagship large language model optimized for advanced instruction following, real-world software engineering, and long-context reasoning.",
+			ContextWindow: 1_047_576,
+			Pricing:       &Pricing{Prompt: 2, Completion: 8},
+			Tools:         true,
+		},
+	}
+
+	AnthropicModels = []Model{
+		{
+			ID:            "claude-sonnet-4-5",
+			Name:          "Claude Sonnet 4.5",
+			Description:   "Anthropic's balanced model for coding and agentic tasks.",
+			ContextWindow: 200_000,
+			Pricing:       &Pricing{Prompt: 3, Completion: 15},
+			Tools:         true,
+			Reasoning:     true,
+		},
+		{
+			ID:            "claude-opus-4-1",
+			Name:          "Claude Opus 4.1",
+			Description:   "Anthropic's most capable model for complex, long-running tasks.",
+			ContextWindow: 200_000,
+			Pricing:       &Pricing{Prompt: 15, Completion: 75},
+			Tools:         true,
+			Reasoning:     true,
+		},
+		{
+			ID:            "claude-3-5-haiku-latest",
+			Name:          "Claude Haiku 3.5",
+			Description:   "Anthropic's fastest model for lightweight tasks.",
+			ContextWindow: 200_000,
+			Pricing:       &Pricing{Prompt: 0.8, Completion: 4},
+			Tools:         true,
+		},
+	}
+
+	GeminiModels = []Model{
+		{
+			ID:            "gemini-2.5-pro",
+			Name:          "Gemini 2.5 Pro",
+			Description:   "Gemini 2.5 Pro is Google’s state-of-the-art AI model designed for advanced reasoning, coding, mathematics, and scientific tasks.",
+			ContextWindow: 1_048_576,
+			Pricing:       &Pricing{Prompt: 1.25, Completion: 10},
+			Tools:         true,
+			Reasoning:     true,
+		},
+		{
+			ID:            "gemini-2.5-flash",
+			Name:          "Gemini 2.5 Flash",
+			Description:   "Gemini 2.5 Flash is Google’s fast, cost-efficient model with built-in thinking.",
+			ContextWindow: 1_048_576,
+			Pricing:       &Pricing{Prompt: 0.3, Completion: 2.5},
+			Tools:         true,
+			Reasoning:     true,
+		},
+	}
+
+	OllamaModels = []Model{
+		{
+			ID:            "qwen2.5-coder",
+			Name:          "Qwen 2.5 Coder",
+			Description:   "Code-specific Qwen model running locally through Ollama",
+			ContextWindow: 32_768,
+			Pricing:       &Pricing{},
+			Tools:         true,
+		},
+		{
+			ID:            "llama3.1",
+			Name:          "Llama 3.1",
+			Description:   "Meta's Llama 3.1 running locally through Ollama",
+			ContextWindow: 131_072,
+			Pricing:       &Pricing{},
+			Tools:         true,
+		},
+		{
+			ID:            "gpt-oss:20b",
+			Name:          "GPT OSS 20B",
+			Description:   "OpenAI's open-weight reasoning model running locally through Ollama",
+			ContextWindow: 131_072,
+			Pricing:       &Pricing{},
+			Tools:         true,
+			Reasoning:     true,
 		},
 	}
 )
 
+// DefaultContextWindow is assumed for models that aren't in the lists above
+const DefaultContextWindow = 128_000
+
+// ContextWindow returns the context window of a model in tokens. The context_window setting overrides it,
+// e.g. for an Ollama server started with a smaller num_ctx.
+func ContextWindow(selected SelectedModel) int {
+	if limit := Current().Agent.ContextWindow; limit > 0 {
+		return limit
+	}
+	if model, ok := FindModel(selected); ok && model.ContextWindow > 0 {
+		return model.ContextWindow
+	}
+	return DefaultContextWindow
+}
+
+// SupportsTools reports whether a model can call tools; models that aren't in the catalog are assumed to
+func SupportsTools(selected SelectedModel) bool {
+	model, ok := FindModel(selected)
+	return !ok || model.Tools
+}
+
+// FindModel looks a model up in the catalog of its provider
+func FindModel(selected SelectedModel) (Model, bool) {
+	for _, model := range GetModelsByProvider(selected.Provider) {
+		if model.ID == selected.Model {
+			return model, true
+		}
+	}
+	return Model{}, false
+}
+
 // GetAllProviders returns all available providers
 func GetAllProviders() []Provider {
 	return []Provider{
 		ProviderOpenRouter,
+		ProviderOpenAI,
+		ProviderAnthropic,
+		ProviderGemini,
+		ProviderOllama,
 	}
 }
 
-// GetModelsByProvider returns models for a specific provider
+var (
+	catalogsMu sync.RWMutex
+	catalogs   = map[string][]Model{}
+)
+
+// SetModels replaces the built-in models of a provider with its loaded catalog
+func SetModels(providerID string, models []Model) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	catalogs[providerID] = models
+}
+
+// GetModelsByProvider returns models for a specific provider: its loaded catalog, or the built-in models
 func GetModelsByProvider(providerID string) []Model {
+	catalogsMu.RLock()
+	models, ok := catalogs[providerID]
+	catalogsMu.RUnlock()
+	if ok {
+		return models
+	}
+	return BuiltinModels(providerID)
+}
+
+// BuiltinModels returns the models of a provider that ship with the program
+func BuiltinModels(providerID string) []Model {
 	switch providerID {
 	case ProviderOpenRouter.ID:
 		return OpenRouterModels
+	case ProviderOpenAI.ID:
+		return OpenAIModels
+	case ProviderAnthropic.ID:
+		return AnthropicModels
+	case ProviderGemini.ID:
+		return GeminiModels
+	case ProviderOllama.ID:
+		return OllamaModels
 	default:
 		return []Model{}
 	}
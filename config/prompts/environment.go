@@ -0,0 +1,257 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// gitTimeout bounds each git command run to describe the repository
+	gitTimeout = 2 * time.Second
+	// maxStatusLines caps the changed files listed from git status
+	maxStatusLines = 20
+	// maxTreeChildren caps the entries listed under each top-level directory of the tree
+	maxTreeChildren = 15
+	// maxTreeEntries caps the top-level entries of the tree
+	maxTreeEntries = 60
+)
+
+// skippedDirs are left out of the tree outside git repositories, where .gitignore can't tell
+var skippedDirs = map[string]bool{
+	"node_modules": true, "vendor": true, "dist": true, "build": true, "target": true,
+	"__pycache__": true, "venv": true,
+}
+
+// toolchainFiles map files in the working directory to the language or tool they point to
+var toolchainFiles = []struct {
+	file string
+	name string
+}{
+	{"go.mod", "Go"},
+	{"package.json", "Node.js"},
+	{"bun.lockb", "Bun"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "Yarn"},
+	{"tsconfig.json", "TypeScript"},
+	{"Cargo.toml", "Rust (Cargo)"},
+	{"pyproject.toml", "Python (pyproject)"},
+	{"requirements.txt", "Python (pip)"},
+	{"pom.xml", "Java (Maven)"},
+	{"build.gradle", "Java/Kotlin (Gradle)"},
+	{"build.gradle.kts", "Kotlin (Gradle)"},
+	{"Gemfile", "Ruby (Bundler)"},
+	{"composer.json", "PHP (Composer)"},
+	{"mix.exs", "Elixir (Mix)"},
+	{"CMakeLists.txt", "C/C++ (CMake)"},
+	{"Makefile", "Make"},
+	{"Dockerfile", "Docker"},
+	{"docker-compose.yml", "Docker Compose"},
+}
+
+// Environment describes the machine and the project, filled in when a conversation starts
+type Environment struct {
+	WorkingDirectory string
+	Platform         string // e.g. linux/amd64
+	Shell            string
+	Date             string
+	GitBranch        string // Empty outside a git repository
+	GitStatus        string // Changed files as git status --short lists them, empty when clean
+	Tree             string // Two levels of the directory tree
+	Toolchains       []string
+}
+
+// CollectEnvironment describes dir and the machine. Anything that can't be found out is left empty.
+func CollectEnvironment(dir string) Environment {
+	env := Environment{
+		WorkingDirectory: dir,
+		Platform:         runtime.GOOS + "/" + runtime.GOARCH,
+		Shell:            os.Getenv("SHELL"),
+		Date:             time.Now().Format("Monday, January 2, 2006"),
+		Toolchains:       detectToolchains(dir),
+	}
+
+	var files []string
+	if status, ok := git(dir, "status", "--short", "--branch"); ok {
+		env.GitBranch, env.GitStatus = parseGitStatus(status)
+		if list, ok := git(dir, "ls-files", "--cached", "--others", "--exclude-standard"); ok {
+			files = strings.Split(strings.TrimSpace(list), "\n")
+		}
+	}
+	if files != nil {
+		env.Tree = renderTree(treeFromPaths(files))
+	} else {
+		env.Tree = renderTree(treeFromDir(dir))
+	}
+	return env
+}
+
+// git runs a git command in dir and returns its output; ok is false when git fails or dir isn't a repository
+func git(dir string, args ...string) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	command := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	command.Stdout = &stdout
+	if err := command.Run(); err != nil {
+		return "", false
+	}
+	return stdout.String(), true
+}
+
+// parseGitStatus splits the output of git status --short --branch into the branch line and the changed files
+func parseGitStatus(output string) (string, string) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	branch := strings.TrimPrefix(lines[0], "## ")
+	changes := lines[1:]
+	if len(changes) > maxStatusLines {
+		more := len(changes) - maxStatusLines
+		changes = append(changes[:maxStatusLines:maxStatusLines], fmt.Sprintf("... and %d more", more))
+	}
+	return branch, strings.Join(changes, "\n")
+}
+
+func detectToolchains(dir string) []string {
+	var toolchains []string
+	for _, candidate := range toolchainFiles {
+		if _, err := os.Stat(filepath.Join(dir, candidate.file)); err != nil {
+			continue
+		}
+		name := candidate.name
+		if candidate.file == "go.mod" {
+			name += goVersion(filepath.Join(dir, candidate.file))
+		}
+		toolchains = append(toolchains, name)
+	}
+	return toolchains
+}
+
+// goVersion returns the module and Go version of a go.mod, e.g. " (module example.com/app, go 1.25)"
+func goVersion(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var details []string
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && (fields[0] == "module" || fields[0] == "go") {
+			details = append(details, fields[0]+" "+fields[1])
+		}
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+// tree maps the top-level entries of a directory to the entries inside them; directories end in a slash
+type tree map[string]map[string]bool
+
+func (t tree) add(top string, child string) {
+	if t[top] == nil {
+		t[top] = map[string]bool{}
+	}
+	if child != "" {
+		t[top][child] = true
+	}
+}
+
+// treeFromPaths builds the tree from file paths relative to the root, as git ls-files lists them
+func treeFromPaths(paths []string) tree {
+	t := tree{}
+	for _, path := range paths {
+		parts := strings.Split(path, "/")
+		switch {
+		case path == "":
+		case len(parts) == 1:
+			t.add(parts[0], "")
+		case len(parts) == 2:
+			t.add(parts[0]+"/", parts[1])
+		default:
+			t.add(parts[0]+"/", parts[1]+"/")
+		}
+	}
+	return t
+}
+
+// treeFromDir builds the tree by listing dir, skipping hidden entries and dependency or build output directories
+func treeFromDir(dir string) tree {
+	t := tree{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return t
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || (entry.IsDir() && skippedDirs[name]) {
+			continue
+		}
+		if !entry.IsDir() {
+			t.add(name, "")
+			continue
+		}
+
+		t.add(name+"/", "")
+		children, err := os.ReadDir(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, child := range children {
+			childName := child.Name()
+			if strings.HasPrefix(childName, ".") {
+				continue
+			}
+			if child.IsDir() {
+				childName += "/"
+			}
+			t.add(name+"/", childName)
+		}
+	}
+	return t
+}
+
+// renderTree lists directories before files, both sorted, and cuts long listings
+func renderTree(t tree) string {
+	var lines []string
+	for i, top := range sortEntries(t) {
+		if i == maxTreeEntries {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(t)-maxTreeEntries))
+			break
+		}
+		lines = append(lines, top)
+
+		children := sortEntries(t[top])
+		for j, child := range children {
+			if j == maxTreeChildren {
+				lines = append(lines, fmt.Sprintf("  ... and %d more", len(children)-maxTreeChildren))
+				break
+			}
+			lines = append(lines, "  "+child)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sortEntries[V any](entries map[string]V) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iDir, jDir := strings.HasSuffix(names[i], "/"), strings.HasSuffix(names[j], "/")
+		if iDir != jDir {
+			return iDir
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	_ "embed"
+	"strings"
 	"text/template"
 )
 
@@ -11,12 +12,20 @@ import (
 //go:embed openrouter.md
 var openrouterSysPrompt string
 
+//go:embed compact.md
+var compactSysPrompt string
+
+// systemPromptTemplate is the system prompt, filled in with the Environment when a conversation starts
+var systemPromptTemplate = template.Must(template.New("systemPrompt").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(openrouterSysPrompt))
+
 type PromptPair struct {
 	SystemPrompt string
 	UserPrompt   string
 }
 
-func GetPrompts(userPrompt string, provider string) PromptPair {
+func GetPrompts(userPrompt string, provider string, env Environment) PromptPair {
 	// Template for user prompt
 	userPromptTemplate := `{{.UserPrompt}}`
 
@@ -30,8 +39,10 @@ func GetPrompts(userPrompt string, provider string) PromptPair {
 	var userPromptBuffer bytes.Buffer
 	data := struct {
 		UserPrompt string
+		Env        Environment
 	}{
 		UserPrompt: userPrompt,
+		Env:        env,
 	}
 
 	err = tmpl.Execute(&userPromptBuffer, data)
@@ -44,8 +55,12 @@ func GetPrompts(userPrompt string, provider string) PromptPair {
 	// Return separate system and user prompts based on provider
 	switch provider {
 	case "openrouter":
+		var systemPromptBuffer bytes.Buffer
+		if err := systemPromptTemplate.Execute(&systemPromptBuffer, data); err != nil {
+			panic(err)
+		}
 		return PromptPair{
-			SystemPrompt: openrouterSysPrompt,
+			SystemPrompt: systemPromptBuffer.String(),
 			UserPrompt:   formattedUserPrompt,
 		}
 	default:
@@ -55,3 +70,8 @@ func GetPrompts(userPrompt string, provider string) PromptPair {
 		}
 	}
 }
+
+// GetCompactPrompt returns the system prompt for summarizing the earlier part of a conversation
+func GetCompactPrompt() string {
+	return compactSysPrompt
+}
@@ -0,0 +1,658 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// Settings is the configuration, merged from the built-in defaults, the saved credentials, the global config
+// file, the project config file, the environment and the command line, each overriding the ones before it
+type Settings struct {
+	Model       SelectedModel
+	Providers   map[string]ProviderSettings // By provider ID
+	Permissions PermissionSettings
+	Workspace   WorkspaceSettings
+	Agent       AgentSettings
+	Tools       ToolSettings
+	MCP         map[string]MCPServer // MCP servers whose tools the model can use, by name
+	Theme       Theme
+	Keys        map[string][]string // Key bindings by action, see KeyActions
+	Files       []string            // The config files that were read, in the order they were applied
+	Warnings    []string            // Problems that didn't stop the load, e.g. variables refused from .env
+}
+
+type ProviderSettings struct {
+	APIKey  string
+	BaseURL string // The API endpoint, for Ollama the server address
+}
+
+type PermissionSettings struct {
+	Tools           map[string]string // Policy per tool: allow, ask or deny
+	AllowedCommands []string          // Command prefixes run_command may run without asking, on top of the built-in ones
+	OutsideRoot     string            // Policy for paths outside the workspace, empty to ask
+}
+
+type WorkspaceSettings struct {
+	Root       string // Empty for the launch directory
+	ExtraRoots []string
+}
+
+type AgentSettings struct {
+	MaxIterations int // Model calls per message, 0 for the default
+	ContextWindow int // Overrides the context window of every model, 0 to use the known limits
+}
+
+type ToolSettings struct {
+	SerperAPIKey string // For web_search
+}
+
+// MCPServer is an MCP server, either a command that speaks MCP over stdin and stdout or a URL
+type MCPServer struct {
+	Command   string
+	Args      []string
+	Env       map[string]string // Added to the environment of the command; values may use $VARIABLES
+	URL       string
+	Transport string            // stdio, http or sse; empty for stdio with a command and http with a URL
+	Headers   map[string]string // Sent with every HTTP request; values may use $VARIABLES
+	Disabled  bool
+}
+
+// MCPTransports are the ways to reach an MCP server
+var MCPTransports = []string{"stdio", "http", "sse"}
+
+// TransportName returns the transport to reach the server with, filling in the default
+func (server MCPServer) TransportName() string {
+	switch {
+	case server.Transport != "":
+		return server.Transport
+	case server.URL != "":
+		return "http"
+	default:
+		return "stdio"
+	}
+}
+
+// Theme overrides colors of the chat, as "#rrggbb" or an ANSI color number; empty fields keep the default
+type Theme struct {
+	Primary   string `json:"primary"`
+	Secondary string `json:"secondary"`
+	Accent    string `json:"accent"`
+	Success   string `json:"success"`
+	Error     string `json:"error"`
+	Muted     string `json:"muted"`
+	Border    string `json:"border"`
+	Header    string `json:"header"`
+}
+
+// Overrides are the settings given on the command line
+type Overrides struct {
+	Provider      string
+	Model         string
+	MaxIterations int
+}
+
+// KeyActions are the actions of the chat that can be bound to other keys in the [keys] table
+var KeyActions = []string{"switch_focus", "scroll_up", "scroll_down", "page_up", "page_down", "send", "quit", "cancel", "models", "sessions"}
+
+// providerEnv are the environment variables of each provider's API key and endpoint
+var providerEnv = map[string]struct{ apiKey, baseURL string }{
+	ProviderOpenRouter.ID: {apiKey: "OPENROUTER_API_KEY"},
+	ProviderOpenAI.ID:     {apiKey: "OPENAI_API_KEY", baseURL: "OPENAI_BASE_URL"},
+	ProviderAnthropic.ID:  {apiKey: "ANTHROPIC_API_KEY", baseURL: "ANTHROPIC_BASE_URL"},
+	ProviderGemini.ID:     {apiKey: "GEMINI_API_KEY", baseURL: "GEMINI_BASE_URL"},
+	ProviderOllama.ID:     {baseURL: "OLLAMA_HOST"},
+}
+
+var (
+	currentMu sync.Mutex
+	current   *Settings
+)
+
+// Defaults returns the built-in settings
+func Defaults() *Settings {
+	return &Settings{
+		Model: DefaultModel,
+		Providers: map[string]ProviderSettings{
+			ProviderOpenRouter.ID: {},
+			ProviderOpenAI.ID:     {BaseURL: "https://api.openai.com/v1"},
+			ProviderAnthropic.ID:  {BaseURL: "https://api.anthropic.com/v1"},
+			ProviderGemini.ID:     {BaseURL: "https://generativelanguage.googleapis.com/v1beta"},
+			ProviderOllama.ID:     {BaseURL: "http://localhost:11434"},
+		},
+		Permissions: PermissionSettings{Tools: map[string]string{}},
+		MCP:         map[string]MCPServer{},
+		Keys:        map[string][]string{},
+	}
+}
+
+// ConfigDir returns $XDG_CONFIG_HOME/nyron, or ~/.config/nyron when XDG_CONFIG_HOME is not set. Both variables
+// are in trustedEnv, so this is the user's directory as the environment had it before .env was read, never
+// one inside the project.
+func ConfigDir() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "nyron"), nil
+}
+
+// GlobalConfigPath returns config.toml in ConfigDir
+func GlobalConfigPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// ProjectConfigPath returns .nyron/config.toml in the working directory
+func ProjectConfigPath() string {
+	return filepath.Join(".nyron", "config.toml")
+}
+
+// Load reads every configuration layer and checks the result. It is called once at startup;
+// Current returns what it loaded.
+func Load(overrides Overrides) (*Settings, error) {
+	settings, err := load(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	currentMu.Lock()
+	current = settings
+	currentMu.Unlock()
+	return settings, nil
+}
+
+// Current returns the settings from Load. Before Load they are loaded without command line overrides,
+// and settings that don't pass the checks are used as they are.
+func Current() *Settings {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	if current == nil {
+		current, _ = load(Overrides{})
+		if current == nil {
+			current = Defaults()
+		}
+	}
+	return current
+}
+
+func load(overrides Overrides) (*Settings, error) {
+	settings := Defaults()
+
+	settings.Warnings = append(settings.Warnings, loadDotEnv()...)
+	if err := settings.applyCredentials(); err != nil {
+		return nil, err
+	}
+
+	paths := []string{ProjectConfigPath()}
+	if global, err := GlobalConfigPath(); err == nil {
+		paths = []string{global, ProjectConfigPath()}
+	}
+	for _, path := range paths {
+		applied, err := settings.applyFile(path, path == ProjectConfigPath())
+		if err != nil {
+			return nil, err
+		}
+		if applied {
+			settings.Files = append(settings.Files, path)
+		}
+	}
+
+	var errs []error
+	errs = append(errs, settings.applyEnv()...)
+	if overrides.Provider != "" {
+		settings.Model.Provider = overrides.Provider
+	}
+	if overrides.Model != "" {
+		settings.Model.Model = overrides.Model
+	}
+	if overrides.MaxIterations != 0 {
+		settings.Agent.MaxIterations = overrides.MaxIterations
+	}
+
+	errs = append(errs, settings.validate()...)
+	if len(errs) > 0 {
+		return settings, errors.Join(errs...)
+	}
+	return settings, nil
+}
+
+// trustedEnv are the environment variables a .env file can't set: it comes with the project, which must
+// not be able to loosen the permissions or send requests and API keys to another server. That includes the
+// variables that locate the user's directories, or the project could pose as the global config, and those
+// that make the allow-listed go vet run other programs, e.g. GOFLAGS=-toolexec=./x.
+var trustedEnv = []string{
+	"NYRON_TOOL_POLICIES", "NYRON_ALLOWED_COMMANDS", "NYRON_OUTSIDE_ROOT", "NYRON_WORKSPACE_ROOT", "NYRON_EXTRA_ROOTS",
+	"OPENAI_BASE_URL", "ANTHROPIC_BASE_URL", "GEMINI_BASE_URL", "OLLAMA_HOST",
+	"HOME", "USERPROFILE", "XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME",
+	"GOFLAGS", "GOENV", "GOTOOLCHAIN", "CC", "CXX", "CGO_CFLAGS_ALLOW", "CGO_CPPFLAGS_ALLOW", "CGO_CXXFLAGS_ALLOW",
+}
+
+// loadDotEnv sets the variables of an optional .env file in the working directory that aren't set already.
+// The variables in trustedEnv are skipped and returned as a warning, so an unrelated .env doesn't stop nyron.
+func loadDotEnv() []string {
+	values, err := godotenv.Read()
+	if err != nil {
+		// A missing or unreadable .env file is skipped, as before
+		return nil
+	}
+
+	var refused []string
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if slices.Contains(trustedEnv, name) {
+			refused = append(refused, name)
+			continue
+		}
+		if _, ok := os.LookupEnv(name); !ok {
+			os.Setenv(name, values[name])
+		}
+	}
+	if len(refused) > 0 {
+		return []string{fmt.Sprintf(".env: ignoring %s, which a project's .env file may not set", strings.Join(refused, ", "))}
+	}
+	return nil
+}
+
+// fileSettings is the layout of a config file; unset fields are nil so they don't override earlier layers
+type fileSettings struct {
+	Model *struct {
+		Provider *string `json:"provider"`
+		Model    *string `json:"model"`
+	} `json:"model"`
+	Providers map[string]struct {
+		APIKey  *string `json:"api_key"`
+		BaseURL *string `json:"base_url"`
+	} `json:"providers"`
+	Permissions *struct {
+		Tools           map[string]string `json:"tools"`
+		AllowedCommands []string          `json:"allowed_commands"`
+		OutsideRoot     *string           `json:"outside_root"`
+	} `json:"permissions"`
+	Workspace *struct {
+		Root       *string  `json:"root"`
+		ExtraRoots []string `json:"extra_roots"`
+	} `json:"workspace"`
+	Agent *struct {
+		MaxIterations *int `json:"max_iterations"`
+		ContextWindow *int `json:"context_window"`
+	} `json:"agent"`
+	Tools *struct {
+		SerperAPIKey *string `json:"serper_api_key"`
+	} `json:"tools"`
+	MCP map[string]struct {
+		Command   *string           `json:"command"`
+		Args      []string          `json:"args"`
+		Env       map[string]string `json:"env"`
+		URL       *string           `json:"url"`
+		Transport *string           `json:"transport"`
+		Headers   map[string]string `json:"headers"`
+		Disabled  *bool             `json:"disabled"`
+	} `json:"mcp"`
+	Theme *Theme              `json:"theme"`
+	Keys  map[string]keysList `json:"keys"`
+}
+
+// trustedSections returns the tables that are set and may only come from the global config
+func (f fileSettings) trustedSections() []string {
+	var sections []string
+	if f.Providers != nil {
+		sections = append(sections, "[providers]")
+	}
+	if f.Permissions != nil {
+		sections = append(sections, "[permissions]")
+	}
+	if f.Workspace != nil {
+		sections = append(sections, "[workspace]")
+	}
+	if f.Tools != nil {
+		sections = append(sections, "[tools]")
+	}
+	if f.MCP != nil {
+		sections = append(sections, "[mcp]")
+	}
+	return sections
+}
+
+// keysList is a key binding, written as one key or a list of keys
+type keysList []string
+
+func (k *keysList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*k = keysList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*k = list
+	return nil
+}
+
+// applyFile merges a config file into the settings; a missing file is skipped. The project file comes with
+// the repository, so it may only set the model, the agent limits, the theme and the keys.
+func (s *Settings) applyFile(path string, project bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading config file: %w", err)
+	}
+
+	values, err := parseTOML(string(data))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	// The JSON round trip checks the names and types of the settings against fileSettings
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	var file fileSettings
+	if err := decoder.Decode(&file); err != nil {
+		return false, fmt.Errorf("%s: %s", path, describeDecodeError(err))
+	}
+	if project {
+		if sections := file.trustedSections(); len(sections) > 0 {
+			return false, fmt.Errorf("%s: %s can only be set in the global config, not in a project",
+				path, strings.Join(sections, ", "))
+		}
+	}
+
+	if file.Model != nil {
+		setString(&s.Model.Provider, file.Model.Provider)
+		setString(&s.Model.Model, file.Model.Model)
+	}
+	for id, provider := range file.Providers {
+		merged := s.Providers[id]
+		setString(&merged.APIKey, provider.APIKey)
+		setString(&merged.BaseURL, provider.BaseURL)
+		s.Providers[id] = merged
+	}
+	if p := file.Permissions; p != nil {
+		for tool, policy := range p.Tools {
+			s.Permissions.Tools[tool] = policy
+		}
+		s.Permissions.AllowedCommands = append(s.Permissions.AllowedCommands, p.AllowedCommands...)
+		setString(&s.Permissions.OutsideRoot, p.OutsideRoot)
+	}
+	if w := file.Workspace; w != nil {
+		setString(&s.Workspace.Root, w.Root)
+		s.Workspace.ExtraRoots = append(s.Workspace.ExtraRoots, w.ExtraRoots...)
+	}
+	if a := file.Agent; a != nil {
+		setInt(&s.Agent.MaxIterations, a.MaxIterations)
+		setInt(&s.Agent.ContextWindow, a.ContextWindow)
+	}
+	if file.Tools != nil {
+		setString(&s.Tools.SerperAPIKey, file.Tools.SerperAPIKey)
+	}
+	for name, server := range file.MCP {
+		merged := s.MCP[name]
+		setString(&merged.Command, server.Command)
+		if server.Args != nil {
+			merged.Args = server.Args
+		}
+		merged.Env = mergeMap(merged.Env, server.Env)
+		setString(&merged.URL, server.URL)
+		setString(&merged.Transport, server.Transport)
+		merged.Headers = mergeMap(merged.Headers, server.Headers)
+		if server.Disabled != nil {
+			merged.Disabled = *server.Disabled
+		}
+		s.MCP[name] = merged
+	}
+	if file.Theme != nil {
+		s.Theme.merge(*file.Theme)
+	}
+	for action, keys := range file.Keys {
+		s.Keys[action] = keys
+	}
+	return true, nil
+}
+
+// describeDecodeError rewords the errors of the JSON round trip in terms of the config file
+func describeDecodeError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		expected := "a " + typeErr.Type.Kind().String()
+		switch typeErr.Type.Kind() {
+		case reflect.Int:
+			expected = "a whole number"
+		case reflect.Slice:
+			expected = "a list"
+		case reflect.Struct, reflect.Map, reflect.Pointer:
+			expected = "a table"
+		}
+		actual := "a " + typeErr.Value
+		if typeErr.Value == "array" || typeErr.Value == "object" {
+			actual = "an " + typeErr.Value
+		}
+		return fmt.Sprintf("%s must be %s, not %s", typeErr.Field, expected, actual)
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return "unknown setting " + field
+	}
+	return err.Error()
+}
+
+func setString(target *string, value *string) {
+	if value != nil {
+		*target = *value
+	}
+}
+
+func setInt(target *int, value *int) {
+	if value != nil {
+		*target = *value
+	}
+}
+
+// mergeMap returns target with the entries of values added, leaving target itself unchanged
+func mergeMap(target map[string]string, values map[string]string) map[string]string {
+	if len(values) == 0 {
+		return target
+	}
+	merged := maps.Clone(target)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, values)
+	return merged
+}
+
+func (t *Theme) merge(other Theme) {
+	for _, field := range []struct{ target, value *string }{
+		{&t.Primary, &other.Primary}, {&t.Secondary, &other.Secondary}, {&t.Accent, &other.Accent},
+		{&t.Success, &other.Success}, {&t.Error, &other.Error}, {&t.Muted, &other.Muted},
+		{&t.Border, &other.Border}, {&t.Header, &other.Header},
+	} {
+		if *field.value != "" {
+			*field.target = *field.value
+		}
+	}
+}
+
+// applyEnv applies the environment variables; values that can't be parsed are returned as errors
+func (s *Settings) applyEnv() []error {
+	var errs []error
+	env := func(name string) (string, bool) {
+		value := strings.TrimSpace(os.Getenv(name))
+		return value, value != ""
+	}
+	envInt := func(name string, target *int) {
+		if value, ok := env(name); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a whole number, not %q", name, value))
+				return
+			}
+			*target = n
+		}
+	}
+
+	if value, ok := env("NYRON_PROVIDER"); ok {
+		s.Model.Provider = value
+	}
+	if value, ok := env("NYRON_MODEL"); ok {
+		s.Model.Model = value
+	}
+	for id, names := range providerEnv {
+		provider := s.Providers[id]
+		if value, ok := env(names.apiKey); ok && names.apiKey != "" {
+			provider.APIKey = value
+		}
+		if value, ok := env(names.baseURL); ok && names.baseURL != "" {
+			provider.BaseURL = value
+		}
+		s.Providers[id] = provider
+	}
+
+	// NYRON_TOOL_POLICIES looks like "write_content=allow,web_search=ask,edit_content=deny"
+	if value, ok := env("NYRON_TOOL_POLICIES"); ok {
+		for _, entry := range strings.Split(value, ",") {
+			name, policy, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok {
+				if entry = strings.TrimSpace(entry); entry != "" {
+					errs = append(errs, fmt.Errorf("NYRON_TOOL_POLICIES: %q should look like tool=policy", entry))
+				}
+				continue
+			}
+			s.Permissions.Tools[strings.TrimSpace(name)] = strings.TrimSpace(policy)
+		}
+	}
+	if value, ok := env("NYRON_ALLOWED_COMMANDS"); ok {
+		for _, command := range strings.Split(value, ",") {
+			if command = strings.TrimSpace(command); command != "" {
+				s.Permissions.AllowedCommands = append(s.Permissions.AllowedCommands, command)
+			}
+		}
+	}
+	if value, ok := env("NYRON_OUTSIDE_ROOT"); ok {
+		s.Permissions.OutsideRoot = value
+	}
+	if value, ok := env("NYRON_WORKSPACE_ROOT"); ok {
+		s.Workspace.Root = value
+	}
+	// NYRON_EXTRA_ROOTS is separated like PATH
+	for _, root := range filepath.SplitList(os.Getenv("NYRON_EXTRA_ROOTS")) {
+		if root = strings.TrimSpace(root); root != "" {
+			s.Workspace.ExtraRoots = append(s.Workspace.ExtraRoots, root)
+		}
+	}
+	envInt("NYRON_MAX_ITERATIONS", &s.Agent.MaxIterations)
+	envInt("NYRON_CONTEXT_WINDOW", &s.Agent.ContextWindow)
+	if value, ok := env("SERPER_API_KEY"); ok {
+		s.Tools.SerperAPIKey = value
+	}
+	return errs
+}
+
+var mcpNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// validate checks an [mcp.<name>] table
+func (server MCPServer) validate(name string) []error {
+	var errs []error
+	if !mcpNamePattern.MatchString(name) {
+		errs = append(errs, fmt.Errorf("MCP server name %q may only use letters, digits, _ and -", name))
+	}
+	if server.Transport != "" && !slices.Contains(MCPTransports, server.Transport) {
+		errs = append(errs, fmt.Errorf("unknown transport %q for [mcp.%s], use one of %s", server.Transport, name, strings.Join(MCPTransports, ", ")))
+	}
+	switch {
+	case server.Command == "" && server.URL == "":
+		errs = append(errs, fmt.Errorf("[mcp.%s] needs a command to run or a url to connect to", name))
+	case server.Command != "" && server.URL != "":
+		errs = append(errs, fmt.Errorf("[mcp.%s] has both a command and a url, keep one", name))
+	case server.Command != "" && server.Transport != "" && server.Transport != "stdio":
+		errs = append(errs, fmt.Errorf("[mcp.%s] runs a command, which only works with the stdio transport", name))
+	case server.URL != "" && server.Transport == "stdio":
+		errs = append(errs, fmt.Errorf("[mcp.%s] has a url, use the http or sse transport", name))
+	}
+	return errs
+}
+
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{6}|#[0-9a-fA-F]{3}|[0-9]{1,3})$`)
+
+// validate checks the merged settings and describes every problem it finds
+func (s *Settings) validate() []error {
+	var errs []error
+	providers := []string{}
+	for _, provider := range GetAllProviders() {
+		providers = append(providers, provider.ID)
+	}
+	isPolicy := func(value string) bool {
+		return value == "allow" || value == "ask" || value == "deny"
+	}
+
+	if !slices.Contains(providers, s.Model.Provider) {
+		errs = append(errs, fmt.Errorf("unknown provider %q for the model, use one of %s", s.Model.Provider, strings.Join(providers, ", ")))
+	}
+	if s.Model.Model == "" {
+		errs = append(errs, errors.New("no model is set, add model under [model] or pass --model"))
+	}
+	for id := range s.Providers {
+		if !slices.Contains(providers, id) {
+			errs = append(errs, fmt.Errorf("unknown provider [providers.%s], use one of %s", id, strings.Join(providers, ", ")))
+		}
+	}
+	for tool, policy := range s.Permissions.Tools {
+		if !isPolicy(policy) {
+			errs = append(errs, fmt.Errorf("invalid policy %q for %s, use allow, ask or deny", policy, tool))
+		}
+	}
+	if s.Permissions.OutsideRoot != "" && !isPolicy(s.Permissions.OutsideRoot) {
+		errs = append(errs, fmt.Errorf("invalid outside_root policy %q, use allow, ask or deny", s.Permissions.OutsideRoot))
+	}
+	if s.Agent.MaxIterations < 0 {
+		errs = append(errs, fmt.Errorf("max_iterations can't be negative"))
+	}
+	if s.Agent.ContextWindow < 0 {
+		errs = append(errs, fmt.Errorf("context_window can't be negative"))
+	}
+	for name, server := range s.MCP {
+		errs = append(errs, server.validate(name)...)
+	}
+	for action, keys := range s.Keys {
+		if !slices.Contains(KeyActions, action) {
+			errs = append(errs, fmt.Errorf("unknown key action %q, use one of %s", action, strings.Join(KeyActions, ", ")))
+		}
+		if len(keys) == 0 || slices.Contains(keys, "") {
+			errs = append(errs, fmt.Errorf("the key binding for %s is empty", action))
+		}
+	}
+	for name, color := range map[string]string{
+		"primary": s.Theme.Primary, "secondary": s.Theme.Secondary, "accent": s.Theme.Accent, "success": s.Theme.Success,
+		"error": s.Theme.Error, "muted": s.Theme.Muted, "border": s.Theme.Border, "header": s.Theme.Header,
+	} {
+		if color != "" && !colorPattern.MatchString(color) {
+			errs = append(errs, fmt.Errorf("theme color %s = %q should be like \"#6366f1\" or an ANSI color number", name, color))
+		}
+	}
+	return errs
+}
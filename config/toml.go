@@ -0,0 +1,22 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML decodes a config file into plain maps and values, which the JSON round trip in applyFile
+// then checks against fileSettings
+func parseTOML(input string) (map[string]any, error) {
+	values := map[string]any{}
+	if _, err := toml.Decode(input, &values); err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("line %d: %s", parseErr.Position.Line, parseErr.Message)
+		}
+		return nil, err
+	}
+	return values, nil
+}
@@ -3,7 +3,9 @@ module github.com/krishkalaria12/nyron-ai-cli
 go 1.25.1
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/MichaelMure/go-term-markdown v0.1.4
+	github.com/aymanbagabas/go-udiff v0.2.0
 	github.com/charmbracelet/bubbles v0.21.0
 	github.com/charmbracelet/bubbles/v2 v2.0.0-beta.1
 	github.com/charmbracelet/bubbletea v1.3.9
@@ -0,0 +1,93 @@
+package headless
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/krishkalaria12/nyron-ai-cli/agent"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+)
+
+// Options configure a headless run
+type Options struct {
+	Prompt string
+	Model  config.SelectedModel
+	Format Format
+	// Permission answers the tool calls the TUI would ask about, PolicyAllow or PolicyDeny.
+	// Dangerous shell commands are denied either way since nobody can review them.
+	Permission    permission.Policy
+	MaxIterations int // Model calls allowed for the prompt, 0 for the configured default
+}
+
+// Run sends the prompt to the model, runs the tools it asks for and prints the answer to stdout.
+// Progress goes to stderr, except in the JSON format where every event goes to stdout.
+func Run(ctx context.Context, options Options, stdout io.Writer, stderr io.Writer) error {
+	out, err := newPrinter(options.Format, stdout, stderr)
+	if err != nil {
+		return err
+	}
+
+	maxIterations := options.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = config.MaxIterations()
+	}
+	runner := agent.New(permission.NewManagerFromConfig(), maxIterations)
+
+	out.start(options.Model)
+	var runErr error
+	for event := range runner.Run(ctx, session.New(options.Model), options.Prompt) {
+		switch event := event.(type) {
+		case agent.MessageDone:
+			if len(event.Message.ToolCalls) > 0 && event.Message.Content.Text != "" {
+				out.text(event.Message.Content.Text)
+			}
+		case agent.ToolCallRequested:
+			out.toolCall(event.Call)
+		case agent.ApprovalRequested:
+			event.Respond(approve(event, options.Permission))
+		case agent.ToolResult:
+			out.toolResult(event.Call, event.Result, event.Denied)
+		case agent.Usage:
+			out.usage(event.Usage)
+		case agent.Done:
+			if event.Err != nil {
+				runErr = event.Err
+				out.fail(event.Err)
+			} else {
+				out.result(event.Message.Content.Text, event.Usage)
+			}
+		}
+	}
+	return runErr
+}
+
+// approve answers a call the TUI would ask about with the fallback policy; dangerous commands are always denied
+func approve(request agent.ApprovalRequested, fallback permission.Policy) agent.Approval {
+	switch {
+	case request.Dangerous:
+		return agent.Approval{
+			Decision: permission.DenyWithFeedback,
+			Feedback: "Dangerous commands need interactive approval and can't run in headless mode",
+		}
+	case fallback == permission.PolicyAllow:
+		return agent.Approval{Decision: permission.AllowOnce}
+	default:
+		return agent.Approval{
+			Decision: permission.DenyWithFeedback,
+			Feedback: "This tool needs approval, which headless mode denies unless run with --permission allow",
+		}
+	}
+}
+
+// ParsePermission converts the --permission flag to the policy for calls that would ask
+func ParsePermission(value string) (permission.Policy, error) {
+	switch policy, _ := permission.ParsePolicy(value); policy {
+	case permission.PolicyAllow, permission.PolicyDeny:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid permission %q, use allow or deny", value)
+	}
+}
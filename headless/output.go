@@ -0,0 +1,171 @@
+package headless
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// Format is how a headless run prints its result
+type Format string
+
+const (
+	FormatText     Format = "text"     // The final answer as the model wrote it
+	FormatMarkdown Format = "markdown" // The final answer rendered for the terminal
+	FormatJSON     Format = "json"     // One JSON event per line for every step of the run
+)
+
+// markdownWidth is the line width of answers printed with FormatMarkdown
+const markdownWidth = 100
+
+// ParseFormat converts the --output-format flag to a Format
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case FormatText, FormatMarkdown, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q, use text, markdown or json", value)
+	}
+}
+
+// printer reports the steps of a run
+type printer interface {
+	start(model config.SelectedModel)
+	text(content string)
+	toolCall(call openrouter.ToolCall)
+	toolResult(call openrouter.ToolCall, result string, denied bool)
+	usage(usage openrouter.Usage)
+	result(content string, total openrouter.Usage)
+	fail(err error)
+}
+
+func newPrinter(format Format, stdout io.Writer, stderr io.Writer) (printer, error) {
+	switch format {
+	case FormatText, "":
+		return &textPrinter{stdout: stdout, stderr: stderr}, nil
+	case FormatMarkdown:
+		return &textPrinter{stdout: stdout, stderr: stderr, render: true}, nil
+	case FormatJSON:
+		return &jsonPrinter{encoder: json.NewEncoder(stdout)}, nil
+	default:
+		return nil, fmt.Errorf("invalid output format %q", format)
+	}
+}
+
+// textPrinter prints the final answer to stdout and the tool calls on the way to stderr
+type textPrinter struct {
+	stdout io.Writer
+	stderr io.Writer
+	render bool
+}
+
+func (p *textPrinter) start(config.SelectedModel) {}
+func (p *textPrinter) text(string)                {}
+func (p *textPrinter) usage(openrouter.Usage)     {}
+
+func (p *textPrinter) toolCall(call openrouter.ToolCall) {
+	fmt.Fprintf(p.stderr, "→ %s %s\n", call.Function.Name, compactJSON(call.Function.Arguments))
+}
+
+func (p *textPrinter) toolResult(call openrouter.ToolCall, _ string, denied bool) {
+	if denied {
+		fmt.Fprintf(p.stderr, "✗ %s denied\n", call.Function.Name)
+	}
+}
+
+func (p *textPrinter) result(content string, _ openrouter.Usage) {
+	if p.render {
+		rendered, err := ai.RenderToTerminalWithWidth(content, markdownWidth)
+		if err == nil {
+			content = rendered
+		}
+	}
+	fmt.Fprintln(p.stdout, strings.TrimRight(content, "\n"))
+}
+
+func (p *textPrinter) fail(err error) {
+	fmt.Fprintln(p.stderr, "Error:", err)
+}
+
+// event is one line of the JSON output
+type event struct {
+	Type       string            `json:"type"`
+	Provider   string            `json:"provider,omitempty"`
+	Model      string            `json:"model,omitempty"`
+	Text       string            `json:"text,omitempty"`
+	ToolCallID string            `json:"tool_call_id,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Arguments  json.RawMessage   `json:"arguments,omitempty"`
+	Result     json.RawMessage   `json:"result,omitempty"`
+	Denied     bool              `json:"denied,omitempty"`
+	Usage      *openrouter.Usage `json:"usage,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// jsonPrinter writes the events of a run as JSON Lines
+type jsonPrinter struct {
+	encoder *json.Encoder
+}
+
+func (p *jsonPrinter) emit(e event) {
+	_ = p.encoder.Encode(e)
+}
+
+func (p *jsonPrinter) start(model config.SelectedModel) {
+	p.emit(event{Type: "start", Provider: model.Provider, Model: model.Model})
+}
+
+func (p *jsonPrinter) text(content string) {
+	p.emit(event{Type: "text", Text: content})
+}
+
+func (p *jsonPrinter) toolCall(call openrouter.ToolCall) {
+	p.emit(event{Type: "tool_call", ToolCallID: call.ID, Name: call.Function.Name, Arguments: rawJSON(call.Function.Arguments)})
+}
+
+func (p *jsonPrinter) toolResult(call openrouter.ToolCall, result string, denied bool) {
+	p.emit(event{Type: "tool_result", ToolCallID: call.ID, Name: call.Function.Name, Result: rawJSON(result), Denied: denied})
+}
+
+func (p *jsonPrinter) usage(usage openrouter.Usage) {
+	p.emit(event{Type: "usage", Usage: &usage})
+}
+
+func (p *jsonPrinter) result(content string, total openrouter.Usage) {
+	p.emit(event{Type: "result", Text: content, Usage: &total})
+}
+
+func (p *jsonPrinter) fail(err error) {
+	p.emit(event{Type: "error", Error: err.Error()})
+}
+
+// rawJSON embeds valid JSON as is and quotes anything else, such as truncated tool arguments
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	quoted, _ := json.Marshal(value)
+	return quoted
+}
+
+// compactJSON shortens tool arguments to one line for the progress output
+func compactJSON(value string) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(value)); err != nil {
+		return value
+	}
+	compact := b.String()
+	if runes := []rune(compact); len(runes) > 200 {
+		compact = string(runes[:199]) + "…"
+	}
+	return compact
+}
@@ -0,0 +1,55 @@
+package headless
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/catalog"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	"github.com/krishkalaria12/nyron-ai-cli/mcp"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// Start runs a headless session in the current directory and exits with status 1 if it fails
+func Start(options Options) {
+	// The OpenRouter client logs through slog, which would mix into the output
+	openrouter.DisableLogs()
+
+	workspace, err := tools.NewWorkspaceFromConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error setting up the workspace:", err)
+		os.Exit(1)
+	}
+	tools.SetWorkspace(workspace)
+
+	// The cached catalog tells the context window and tool support of the model, without waiting on the network
+	catalog.LoadCached(options.Model.Provider)
+
+	// The model only sees the tools of servers that are ready, so headless runs wait for them
+	servers := mcp.NewManager(config.Current().MCP)
+	mcp.SetDefault(servers)
+	servers.Start()
+	servers.Wait()
+	for _, server := range servers.Servers() {
+		if server.State == mcp.StateFailed {
+			fmt.Fprintf(os.Stderr, "MCP server %s failed to start: %v\n", server.Name, server.Err)
+		}
+		for _, warning := range server.Warnings {
+			fmt.Fprintf(os.Stderr, "MCP server %s: %s\n", server.Name, warning)
+		}
+	}
+
+	// Ctrl+C cancels the model call or tool in flight
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = Run(ctx, options, os.Stdout, os.Stderr)
+	servers.Close()
+	if err != nil {
+		stop()
+		os.Exit(1)
+	}
+}
@@ -1,7 +1,210 @@
 package main
 
-import "github.com/krishkalaria12/nyron-ai-cli/tui"
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	"github.com/krishkalaria12/nyron-ai-cli/headless"
+	"github.com/krishkalaria12/nyron-ai-cli/mcp"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	"github.com/krishkalaria12/nyron-ai-cli/tui"
+)
 
 func main() {
-	tui.StartTUI()
+	if len(os.Args) > 1 && os.Args[1] == "usage" {
+		if err := usageReport(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		if err := mcpCommand(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	continueSession := flag.Bool("continue", false, "continue the most recent session of the current directory")
+	resumeSession := flag.Bool("resume", false, "resume a session by ID, or pick one from the session browser when no ID is given")
+	prompt := flag.String("p", "", "run the prompt without the TUI and print the answer; use - or pipe input to read it from stdin")
+	outputFormat := flag.String("output-format", string(headless.FormatText), "output of -p: text, markdown or json")
+	permissionPolicy := flag.String("permission", "deny", "with -p, allow or deny the tool calls that would ask for approval")
+	providerID := flag.String("provider", "", "provider to start with, overriding the config (default "+config.DefaultModel.Provider+")")
+	modelID := flag.String("model", "", "model to start with, overriding the config (default "+config.DefaultModel.Model+")")
+	maxIterations := flag.Int("max-iterations", 0, "the most model calls per message before giving up (default 50)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s [--continue] [--resume [session-id]]\n  %s -p <prompt> [--output-format text|markdown|json] [--permission allow|deny]\n  %s usage [--format json|csv] [--all] [-o file]\n  %s mcp serve [--allow-mutating]\n\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	settings := loadConfig(config.Overrides{Provider: *providerID, Model: *modelID, MaxIterations: *maxIterations})
+
+	if isFlagSet("p") {
+		options, err := headlessOptions(*prompt, *outputFormat, *permissionPolicy)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(2)
+		}
+		options.Model = settings.Model
+		options.MaxIterations = settings.Agent.MaxIterations
+		headless.Start(options)
+		return
+	}
+
+	options := tui.Options{
+		Continue: *continueSession,
+		Resume:   *resumeSession,
+	}
+	if *resumeSession {
+		options.SessionID = flag.Arg(0)
+	}
+	tui.StartTUI(options)
+}
+
+// loadConfig loads the settings and prints their warnings, or lists what is wrong with them and exits with status 2
+func loadConfig(overrides config.Overrides) *config.Settings {
+	settings, err := config.Load(overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid configuration:")
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  -", line)
+		}
+		os.Exit(2)
+	}
+	for _, warning := range settings.Warnings {
+		fmt.Fprintln(os.Stderr, "Warning:", warning)
+	}
+	return settings
+}
+
+// mcpCommand runs "nyron mcp serve", which offers the built-in tools to other MCP clients over stdin and stdout
+func mcpCommand(args []string) error {
+	flags := flag.NewFlagSet("mcp serve", flag.ExitOnError)
+	allowMutating := flags.Bool("allow-mutating", false, "also offer the tools that change files or run commands, for clients that confirm calls with their user")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage:\n  %s mcp serve [--allow-mutating]\n\nServes the built-in tools over MCP on stdin and stdout, confined to the workspace.\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "serve" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	flags.Parse(args[1:])
+	if flags.NArg() > 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	loadConfig(config.Overrides{})
+	workspace, err := tools.NewWorkspaceFromConfig()
+	if err != nil {
+		return fmt.Errorf("setting up the workspace: %w", err)
+	}
+	tools.SetWorkspace(workspace)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return mcp.Serve(ctx, os.Stdin, os.Stdout, permission.NewManagerFromConfig(), *allowMutating)
+}
+
+// usageReport prints the token usage and cost of the saved sessions of this directory, or of all of them with --all
+func usageReport(args []string) error {
+	flags := flag.NewFlagSet("usage", flag.ExitOnError)
+	format := flags.String("format", string(session.ReportJSON), "report format: json (totals per directory and session) or csv (one row per model call)")
+	all := flags.Bool("all", false, "include the sessions of every directory, not only the current one and its subdirectories")
+	output := flags.String("o", "", "write the report to a file instead of stdout")
+	flags.Parse(args)
+
+	reportFormat, err := session.ParseReportFormat(*format)
+	if err != nil {
+		return err
+	}
+	store, err := session.NewDefaultStore()
+	if err != nil {
+		return fmt.Errorf("finding the session directory: %w", err)
+	}
+	sessions, err := store.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	if !*all {
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		var matching []*session.Session
+		for _, s := range sessions {
+			if rel, err := filepath.Rel(dir, s.Dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				matching = append(matching, s)
+			}
+		}
+		sessions = matching
+	}
+
+	if *output == "" {
+		return session.WriteReport(os.Stdout, sessions, reportFormat)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := session.WriteReport(file, sessions, reportFormat); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// headlessOptions validates the -p flags; input piped to stdin is appended to the prompt
+func headlessOptions(prompt string, outputFormat string, permissionPolicy string) (headless.Options, error) {
+	format, err := headless.ParseFormat(outputFormat)
+	if err != nil {
+		return headless.Options{}, err
+	}
+	policy, err := headless.ParsePermission(permissionPolicy)
+	if err != nil {
+		return headless.Options{}, err
+	}
+
+	if prompt == "-" {
+		prompt = ""
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return headless.Options{}, fmt.Errorf("reading stdin: %w", err)
+		}
+		if text := strings.TrimSpace(string(input)); text != "" {
+			prompt = strings.TrimSpace(prompt + "\n\n" + text)
+		}
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return headless.Options{}, fmt.Errorf("no prompt given, pass it with -p or on stdin")
+	}
+
+	return headless.Options{Prompt: prompt, Format: format, Permission: policy}, nil
+}
+
+// isFlagSet reports whether a flag was given on the command line, even with an empty value
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
@@ -0,0 +1,187 @@
+// Package mcp connects to Model Context Protocol servers, whose tools the model can then call like the
+// built-in ones, and serves the built-in tools to other MCP clients
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// ProtocolVersion is the MCP revision this client speaks
+const ProtocolVersion = "2025-06-18"
+
+// Tool is a tool offered by a server
+type Tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// Content is one part of a tool result
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Data     string `json:"data,omitempty"`
+	Resource *struct {
+		URI  string `json:"uri"`
+		Text string `json:"text,omitempty"`
+	} `json:"resource,omitempty"`
+}
+
+// CallResult is what a server returns for a tool call
+type CallResult struct {
+	Content           []Content       `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// Text returns the content of a result as text, with placeholders for images and audio
+func (r CallResult) Text() string {
+	parts := make([]string, 0, len(r.Content))
+	for _, content := range r.Content {
+		switch {
+		case content.Type == "text":
+			parts = append(parts, content.Text)
+		case content.Type == "resource" && content.Resource != nil && content.Resource.Text != "":
+			parts = append(parts, content.Resource.Text)
+		case content.Type == "resource" && content.Resource != nil:
+			parts = append(parts, "[resource "+content.Resource.URI+"]")
+		default:
+			parts = append(parts, fmt.Sprintf("[%s %s]", content.Type, content.MimeType))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Client is a connection to one server
+type Client struct {
+	conn *conn
+	// Instructions is what the server says about using its tools, if anything
+	Instructions string
+	// ServerName and ServerVersion are how the server introduced itself
+	ServerName, ServerVersion string
+}
+
+// Connect starts or reaches a server and completes the MCP handshake
+func Connect(ctx context.Context, server config.MCPServer) (*Client, error) {
+	c := newConn()
+	var setVersion func(string)
+	switch server.TransportName() {
+	case "stdio":
+		if err := startStdio(c, server.Command, server.Args, server.Env); err != nil {
+			return nil, err
+		}
+	case "http":
+		t := newHTTP(c, server.URL, server.Headers)
+		c.transport = t
+		setVersion = t.setProtocolVersion
+	case "sse":
+		if err := startSSE(ctx, c, server.URL, server.Headers); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown transport %q", server.Transport)
+	}
+
+	client := &Client{conn: c}
+	if err := client.initialize(ctx, setVersion); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// buildVersion returns the version nyron was installed as, or "dev" for a local build
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+func (c *Client) initialize(ctx context.Context, setVersion func(string)) error {
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+		Instructions string `json:"instructions"`
+	}
+	err := c.conn.call(ctx, "initialize", map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]string{"name": "nyron", "version": buildVersion()},
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("initializing: %w", err)
+	}
+	c.ServerName = result.ServerInfo.Name
+	c.ServerVersion = result.ServerInfo.Version
+	c.Instructions = result.Instructions
+	if setVersion != nil {
+		setVersion(result.ProtocolVersion)
+	}
+	return c.conn.notify(ctx, "notifications/initialized", nil)
+}
+
+// ListTools returns every tool the server offers
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.conn.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, fmt.Errorf("listing tools: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool runs a tool on the server with JSON arguments
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (CallResult, error) {
+	var result CallResult
+	err := c.conn.call(ctx, "tools/call", map[string]any{"name": name, "arguments": arguments}, &result)
+	return result, err
+}
+
+// Watch calls onToolsChanged when the server's tools change and onClose when the connection ends by itself,
+// e.g. because the server exited
+func (c *Client) Watch(onToolsChanged func(), onClose func(err error)) {
+	c.conn.watch(func(method string) {
+		if method == "notifications/tools/list_changed" {
+			onToolsChanged()
+		}
+	}, onClose)
+}
+
+// Stderr returns the last lines a stdio server wrote to stderr
+func (c *Client) Stderr() string {
+	if t, ok := c.conn.transport.(*stdioTransport); ok {
+		return t.stderr.String()
+	}
+	return ""
+}
+
+// Close ends the connection, stopping the server if it was started for it
+func (c *Client) Close() error {
+	return c.conn.close()
+}
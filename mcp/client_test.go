@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name   string
+		server func(t *testing.T) config.MCPServer
+		stderr string // Lines kept from the server's output
+	}{
+		{"stdio", func(t *testing.T) config.MCPServer { return stdioStub("serve") }, ""},
+		{"stdio with logs on stdout", func(t *testing.T) config.MCPServer { return stdioStub("noisy") }, "stub starting up"},
+		{"http", func(t *testing.T) config.MCPServer {
+			server := httptest.NewServer(&stub{})
+			t.Cleanup(server.Close)
+			return config.MCPServer{URL: server.URL + "/mcp"}
+		}, ""},
+		{"sse", func(t *testing.T) config.MCPServer {
+			server := httptest.NewServer(&stub{events: make(chan message, 16)})
+			t.Cleanup(server.Close)
+			return config.MCPServer{URL: server.URL + "/sse", Transport: "sse"}
+		}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			client, err := Connect(ctx, test.server(t))
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			defer client.Close()
+			if client.ServerName != "stub" || client.ServerVersion != "1.2.3" {
+				t.Errorf("server is %q %q, want stub 1.2.3", client.ServerName, client.ServerVersion)
+			}
+			if client.Instructions != "Call echo to test the connection." {
+				t.Errorf("Instructions = %q", client.Instructions)
+			}
+			if client.Stderr() != test.stderr {
+				t.Errorf("Stderr() = %q, want %q", client.Stderr(), test.stderr)
+			}
+
+			serverTools, err := client.ListTools(ctx)
+			if err != nil {
+				t.Fatalf("ListTools: %v", err)
+			}
+			if got := toolNames(serverTools); got != "echo fail change quit" {
+				t.Errorf("tools are %q, want both pages: echo fail change quit", got)
+			}
+
+			result, err := client.CallTool(ctx, "echo", json.RawMessage(`{"text":"hello"}`))
+			if err != nil || result.IsError || result.Text() != "hello" {
+				t.Errorf("echo returned %+v, %v; want hello", result, err)
+			}
+			result, err = client.CallTool(ctx, "fail", json.RawMessage(`{}`))
+			if err != nil || !result.IsError {
+				t.Errorf("fail returned %+v, %v; want a result with isError", result, err)
+			}
+			_, err = client.CallTool(ctx, "missing", json.RawMessage(`{}`))
+			var rpcErr *RPCError
+			if !errors.As(err, &rpcErr) || rpcErr.Code != codeInvalidParams {
+				t.Errorf("calling an unknown tool returned %v, want an invalid params error", err)
+			}
+
+			changed := make(chan struct{}, 1)
+			client.Watch(func() { changed <- struct{}{} }, func(error) {})
+			if _, err := client.CallTool(ctx, "change", json.RawMessage(`{}`)); err != nil {
+				t.Fatalf("change: %v", err)
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				t.Fatal("no list_changed notification")
+			}
+			serverTools, err = client.ListTools(ctx)
+			if err != nil || !strings.HasSuffix(toolNames(serverTools), "quit added") {
+				t.Errorf("tools after the change are %q, %v; want added at the end", toolNames(serverTools), err)
+			}
+		})
+	}
+}
+
+func TestClientNoticesServerExit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, stdioStub("serve"))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	closed := make(chan error, 1)
+	client.Watch(func() {}, func(err error) { closed <- err })
+	if _, err := client.CallTool(ctx, "quit", json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("quit: %v", err)
+	}
+	select {
+	case err := <-closed:
+		if err == nil || !strings.Contains(err.Error(), "the server exited") {
+			t.Errorf("onClose got %v, want the server exited", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("onClose wasn't called after the server exited")
+	}
+	if _, err := client.ListTools(ctx); err == nil {
+		t.Error("ListTools succeeded after the server exited")
+	}
+}
+
+func TestHTTPClientEndsSession(t *testing.T) {
+	s := &stub{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	client, err := Connect(context.Background(), config.MCPServer{URL: server.URL + "/mcp"})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	client.Close()
+	if !s.sessionEnded {
+		t.Error("Close didn't end the session with DELETE")
+	}
+}
+
+func TestConnectFailures(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "token expired", http.StatusUnauthorized)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name   string
+		server config.MCPServer
+		want   string
+	}{
+		{"missing command", config.MCPServer{Command: "/nonexistent/nyron-mcp-stub"}, "starting /nonexistent/nyron-mcp-stub"},
+		{"server exits", stdioStub("crash"), "stub: no API token"},
+		{"http error", config.MCPServer{URL: failing.URL}, "status 401: token expired"},
+		{"sse error", config.MCPServer{URL: failing.URL, Transport: "sse"}, "status 401: token expired"},
+		{"unknown transport", config.MCPServer{URL: failing.URL, Transport: "websocket"}, `unknown transport "websocket"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			client, err := Connect(ctx, test.server)
+			if err == nil {
+				client.Close()
+				t.Fatal("Connect succeeded")
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Connect returned %q, want it to contain %q", err, test.want)
+			}
+		})
+	}
+}
+
+func toolNames(serverTools []Tool) string {
+	names := make([]string, len(serverTools))
+	for i, tool := range serverTools {
+		names[i] = tool.Name
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,314 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// httpTransport speaks the streamable HTTP transport: every message is POSTed to one URL, and the server
+// answers with JSON or with an event stream that carries the response
+type httpTransport struct {
+	conn    *conn
+	url     string
+	headers map[string]string
+
+	mu              sync.Mutex
+	sessionID       string // Given by the server in its answer to initialize
+	protocolVersion string
+}
+
+func newHTTP(c *conn, endpoint string, headers map[string]string) *httpTransport {
+	return &httpTransport{conn: c, url: endpoint, headers: headers}
+}
+
+func (t *httpTransport) send(ctx context.Context, msg message) error {
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		resp.Body.Close()
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if resp.StatusCode == http.StatusNotFound && t.session() != "" {
+			return fmt.Errorf("the server ended the session, reconnect to start a new one")
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		// The response arrives on the stream, perhaps after notifications; the caller waits for it
+		go func() {
+			defer resp.Body.Close()
+			_ = readEvents(resp.Body, func(event string, data string) bool {
+				if event == "message" {
+					t.receive([]byte(data))
+				}
+				return true
+			})
+		}()
+		return nil
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		t.receive(body)
+	}
+	return nil
+}
+
+// receive decodes a message, or a batch of them, and hands it to the connection
+func (t *httpTransport) receive(data []byte) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var batch []message
+		if json.Unmarshal(data, &batch) == nil {
+			for _, msg := range batch {
+				t.conn.receive(msg)
+			}
+		}
+		return
+	}
+	var msg message
+	if json.Unmarshal(data, &msg) == nil {
+		t.conn.receive(msg)
+	}
+}
+
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for name, value := range t.headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set("MCP-Protocol-Version", t.protocolVersion)
+	}
+}
+
+func (t *httpTransport) session() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// setProtocolVersion records the version agreed on in initialize, which later requests send as a header
+func (t *httpTransport) setProtocolVersion(version string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocolVersion = version
+}
+
+// close ends the session on the server, if it started one
+func (t *httpTransport) close() error {
+	if t.session() == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	t.setHeaders(req)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// sseTransport speaks the older HTTP+SSE transport: the server sends messages over an event stream, whose
+// first event tells where to POST the messages for the server
+type sseTransport struct {
+	headers  map[string]string
+	endpoint string
+	cancel   context.CancelFunc
+}
+
+// startSSE opens the event stream, waits for the endpoint event and makes the stream the transport of c
+func startSSE(ctx context.Context, c *conn, streamURL string, headers map[string]string) error {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	t := &sseTransport{headers: headers, cancel: cancel}
+	c.transport = t
+
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		cancel()
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	t.setHeaders(req)
+
+	// The stream is opened without the connect context, which ends before the session does.
+	// Only the first outcome is reported, later ones find the channel full.
+	connected := make(chan error, 1)
+	report := func(err error) {
+		select {
+		case connected <- err:
+		default:
+		}
+	}
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			report(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			report(fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+			return
+		}
+
+		err = readEvents(resp.Body, func(event string, data string) bool {
+			switch event {
+			case "endpoint":
+				endpoint, err := url.Parse(strings.TrimSpace(data))
+				if err != nil {
+					report(fmt.Errorf("the server sent an invalid endpoint %q", data))
+					return false
+				}
+				base, _ := url.Parse(streamURL)
+				t.endpoint = base.ResolveReference(endpoint).String()
+				report(nil)
+			case "message":
+				var msg message
+				if json.Unmarshal([]byte(data), &msg) == nil {
+					c.receive(msg)
+				}
+			}
+			return true
+		})
+		if err == nil {
+			err = fmt.Errorf("the server closed the event stream")
+		}
+		report(err)
+		c.fail(err)
+	}()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			cancel()
+		}
+		return err
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+func (t *sseTransport) send(ctx context.Context, msg message) error {
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	t.setHeaders(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	// The response comes over the event stream
+	return nil
+}
+
+func (t *sseTransport) setHeaders(req *http.Request) {
+	for name, value := range t.headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+}
+
+func (t *sseTransport) close() error {
+	t.cancel()
+	return nil
+}
+
+// readEvents calls fn for every server-sent event until the stream ends or fn returns false
+func readEvents(r io.Reader, fn func(event string, data string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	event := ""
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				if !fn(event, strings.Join(data, "\n")) {
+					return nil
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if event == "" {
+			event = "message"
+		}
+		fn(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// message is a JSON-RPC 2.0 request, notification or response
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is an error the server answered a request with
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// JSON-RPC error codes used when answering requests
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// ErrClosed is returned for requests to a server whose connection has ended
+var ErrClosed = errors.New("the connection to the MCP server is closed")
+
+// transport carries messages to a server; messages from the server are handed to the conn it was made for
+type transport interface {
+	send(ctx context.Context, msg message) error
+	close() error
+}
+
+// conn matches responses to requests over a transport and answers the requests the server sends
+type conn struct {
+	transport transport
+	nextID    atomic.Int64
+
+	mu      sync.Mutex
+	pending map[string]chan message
+	err     error // Why the connection ended, nil while it is open
+
+	// onNotification is called for every notification from the server, e.g. notifications/tools/list_changed
+	onNotification func(method string)
+	// onClose is called once when the connection ends without Close being called
+	onClose func(err error)
+}
+
+func newConn() *conn {
+	return &conn{pending: map[string]chan message{}}
+}
+
+// call sends a request and decodes the result of its response into result, which may be nil
+func (c *conn) call(ctx context.Context, method string, params any, result any) error {
+	id := c.nextID.Add(1)
+	key := strconv.FormatInt(id, 10)
+	msg := message{JSONRPC: "2.0", ID: json.RawMessage(key), Method: method}
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = encoded
+	}
+
+	responses := make(chan message, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.pending[key] = responses
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.mu.Unlock()
+	}()
+
+	if err := c.transport.send(ctx, msg); err != nil {
+		return err
+	}
+
+	select {
+	case response, ok := <-responses:
+		if !ok {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.err
+		}
+		if response.Error != nil {
+			return response.Error
+		}
+		if result == nil || response.Result == nil {
+			return nil
+		}
+		return json.Unmarshal(response.Result, result)
+	case <-ctx.Done():
+		// Tells the server to stop working on the request; the answer doesn't matter any more
+		_ = c.notify(context.WithoutCancel(ctx), "notifications/cancelled", map[string]any{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification, which gets no response
+func (c *conn) notify(ctx context.Context, method string, params any) error {
+	msg := message{JSONRPC: "2.0", Method: method}
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = encoded
+	}
+	return c.transport.send(ctx, msg)
+}
+
+// receive handles a message from the server
+func (c *conn) receive(msg message) {
+	switch {
+	case msg.Method == "" && msg.ID != nil:
+		c.mu.Lock()
+		if responses, ok := c.pending[string(msg.ID)]; ok {
+			// A second response to the same request is dropped
+			select {
+			case responses <- msg:
+			default:
+			}
+		}
+		c.mu.Unlock()
+
+	case msg.Method != "" && msg.ID != nil:
+		// Requests from the server: pings are answered, everything else is a capability this client didn't offer
+		response := message{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("{}")}
+		if msg.Method != "ping" {
+			response = message{JSONRPC: "2.0", ID: msg.ID, Error: &RPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}}
+		}
+		go func() { _ = c.transport.send(context.Background(), response) }()
+
+	case msg.Method != "":
+		c.mu.Lock()
+		onNotification := c.onNotification
+		c.mu.Unlock()
+		if onNotification != nil {
+			onNotification(msg.Method)
+		}
+	}
+}
+
+// fail ends the connection, failing the requests still waiting for a response
+func (c *conn) fail(err error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.err = err
+	for key, responses := range c.pending {
+		close(responses)
+		delete(c.pending, key)
+	}
+	onClose := c.onClose
+	c.mu.Unlock()
+
+	if onClose != nil && !errors.Is(err, ErrClosed) {
+		onClose(err)
+	}
+}
+
+// watch sets the callbacks for notifications and for the end of the connection; when the connection has
+// already ended, onClose is called right away on another goroutine
+func (c *conn) watch(onNotification func(method string), onClose func(err error)) {
+	c.mu.Lock()
+	c.onNotification = onNotification
+	c.onClose = onClose
+	err := c.err
+	c.mu.Unlock()
+
+	if err != nil && !errors.Is(err, ErrClosed) {
+		go onClose(err)
+	}
+}
+
+// close ends the connection on purpose
+func (c *conn) close() error {
+	c.fail(ErrClosed)
+	return c.transport.close()
+}
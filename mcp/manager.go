@@ -0,0 +1,357 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// ConnectTimeout is how long a server may take to start and list its tools
+const ConnectTimeout = 30 * time.Second
+
+// State is where the connection to a server stands
+type State string
+
+const (
+	StateConnecting State = "connecting"
+	StateConnected  State = "connected"
+	StateFailed     State = "failed"
+	StateDisabled   State = "disabled"
+)
+
+// Server describes a configured server and the tools it added
+type Server struct {
+	Name      string
+	Transport string
+	State     State
+	Err       error
+	// Tools are the server's tools, each registered under ToolName(Name, tool.Name)
+	Tools []Tool
+	// Warnings tell which tools were left out because their name was already taken
+	Warnings []string
+	Stderr   string
+}
+
+// Manager keeps the connections to the configured servers and registers their tools
+type Manager struct {
+	mu      sync.Mutex
+	servers map[string]*server
+	wg      sync.WaitGroup
+}
+
+type server struct {
+	name       string
+	config     config.MCPServer
+	state      State
+	err        error
+	client     *Client
+	tools      []Tool
+	warnings   []string
+	registered []string
+	generation int // Counts connection attempts, so callbacks of an old connection are ignored
+}
+
+var (
+	defaultMu      sync.Mutex
+	defaultManager = NewManager(nil)
+)
+
+// SetDefault makes m the manager the rest of the program uses
+func SetDefault(m *Manager) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultManager = m
+}
+
+// Default returns the manager set with SetDefault, without servers before that
+func Default() *Manager {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultManager
+}
+
+// NewManager returns a manager for the servers by name; nothing is started until Start
+func NewManager(servers map[string]config.MCPServer) *Manager {
+	m := &Manager{servers: map[string]*server{}}
+	for name, settings := range servers {
+		state := StateConnecting
+		if settings.Disabled {
+			state = StateDisabled
+		}
+		m.servers[name] = &server{name: name, config: settings, state: state}
+	}
+	return m
+}
+
+// Start connects to every enabled server in the background; Wait blocks until they are done
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.servers {
+		if s.state != StateDisabled {
+			m.connect(s)
+		}
+	}
+}
+
+// Wait blocks until every connection attempt has finished, successfully or not
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Reconnect closes the connection to a server and starts a new one, e.g. after the server failed
+func (m *Manager) Reconnect(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.servers[name]
+	if !ok || s.state == StateDisabled {
+		return
+	}
+	if client := m.disconnect(s); client != nil {
+		// Stopping a server can take a few seconds, which the dialog shouldn't wait for
+		go func() { _ = client.Close() }()
+	}
+	m.connect(s)
+}
+
+// Servers returns the configured servers sorted by name
+func (m *Manager) Servers() []Server {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	servers := make([]Server, 0, len(m.servers))
+	for _, s := range m.servers {
+		server := Server{
+			Name:      s.name,
+			Transport: s.config.TransportName(),
+			State:     s.state,
+			Err:       s.err,
+			Tools:     s.tools,
+			Warnings:  s.warnings,
+		}
+		if s.client != nil {
+			server.Stderr = s.client.Stderr()
+		}
+		servers = append(servers, server)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers
+}
+
+// Close disconnects every server, waiting for the servers it started to stop, and removes their tools
+func (m *Manager) Close() {
+	m.mu.Lock()
+	var clients []*Client
+	for _, s := range m.servers {
+		if client := m.disconnect(s); client != nil {
+			clients = append(clients, client)
+		}
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = client.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+// connect starts a connection attempt; the caller holds m.mu
+func (m *Manager) connect(s *server) {
+	s.generation++
+	s.state, s.err = StateConnecting, nil
+	generation := s.generation
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), ConnectTimeout)
+		defer cancel()
+
+		client, err := Connect(ctx, s.config)
+		var serverTools []Tool
+		if err == nil {
+			serverTools, err = client.ListTools(ctx)
+			if err != nil {
+				_ = client.Close()
+			}
+		}
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if s.generation != generation {
+			// Reconnected or closed in the meantime
+			if err == nil {
+				_ = client.Close()
+			}
+			return
+		}
+		if err != nil {
+			s.state, s.err = StateFailed, err
+			return
+		}
+		s.state, s.client = StateConnected, client
+		m.register(s, serverTools)
+
+		client.Watch(func() {
+			go m.refreshTools(s, generation)
+		}, func(err error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if s.generation == generation {
+				m.unregister(s)
+				s.state, s.err, s.client = StateFailed, err, nil
+			}
+		})
+	}()
+}
+
+// refreshTools lists the tools of a server again after it said they changed
+func (m *Manager) refreshTools(s *server, generation int) {
+	m.mu.Lock()
+	client := s.client
+	m.mu.Unlock()
+	if client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ConnectTimeout)
+	defer cancel()
+	serverTools, err := client.ListTools(ctx)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.generation == generation && s.state == StateConnected {
+		m.unregister(s)
+		m.register(s, serverTools)
+	}
+}
+
+// disconnect removes the server's tools and returns its client for the caller to close; the caller holds m.mu
+func (m *Manager) disconnect(s *server) *Client {
+	s.generation++
+	m.unregister(s)
+	client := s.client
+	s.client = nil
+	if s.state != StateDisabled {
+		s.state = StateConnecting
+	}
+	return client
+}
+
+// register adds the tools of a server to the tool registry; the caller holds m.mu. A tool whose name is
+// taken, by another tool of the server or of another server, is left out rather than replacing it.
+func (m *Manager) register(s *server, serverTools []Tool) {
+	client := s.client
+	s.tools, s.warnings = nil, nil
+	for _, tool := range serverTools {
+		name := ToolName(s.name, tool.Name)
+		if _, taken := tools.Lookup(name); taken {
+			s.warnings = append(s.warnings, fmt.Sprintf("%s was left out, another tool is already named %s", tool.Name, name))
+			continue
+		}
+		s.tools = append(s.tools, tool)
+		title := tool.Title
+		if title == "" {
+			title = tool.Name
+		}
+		description := tool.Description
+		if description == "" {
+			description = title
+		}
+
+		tools.Register(tools.Spec[json.RawMessage, CallResult]{
+			Name:        name,
+			Description: fmt.Sprintf("%s (from the %s MCP server)", description, s.name),
+			Parameters:  parseSchema(tool.InputSchema),
+			Schema:      modelSchema(tool.InputSchema),
+			// Read-only hints come from the server, which isn't trusted to judge its own tools
+			Class: tools.Mutating,
+			Handler: func(ctx context.Context, arguments json.RawMessage) (CallResult, tools.ToolError) {
+				result, err := client.CallTool(ctx, tool.Name, arguments)
+				if err != nil {
+					return CallResult{}, tools.ToolError{
+						Success: false,
+						Message: fmt.Sprintf("Error calling %s on the %s MCP server: %s", tool.Name, s.name, err.Error()),
+						Err:     err,
+					}
+				}
+				if result.IsError {
+					message := result.Text()
+					if message == "" {
+						message = fmt.Sprintf("%s failed without saying why", tool.Name)
+					}
+					return CallResult{}, tools.ToolError{
+						Success: false,
+						Message: message,
+						Err:     errors.New(message),
+					}
+				}
+				return result, tools.ToolError{}
+			},
+			Display: func(arguments json.RawMessage) (string, string) {
+				return s.name + ": " + title, summarize(arguments)
+			},
+		})
+		s.registered = append(s.registered, name)
+	}
+}
+
+// unregister removes the tools of a server from the tool registry; the caller holds m.mu
+func (m *Manager) unregister(s *server) {
+	for _, name := range s.registered {
+		tools.Unregister(name)
+	}
+	s.registered, s.tools, s.warnings = nil, nil, nil
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// maxToolName is the longest tool name the providers accept
+const maxToolName = 64
+
+// ToolName returns the name a server's tool is registered under, e.g. mcp__github__create_issue. Providers
+// only accept letters, digits, _ and - in names of at most 64 characters; longer names are cut and end in
+// a hash of the whole name, so tools that only differ at the end keep different names.
+func ToolName(serverName string, toolName string) string {
+	name := "mcp__" + serverName + "__" + unsafeNameChars.ReplaceAllString(toolName, "_")
+	if len(name) > maxToolName {
+		sum := sha256.Sum256([]byte(serverName + "\x00" + toolName))
+		suffix := "_" + hex.EncodeToString(sum[:4])
+		name = name[:maxToolName-len(suffix)] + suffix
+	}
+	return name
+}
+
+// summarize shows the arguments of a call on one line, shortened when long
+func summarize(arguments json.RawMessage) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, arguments); err != nil {
+		return string(arguments)
+	}
+	text := compact.String()
+	if text == "{}" {
+		return ""
+	}
+	if len(text) > 120 {
+		text = text[:117] + "..."
+	}
+	return text
+}
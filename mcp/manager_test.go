@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+func TestManager(t *testing.T) {
+	httpServer := httptest.NewServer(&stub{})
+	defer httpServer.Close()
+
+	m := NewManager(map[string]config.MCPServer{
+		"local":  stdioStub("serve"),
+		"remote": {URL: httpServer.URL + "/mcp"},
+		"broken": stdioStub("crash"),
+		"off":    {Command: "/nonexistent/nyron-mcp-stub", Disabled: true},
+	})
+	defer m.Close()
+	m.Start()
+	m.Wait()
+
+	servers := map[string]Server{}
+	for _, server := range m.Servers() {
+		servers[server.Name] = server
+	}
+	for name, want := range map[string]State{"local": StateConnected, "remote": StateConnected, "broken": StateFailed, "off": StateDisabled} {
+		if got := servers[name].State; got != want {
+			t.Errorf("%s is %s, want %s (%v)", name, got, want, servers[name].Err)
+		}
+	}
+	if err := servers["broken"].Err; err == nil || !strings.Contains(err.Error(), "stub: no API token") {
+		t.Errorf("broken failed with %v, want the server's stderr", err)
+	}
+	if got := toolNames(servers["remote"].Tools); got != "echo fail change quit" {
+		t.Errorf("remote has tools %q", got)
+	}
+
+	if response := execute(t, "mcp__local__echo", `{"text":"hi there"}`); !strings.Contains(response, "hi there") {
+		t.Errorf("echo returned %s", response)
+	}
+	if message := toolError(t, "mcp__remote__fail", `{}`); message != "the stub failed on purpose" {
+		t.Errorf("fail returned the error %q", message)
+	}
+	if message := toolError(t, "mcp__local__echo", `{}`); !strings.Contains(message, "text is required") {
+		t.Errorf("echo without its required argument returned %q", message)
+	}
+
+	// The server says its tools changed, and the manager lists them again
+	execute(t, "mcp__remote__change", `{}`)
+	eventually(t, "the added tool is registered", func() bool {
+		_, ok := tools.Lookup("mcp__remote__added")
+		return ok
+	})
+
+	// A server that exits takes its tools with it, and can be reconnected
+	execute(t, "mcp__local__quit", `{}`)
+	eventually(t, "local fails after it exits", func() bool {
+		return serverState(m, "local") == StateFailed
+	})
+	if _, ok := tools.Lookup("mcp__local__echo"); ok {
+		t.Error("the tools of the exited server are still registered")
+	}
+	m.Reconnect("local")
+	m.Wait()
+	if state := serverState(m, "local"); state != StateConnected {
+		t.Errorf("local is %s after Reconnect", state)
+	}
+
+	m.Close()
+	for _, name := range []string{"mcp__local__echo", "mcp__remote__echo", "mcp__remote__added"} {
+		if _, ok := tools.Lookup(name); ok {
+			t.Errorf("%s is still registered after Close", name)
+		}
+	}
+}
+
+// execute calls a registered tool and returns its JSON response
+func execute(t *testing.T, name string, arguments string) string {
+	t.Helper()
+	if _, ok := tools.Lookup(name); !ok {
+		t.Fatalf("%s isn't registered", name)
+	}
+	return tools.ExecuteTool(context.Background(), name, arguments)
+}
+
+// toolError calls a registered tool and returns the message of the error it reported
+func toolError(t *testing.T, name string, arguments string) string {
+	t.Helper()
+	var response struct {
+		Error struct {
+			Message string
+		}
+	}
+	if err := json.Unmarshal([]byte(execute(t, name, arguments)), &response); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return response.Error.Message
+}
+
+func serverState(m *Manager, name string) State {
+	for _, server := range m.Servers() {
+		if server.Name == name {
+			return server.State
+		}
+	}
+	return ""
+}
+
+// eventually waits for a condition that is met in the background
+func eventually(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestToolName(t *testing.T) {
+	long := strings.Repeat("x", 60)
+	tests := []struct {
+		server, tool string
+		want         string
+	}{
+		{"github", "create_issue", "mcp__github__create_issue"},
+		{"db", "run.query v2", "mcp__db__run_query_v2"},
+		{"s", strings.Repeat("y", 55), "mcp__s__" + strings.Repeat("y", 55)},
+	}
+	for _, test := range tests {
+		if got := ToolName(test.server, test.tool); got != test.want {
+			t.Errorf("ToolName(%q, %q) = %q, want %q", test.server, test.tool, got, test.want)
+		}
+	}
+
+	first, second := ToolName("docs", long+"_first"), ToolName("docs", long+"_second")
+	if first == second {
+		t.Errorf("long names with the same start both became %q", first)
+	}
+	for _, name := range []string{first, second} {
+		if len(name) != maxToolName || !strings.HasPrefix(name, "mcp__docs__xxxx") {
+			t.Errorf("%q should keep its start and be cut to %d characters", name, maxToolName)
+		}
+	}
+	if ToolName("docs", long+"_first") != first {
+		t.Error("ToolName isn't stable")
+	}
+}
+
+func TestRegisterLeavesOutTakenNames(t *testing.T) {
+	m := NewManager(nil)
+	db := &server{name: "db"}
+	nested := &server{name: "db__run"}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.register(db, []Tool{{Name: "run.query"}, {Name: "run_query"}, {Name: "run__query"}, {Name: "schema"}})
+	m.register(nested, []Tool{{Name: "query"}, {Name: "explain"}})
+	defer m.unregister(db)
+	defer m.unregister(nested)
+
+	if got := toolNames(db.tools); got != "run.query run__query schema" {
+		t.Errorf("db registered %q, want run.query run__query schema", got)
+	}
+	if got := toolNames(nested.tools); got != "explain" {
+		t.Errorf("db__run registered %q, want explain", got)
+	}
+	for s, taken := range map[*server]string{db: "mcp__db__run_query", nested: "mcp__db__run__query"} {
+		if len(s.warnings) != 1 || !strings.HasSuffix(s.warnings[0], "already named "+taken) {
+			t.Errorf("%s warned %q, want the taken name %s", s.name, s.warnings, taken)
+		}
+	}
+	if definition, ok := tools.Lookup("mcp__db__run__query"); !ok || !strings.Contains(definition.Tool.Function.Description, "the db MCP server") {
+		t.Error("the first tool with a name didn't keep it")
+	}
+}
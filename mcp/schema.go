@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"encoding/json"
+
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+// emptySchema is the input schema of a tool without arguments
+var emptySchema = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// modelSchema returns the input schema of a tool as it is sent to the model. $schema is dropped, since some
+// providers reject schemas that name their draft.
+func modelSchema(raw json.RawMessage) json.RawMessage {
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil || schema == nil {
+		return emptySchema
+	}
+	delete(schema, "$schema")
+	if _, ok := schema["type"]; !ok {
+		schema["type"] = "object"
+	}
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		return emptySchema
+	}
+	return encoded
+}
+
+// parseSchema reads an input schema into the definition the arguments are checked against. Parts that
+// jsonschema.Definition can't hold, such as anyOf with different types, accept any value; the server
+// checks them itself.
+func parseSchema(raw json.RawMessage) jsonschema.Definition {
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil || schema == nil {
+		return jsonschema.Definition{Type: jsonschema.Object, AdditionalProperties: true}
+	}
+	// Tool arguments are always an object
+	schema["type"] = "object"
+	return convertSchema(schema)
+}
+
+func convertSchema(schema map[string]any) jsonschema.Definition {
+	var definition jsonschema.Definition
+	definition.Description, _ = schema["description"].(string)
+
+	switch value := schema["type"].(type) {
+	case string:
+		definition.Type = jsonschema.DataType(value)
+	case []any:
+		// e.g. ["string", "null"]; a choice between several real types is left unchecked
+		var types []string
+		for _, item := range value {
+			if name, ok := item.(string); ok && name == "null" {
+				definition.Nullable = true
+			} else if ok {
+				types = append(types, name)
+			}
+		}
+		if len(types) == 1 {
+			definition.Type = jsonschema.DataType(types[0])
+		}
+	}
+
+	// anyOf and oneOf of one type and null are how optional values are often written
+	for _, key := range []string{"anyOf", "oneOf"} {
+		alternatives, ok := schema[key].([]any)
+		if !ok || definition.Type != "" {
+			continue
+		}
+		var others []map[string]any
+		for _, alternative := range alternatives {
+			alternative, _ := alternative.(map[string]any)
+			if alternative["type"] == "null" {
+				definition.Nullable = true
+			} else if alternative != nil {
+				others = append(others, alternative)
+			}
+		}
+		if len(others) == 1 {
+			converted := convertSchema(others[0])
+			converted.Nullable = definition.Nullable
+			if converted.Description == "" {
+				converted.Description = definition.Description
+			}
+			definition = converted
+		}
+	}
+
+	if values, ok := schema["enum"].([]any); ok {
+		enum := make([]string, 0, len(values))
+		for _, value := range values {
+			if text, ok := value.(string); ok {
+				enum = append(enum, text)
+			}
+		}
+		// Enums of numbers or mixed values can't be held, so they aren't checked
+		if len(enum) == len(values) {
+			definition.Enum = enum
+		}
+	}
+
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		if definition.Type == "" {
+			definition.Type = jsonschema.Object
+		}
+		definition.Properties = map[string]jsonschema.Definition{}
+		for name, property := range properties {
+			property, _ := property.(map[string]any)
+			definition.Properties[name] = convertSchema(property)
+		}
+	}
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				definition.Required = append(definition.Required, name)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		converted := convertSchema(items)
+		definition.Items = &converted
+	}
+
+	switch additional := schema["additionalProperties"].(type) {
+	case bool:
+		definition.AdditionalProperties = additional
+	case map[string]any:
+		definition.AdditionalProperties = convertSchema(additional)
+	default:
+		// JSON Schema allows other properties unless it says otherwise, so the server may take more than it lists
+		if definition.Type == jsonschema.Object {
+			definition.AdditionalProperties = true
+		}
+	}
+	return definition
+}
@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/revrost/go-openrouter/jsonschema"
+)
+
+func TestParseSchemaAllowsUnlistedProperties(t *testing.T) {
+	definition := parseSchema(json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string"},
+			"filter": {"type": "object", "properties": {"lang": {"type": "string"}}},
+			"strict": {"type": "object", "properties": {"a": {"type": "string"}}, "additionalProperties": false}
+		},
+		"required": ["query"]
+	}`))
+
+	checks := map[string]any{
+		"the arguments":             definition.AdditionalProperties,
+		"a nested object":           definition.Properties["filter"].AdditionalProperties,
+		"an object that says false": definition.Properties["strict"].AdditionalProperties,
+		"a string":                  definition.Properties["query"].AdditionalProperties,
+	}
+	want := map[string]any{
+		"the arguments":             true,
+		"a nested object":           true,
+		"an object that says false": false,
+		"a string":                  nil,
+	}
+	for name, got := range checks {
+		if got != want[name] {
+			t.Errorf("additionalProperties of %s = %v, want %v", name, got, want[name])
+		}
+	}
+}
+
+func TestUnlistedArgumentsReachTheServer(t *testing.T) {
+	const name = "mcp__schema_test__search"
+	tools.Register(tools.Spec[map[string]any, string]{
+		Name: name,
+		Parameters: parseSchema(json.RawMessage(`{
+			"type": "object",
+			"properties": {"query": {"type": "string"}, "filter": {"type": "object", "properties": {"lang": {"type": "string"}}}},
+			"required": ["query"]
+		}`)),
+		Class: tools.ReadOnly,
+		Handler: func(ctx context.Context, params map[string]any) (string, tools.ToolError) {
+			return "ok", tools.ToolError{Success: true}
+		},
+	})
+	defer tools.Unregister(name)
+
+	if message := toolError(t, name, `{"query":"x","limit":5,"filter":{"lang":"go","since":"2024"}}`); message != "" {
+		t.Errorf("arguments the schema doesn't list were refused: %s", message)
+	}
+	// Listed properties are still checked
+	if message := toolError(t, name, `{"query":5}`); !strings.Contains(message, "query") {
+		t.Errorf("a number for the query string returned %q", message)
+	}
+}
+
+func TestParseSchemaOfInvalidJSON(t *testing.T) {
+	definition := parseSchema(json.RawMessage(`not json`))
+	if definition.Type != jsonschema.Object || definition.AdditionalProperties != true {
+		t.Errorf("invalid schema parsed as %+v, want an object that takes anything", definition)
+	}
+}
@@ -0,0 +1,255 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+)
+
+// supportedVersions are the MCP revisions Serve can answer in, newest first
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// toolServer answers the requests of one MCP client with the tools of the registry
+type toolServer struct {
+	permissions   *permission.Manager
+	allowMutating bool
+
+	writeMu sync.Mutex
+	out     io.Writer
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc // Tool calls in flight by request ID, so the client can cancel them
+	wg      sync.WaitGroup
+}
+
+// serverTool is a tool as listed to a client
+type serverTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema any             `json:"inputSchema"`
+	Annotations toolAnnotations `json:"annotations"`
+}
+
+type toolAnnotations struct {
+	ReadOnlyHint bool `json:"readOnlyHint"`
+}
+
+// Serve answers MCP requests read from in, one JSON message per line, until in ends. Nobody can be asked for
+// approval, so like headless mode only the read-only tools the permissions allow are offered. allowMutating
+// also offers the tools that would ask, run_command and the file tools among them, for clients that confirm
+// calls with their own user; dangerous shell commands still never run. Paths outside the workspace are
+// refused unless the outside-root policy allows them.
+func Serve(ctx context.Context, in io.Reader, out io.Writer, permissions *permission.Manager, allowMutating bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &toolServer{permissions: permissions, allowMutating: allowMutating, out: out, running: map[string]context.CancelFunc{}}
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var msg message
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			s.reply(message{ID: json.RawMessage("null"), Error: &RPCError{Code: codeParseError, Message: "invalid JSON: " + err.Error()}})
+			continue
+		}
+		s.handle(ctx, msg)
+	}
+	// Calls in flight still answer, the client may only have closed its side for writing
+	s.wg.Wait()
+	return scanner.Err()
+}
+
+// handle answers one message; tool calls run in the background so they can be cancelled
+func (s *toolServer) handle(ctx context.Context, msg message) {
+	if msg.Method == "" {
+		// A response, but the server sends no requests
+		return
+	}
+	if msg.ID == nil {
+		if msg.Method == "notifications/cancelled" {
+			var params struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			if json.Unmarshal(msg.Params, &params) == nil {
+				s.cancel(string(params.RequestID))
+			}
+		}
+		return
+	}
+
+	switch msg.Method {
+	case "initialize":
+		s.respond(msg.ID, s.initialize(msg.Params), nil)
+	case "ping":
+		s.respond(msg.ID, struct{}{}, nil)
+	case "tools/list":
+		s.respond(msg.ID, map[string]any{"tools": s.listTools()}, nil)
+	case "tools/call":
+		callCtx, cancel := context.WithCancel(ctx)
+		key := string(msg.ID)
+		s.mu.Lock()
+		s.running[key] = cancel
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.cancel(key)
+			result, err := s.callTool(callCtx, msg.Params)
+			s.respond(msg.ID, result, err)
+		}()
+	default:
+		s.respond(msg.ID, nil, &RPCError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method})
+	}
+}
+
+func (s *toolServer) initialize(params json.RawMessage) map[string]any {
+	var request struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(params, &request)
+	// A client asking for a revision this server doesn't know gets the newest, and decides whether to go on
+	version := ProtocolVersion
+	if slices.Contains(supportedVersions, request.ProtocolVersion) {
+		version = request.ProtocolVersion
+	}
+
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]string{"name": "nyron", "version": buildVersion()},
+		"instructions": fmt.Sprintf("File tools work inside %s; relative paths resolve against it.",
+			tools.CurrentWorkspace().Root()),
+	}
+}
+
+// listTools returns the registered tools that are offered
+func (s *toolServer) listTools() []serverTool {
+	var listed []serverTool
+	for _, tool := range tools.GetAllTools() {
+		if tool.Function == nil || !s.offers(tool.Function.Name) {
+			continue
+		}
+		listed = append(listed, serverTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: tool.Function.Parameters,
+			Annotations: toolAnnotations{ReadOnlyHint: tools.IsReadOnly(tool.Function.Name)},
+		})
+	}
+	return listed
+}
+
+// offers reports whether a tool is served; tools of other MCP servers are never passed on
+func (s *toolServer) offers(name string) bool {
+	if _, ok := tools.Lookup(name); !ok || strings.HasPrefix(name, "mcp__") {
+		return false
+	}
+	switch s.permissions.Check(name) {
+	case permission.PolicyDeny:
+		return false
+	case permission.PolicyAllow:
+		return s.allowMutating || tools.IsReadOnly(name)
+	default:
+		return s.allowMutating
+	}
+}
+
+// callTool runs a tool and returns the JSON result the model would get as text content
+func (s *toolServer) callTool(ctx context.Context, params json.RawMessage) (CallResult, *RPCError) {
+	var request struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &request); err != nil {
+		return CallResult{}, &RPCError{Code: codeInvalidParams, Message: "invalid tools/call parameters: " + err.Error()}
+	}
+	if !s.offers(request.Name) {
+		return CallResult{}, &RPCError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", request.Name)}
+	}
+	arguments := string(request.Arguments)
+	if arguments == "" || arguments == "null" {
+		arguments = "{}"
+	}
+
+	if request.Name == permission.RunCommandTool {
+		var command tools.RunCommandParams
+		_ = json.Unmarshal([]byte(arguments), &command)
+		if permission.IsDangerousCommand(command.Command) {
+			return textResult(tools.ToolResponse{Error: tools.ToolError{
+				Success: false,
+				Message: "Dangerous commands need interactive approval in nyron and can't run over MCP",
+			}}), nil
+		}
+	}
+
+	// With the deny or ask policy the tool refuses the paths itself, as there is nobody to ask
+	outside := tools.CurrentWorkspace().OutsidePaths(ctx, request.Name, arguments)
+	if len(outside) > 0 && s.permissions.CheckOutsideRoot() == permission.PolicyAllow {
+		ctx = tools.WithGrantedPaths(ctx, outside)
+	}
+
+	result := tools.ExecuteTool(ctx, request.Name, arguments)
+	var response struct {
+		Error struct {
+			Message string
+		}
+	}
+	_ = json.Unmarshal([]byte(result), &response)
+	return CallResult{
+		Content: []Content{{Type: "text", Text: result}},
+		IsError: response.Error.Message != "",
+	}, nil
+}
+
+// textResult returns a tool response as the text content of a failed result
+func textResult(response tools.ToolResponse) CallResult {
+	encoded, _ := json.Marshal(response)
+	return CallResult{Content: []Content{{Type: "text", Text: string(encoded)}}, IsError: true}
+}
+
+func (s *toolServer) cancel(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.running[key]; ok {
+		cancel()
+		delete(s.running, key)
+	}
+}
+
+// respond answers a request with a result or an error
+func (s *toolServer) respond(id json.RawMessage, result any, rpcErr *RPCError) {
+	msg := message{ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			msg.Error = &RPCError{Code: codeInternalError, Message: err.Error()}
+		} else {
+			msg.Result = encoded
+		}
+	}
+	s.reply(msg)
+}
+
+func (s *toolServer) reply(msg message) {
+	msg.JSONRPC = "2.0"
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, _ = s.out.Write(append(encoded, '\n'))
+}
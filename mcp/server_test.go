@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+)
+
+// serve sends requests to Serve and returns the responses by request ID
+func serve(t *testing.T, permissions *permission.Manager, allowMutating bool, requests ...string) map[string]message {
+	t.Helper()
+	var out bytes.Buffer
+	in := strings.NewReader(strings.Join(requests, "\n") + "\n")
+	if err := Serve(context.Background(), in, &out, permissions, allowMutating); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	responses := map[string]message{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var msg message
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatalf("Serve wrote %q: %v", line, err)
+		}
+		responses[string(msg.ID)] = msg
+	}
+	return responses
+}
+
+func TestServeInitialize(t *testing.T) {
+	responses := serve(t, permission.NewManager(nil), false,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+		`not json`,
+	)
+
+	for id, want := range map[string]string{"1": "2024-11-05", "2": ProtocolVersion} {
+		var result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+			ServerInfo      struct {
+				Name string `json:"name"`
+			} `json:"serverInfo"`
+		}
+		if err := json.Unmarshal(responses[id].Result, &result); err != nil {
+			t.Fatalf("initialize %s: %v", id, err)
+		}
+		if result.ProtocolVersion != want || result.ServerInfo.Name != "nyron" {
+			t.Errorf("initialize %s answered %+v, want version %s from nyron", id, result, want)
+		}
+	}
+	if response := responses["3"]; response.Error != nil || string(response.Result) != "{}" {
+		t.Errorf("ping answered %+v", response)
+	}
+	if response := responses["4"]; response.Error == nil || response.Error.Code != codeMethodNotFound {
+		t.Errorf("an unknown method answered %+v, want method not found", response)
+	}
+	if response := responses["null"]; response.Error == nil || response.Error.Code != codeParseError {
+		t.Errorf("invalid JSON answered %+v, want a parse error", response)
+	}
+}
+
+func TestServeOffersTools(t *testing.T) {
+	// Tools of other MCP servers are never passed on
+	tools.Register(tools.Spec[json.RawMessage, string]{Name: "mcp__other__lookup", Class: tools.ReadOnly})
+	defer tools.Unregister("mcp__other__lookup")
+
+	tests := []struct {
+		name          string
+		policies      map[string]permission.Policy
+		allowMutating bool
+		offered       []string
+		hidden        []string
+	}{
+		{
+			name:    "read-only tools by default",
+			offered: []string{"read_file", "grep", "list_directory"},
+			hidden:  []string{"write_content", "edit_content", "run_command", "mcp__other__lookup"},
+		},
+		{
+			name:     "mutating tools need the flag even when allowed",
+			policies: map[string]permission.Policy{"write_content": permission.PolicyAllow, "grep": permission.PolicyAsk},
+			offered:  []string{"read_file"},
+			hidden:   []string{"write_content", "grep", "run_command"},
+		},
+		{
+			name:          "allow mutating",
+			allowMutating: true,
+			offered:       []string{"read_file", "write_content", "edit_content", "run_command"},
+			hidden:        []string{"mcp__other__lookup"},
+		},
+		{
+			name:          "deny wins over allow mutating",
+			policies:      map[string]permission.Policy{"run_command": permission.PolicyDeny, "read_file": permission.PolicyDeny},
+			allowMutating: true,
+			offered:       []string{"write_content"},
+			hidden:        []string{"run_command", "read_file"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			responses := serve(t, permission.NewManager(test.policies), test.allowMutating,
+				`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+				`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"`+test.hidden[0]+`","arguments":{}}}`,
+			)
+
+			var result struct {
+				Tools []serverTool `json:"tools"`
+			}
+			if err := json.Unmarshal(responses["1"].Result, &result); err != nil {
+				t.Fatalf("tools/list: %v", err)
+			}
+			listed := map[string]bool{}
+			for _, tool := range result.Tools {
+				listed[tool.Name] = true
+			}
+			for _, name := range test.offered {
+				if !listed[name] {
+					t.Errorf("%s isn't offered", name)
+				}
+			}
+			for _, name := range test.hidden {
+				if listed[name] {
+					t.Errorf("%s is offered", name)
+				}
+			}
+			if response := responses["2"]; response.Error == nil || !strings.Contains(response.Error.Message, "unknown tool") {
+				t.Errorf("calling %s answered %+v, want unknown tool", test.hidden[0], response)
+			}
+		})
+	}
+}
+
+func TestServeCallsTools(t *testing.T) {
+	responses := serve(t, permission.NewManager(nil), true,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"read_file","arguments":{"FilePath":"server.go"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"read_file","arguments":{"Path":"server.go"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"run_command","arguments":{"Command":"sudo true"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"read_file","arguments":{"FilePath":"/etc/hostname"}}}`,
+	)
+
+	results := map[string]CallResult{}
+	for id, response := range responses {
+		var result CallResult
+		if err := json.Unmarshal(response.Result, &result); err != nil || response.Error != nil {
+			t.Fatalf("call %s answered %+v, %v", id, response, err)
+		}
+		results[id] = result
+	}
+	if result := results["1"]; result.IsError || !strings.Contains(result.Text(), "package mcp") {
+		t.Errorf("read_file returned %+v", result)
+	}
+	if result := results["2"]; !result.IsError || !strings.Contains(result.Text(), "FilePath is required") {
+		t.Errorf("read_file with the wrong argument returned %+v, want the schema problem", result)
+	}
+	if result := results["3"]; !result.IsError || !strings.Contains(result.Text(), "Dangerous commands") {
+		t.Errorf("a dangerous command returned %+v, want it refused", result)
+	}
+	if result := results["4"]; !result.IsError {
+		t.Errorf("reading outside the workspace returned %+v, want it refused", result)
+	}
+}
@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stderrLines is how much of a server's stderr is kept to explain why it failed
+const stderrLines = 20
+
+// stdioTransport runs a server as a child process that reads messages from stdin and writes them to stdout,
+// one JSON object per line
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *tail
+	done   chan struct{} // Closed once the process has exited
+
+	writeMu sync.Mutex
+}
+
+// startStdio starts the server and makes it the transport of c
+func startStdio(c *conn, command string, args []string, env map[string]string) error {
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	for name, value := range env {
+		cmd.Env = append(cmd.Env, name+"="+os.ExpandEnv(value))
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	t := &stdioTransport{cmd: cmd, stdin: stdin, stderr: &tail{}, done: make(chan struct{})}
+	cmd.Stderr = t.stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", command, err)
+	}
+	c.transport = t
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		// Tool results can be large, e.g. a whole file
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var msg message
+			if err := json.Unmarshal([]byte(line), &msg); err != nil {
+				// Servers sometimes log to stdout; the line is kept with stderr rather than breaking the connection
+				t.stderr.Write([]byte(line + "\n"))
+				continue
+			}
+			c.receive(msg)
+		}
+
+		err := cmd.Wait()
+		close(t.done)
+		switch {
+		case err != nil && t.stderr.String() != "":
+			c.fail(fmt.Errorf("the server exited: %w\n%s", err, t.stderr.String()))
+		case err != nil:
+			c.fail(fmt.Errorf("the server exited: %w", err))
+		default:
+			c.fail(fmt.Errorf("the server exited"))
+		}
+	}()
+	return nil
+}
+
+func (t *stdioTransport) send(ctx context.Context, msg message) error {
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(encoded, '\n')); err != nil {
+		return fmt.Errorf("writing to the server: %w", err)
+	}
+	return nil
+}
+
+// close closes stdin, which tells the server to exit, and kills it if it doesn't within a few seconds
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+	select {
+	case <-t.done:
+	case <-time.After(3 * time.Second):
+		_ = t.cmd.Process.Kill()
+		<-t.done
+	}
+	return nil
+}
+
+// tail keeps the last lines written to it
+type tail struct {
+	mu    sync.Mutex
+	lines []string
+	part  string
+}
+
+func (t *tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(t.part+string(p), "\n")
+	t.part = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		t.lines = append(t.lines, line)
+	}
+	if len(t.lines) > stderrLines {
+		t.lines = t.lines[len(t.lines)-stderrLines:]
+	}
+	return len(p), nil
+}
+
+func (t *tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := t.lines
+	if t.part != "" {
+		lines = append(lines[:len(lines):len(lines)], t.part)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
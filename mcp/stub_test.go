@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// stubEnv makes the test binary run as a stub MCP server over stdio instead of running the tests
+const stubEnv = "NYRON_MCP_STUB"
+
+func TestMain(m *testing.M) {
+	if mode, ok := os.LookupEnv(stubEnv); ok {
+		os.Exit(runStdioStub(mode))
+	}
+	os.Exit(m.Run())
+}
+
+// stdioStub returns the settings that start the test binary as a stub server
+func stdioStub(mode string) config.MCPServer {
+	return config.MCPServer{Command: os.Args[0], Env: map[string]string{stubEnv: mode}}
+}
+
+// runStdioStub serves the stub over stdin and stdout. In "crash" mode it fails before answering, in
+// "noisy" mode it logs to stdout first.
+func runStdioStub(mode string) int {
+	switch mode {
+	case "crash":
+		fmt.Fprintln(os.Stderr, "stub: no API token")
+		return 1
+	case "noisy":
+		fmt.Println("stub starting up")
+	}
+
+	s := &stub{}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg message
+		if json.Unmarshal(scanner.Bytes(), &msg) != nil {
+			continue
+		}
+		replies, quit := s.handle(msg)
+		for _, reply := range replies {
+			encoded, _ := json.Marshal(reply)
+			fmt.Println(string(encoded))
+		}
+		if quit {
+			return 0
+		}
+	}
+	return 0
+}
+
+// stub is a small MCP server. It offers echo, fail and quit over two pages of tools/list, and change,
+// which adds the tool "added" and says the tools changed.
+type stub struct {
+	mu      sync.Mutex
+	changed bool
+
+	// For the HTTP transports
+	sessionEnded bool
+	events       chan message // Messages for the SSE stream
+}
+
+// handle answers one message with the messages to send back, notifications first; quit is true when the
+// server should exit after sending them
+func (s *stub) handle(msg message) (replies []message, quit bool) {
+	if msg.ID == nil {
+		return nil, false
+	}
+	reply := func(result any) message {
+		encoded, _ := json.Marshal(result)
+		return message{JSONRPC: "2.0", ID: msg.ID, Result: encoded}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		return []message{reply(map[string]any{
+			"protocolVersion": params.ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{"listChanged": true}},
+			"serverInfo":      map[string]string{"name": "stub", "version": "1.2.3"},
+			"instructions":    "Call echo to test the connection.",
+		})}, false
+
+	case "tools/list":
+		var params struct {
+			Cursor string `json:"cursor"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		schema := json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`)
+		if params.Cursor == "" {
+			return []message{reply(map[string]any{
+				"tools": []Tool{
+					{Name: "echo", Description: "Returns the text", InputSchema: schema},
+					{Name: "fail", Description: "Always fails"},
+					{Name: "change", Description: "Adds a tool"},
+				},
+				"nextCursor": "page-2",
+			})}, false
+		}
+		page := []Tool{{Name: "quit", Description: "Stops the server"}}
+		s.mu.Lock()
+		if s.changed {
+			page = append(page, Tool{Name: "added", Description: "Added by change"})
+		}
+		s.mu.Unlock()
+		return []message{reply(map[string]any{"tools": page})}, false
+
+	case "tools/call":
+		var params struct {
+			Name      string `json:"name"`
+			Arguments struct {
+				Text string `json:"text"`
+			} `json:"arguments"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		text := func(text string, isError bool) message {
+			return reply(CallResult{Content: []Content{{Type: "text", Text: text}}, IsError: isError})
+		}
+		switch params.Name {
+		case "echo":
+			return []message{text(params.Arguments.Text, false)}, false
+		case "fail":
+			return []message{text("the stub failed on purpose", true)}, false
+		case "change":
+			s.mu.Lock()
+			s.changed = true
+			s.mu.Unlock()
+			return []message{
+				{JSONRPC: "2.0", Method: "notifications/tools/list_changed"},
+				text("added a tool", false),
+			}, false
+		case "quit":
+			return []message{text("bye", false)}, true
+		}
+		return []message{{JSONRPC: "2.0", ID: msg.ID, Error: &RPCError{Code: codeInvalidParams, Message: "unknown tool " + params.Name}}}, false
+	}
+	return []message{{JSONRPC: "2.0", ID: msg.ID, Error: &RPCError{Code: codeMethodNotFound, Message: "method not found"}}}, false
+}
+
+// stubSession is the session ID the stub hands out over streamable HTTP
+const stubSession = "stub-session"
+
+// ServeHTTP speaks streamable HTTP on /mcp and the older HTTP+SSE transport on /sse and /messages
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/sse" && r.Method == http.MethodGet:
+		s.serveEvents(w, r)
+		return
+	case r.URL.Path == "/messages" && r.Method == http.MethodPost:
+		var msg message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		replies, _ := s.handle(msg)
+		for _, reply := range replies {
+			s.events <- reply
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	case r.URL.Path != "/mcp":
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		s.mu.Lock()
+		s.sessionEnded = r.Header.Get("Mcp-Session-Id") == stubSession
+		s.mu.Unlock()
+		return
+	}
+
+	var msg message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg.Method == "initialize" {
+		w.Header().Set("Mcp-Session-Id", stubSession)
+	} else if r.Header.Get("Mcp-Session-Id") != stubSession || r.Header.Get("MCP-Protocol-Version") != ProtocolVersion {
+		http.Error(w, "missing session or protocol version", http.StatusBadRequest)
+		return
+	}
+
+	replies, _ := s.handle(msg)
+	switch {
+	case len(replies) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case len(replies) == 1:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(replies[0])
+	default:
+		// Notifications go out on an event stream before the response
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, reply := range replies {
+			encoded, _ := json.Marshal(reply)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", encoded)
+		}
+	}
+}
+
+// serveEvents is the event stream of the HTTP+SSE transport, which starts with the endpoint to POST to
+func (s *stub) serveEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, "event: endpoint\ndata: /messages?session=1\n\n")
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case msg := <-s.events:
+			encoded, _ := json.Marshal(msg)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", strings.TrimSpace(string(encoded)))
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
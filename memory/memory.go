@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+)
+
+// FileNames are the project memory files, from lowest to highest precedence within a directory
+var FileNames = []string{"CRUSH.md", "AGENTS.md", "NYRON.md"}
+
+// maxFileSize caps how much of one memory file goes into the prompt
+const maxFileSize = 32 * 1024
+
+// Scope tells where a memory file applies
+type Scope string
+
+const (
+	ScopeGlobal  Scope = "global"  // Every project of the user
+	ScopeProject Scope = "project" // The directory it is in and everything below it
+)
+
+// File is a memory file that was found
+type File struct {
+	Path      string
+	Scope     Scope
+	Content   string
+	Truncated bool // Content was cut at maxFileSize
+}
+
+// GlobalPath returns NYRON.md in the user's config directory, which a project's .env can't move
+func GlobalPath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "NYRON.md"), nil
+}
+
+// ProjectPath returns the memory file to edit for dir: the most important one that exists there, otherwise NYRON.md
+func ProjectPath(dir string) string {
+	for i := len(FileNames) - 1; i >= 0; i-- {
+		path := filepath.Join(dir, FileNames[i])
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return filepath.Join(dir, "NYRON.md")
+}
+
+// Discover returns the memory files for dir from lowest to highest precedence: the global file, then the
+// project files from the git root, or dir itself outside a repository, down to dir. Missing files are
+// skipped; files that can't be read are reported in the error while the others are still returned.
+func Discover(dir string) ([]File, error) {
+	var files []File
+	var errs []error
+	add := func(path string, scope Scope) {
+		file, err := read(path, scope)
+		if err != nil {
+			errs = append(errs, err)
+		} else if file != nil {
+			files = append(files, *file)
+		}
+	}
+
+	global, err := GlobalPath()
+	if err == nil {
+		add(global, ScopeGlobal)
+	}
+	for _, directory := range projectDirs(dir) {
+		for _, name := range FileNames {
+			// Working in the config directory itself, the global file is already in
+			if path := filepath.Join(directory, name); path != global {
+				add(path, ScopeProject)
+			}
+		}
+	}
+	return files, errors.Join(errs...)
+}
+
+// projectDirs returns the directories from the git root containing dir down to dir
+func projectDirs(dir string) []string {
+	dir = filepath.Clean(dir)
+	dirs := []string{dir}
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			// Not in a repository, only the directory itself counts
+			return []string{dir}
+		}
+		current = parent
+		dirs = append(dirs, current)
+	}
+
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return dirs
+}
+
+// read returns the memory file at path, nil when it doesn't exist or is empty
+func read(path string, scope Scope) (*File, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading memory file: %w", err)
+	}
+
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return nil, nil
+	}
+	file := &File{Path: path, Scope: scope, Content: content}
+	if len(file.Content) > maxFileSize {
+		file.Content = strings.ToValidUTF8(file.Content[:maxFileSize], "")
+		file.Truncated = true
+	}
+	return file, nil
+}
+
+// Prompt formats the files as a section of the system prompt, empty when there are none
+func Prompt(files []File) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("# Memory Files\n\n")
+	prompt.WriteString("The user keeps instructions for you in the files below, listed from lowest to highest precedence. ")
+	prompt.WriteString("When they disagree, follow the later file: project files override the user's global file, ")
+	prompt.WriteString("and files closer to the working directory override those further up.\n")
+	for _, file := range files {
+		fmt.Fprintf(&prompt, "\n## %s (%s)\n\n%s\n", file.Path, file.Scope, file.Content)
+		if file.Truncated {
+			prompt.WriteString("\n[The rest of this file was left out because it is too long]\n")
+		}
+	}
+	return prompt.String()
+}
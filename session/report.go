@@ -0,0 +1,160 @@
+package session
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ReportFormat is how a usage report is written
+type ReportFormat string
+
+const (
+	ReportCSV  ReportFormat = "csv"  // One row per model call, for spreadsheets
+	ReportJSON ReportFormat = "json" // Totals per directory and session
+)
+
+// ParseReportFormat converts a format name to a ReportFormat
+func ParseReportFormat(value string) (ReportFormat, error) {
+	switch format := ReportFormat(value); format {
+	case ReportCSV, ReportJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid report format %q, use csv or json", value)
+	}
+}
+
+// WriteReport writes the usage of the sessions, oldest first
+func WriteReport(w io.Writer, sessions []*Session, format ReportFormat) error {
+	sorted := append([]*Session(nil), sessions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	switch format {
+	case ReportCSV:
+		return writeCSVReport(w, sorted)
+	case ReportJSON:
+		return writeJSONReport(w, sorted)
+	default:
+		return fmt.Errorf("invalid report format %q", format)
+	}
+}
+
+func writeCSVReport(w io.Writer, sessions []*Session) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"session_id", "title", "directory", "time", "turn", "provider", "model",
+		"prompt_tokens", "completion_tokens", "reasoning_tokens", "cached_tokens", "cost_usd",
+	})
+	for _, s := range sessions {
+		for _, request := range s.Requests {
+			out.Write([]string{
+				s.ID,
+				s.reportTitle(),
+				s.Dir,
+				request.Time.Format(time.RFC3339),
+				strconv.Itoa(request.Turn),
+				request.Model.Provider,
+				request.Model.Model,
+				strconv.Itoa(request.PromptTokens),
+				strconv.Itoa(request.CompletionTokens),
+				strconv.Itoa(request.ReasoningTokens),
+				strconv.Itoa(request.CachedTokens),
+				reportCost(request.Usage),
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// reportCost is the cost column of a request, empty when its cost isn't known
+func reportCost(usage Usage) string {
+	if usage.CostUnknown {
+		return ""
+	}
+	return strconv.FormatFloat(usage.Cost, 'f', -1, 64)
+}
+
+// reportTitle is the title of a session, also of one that wasn't saved yet
+func (s *Session) reportTitle() string {
+	if s.Title == "" {
+		return s.DefaultTitle()
+	}
+	return s.Title
+}
+
+// reportUsage is Usage with the field names of the JSON report
+type reportUsage struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens"`
+	CachedTokens     int     `json:"cached_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CostEstimated    bool    `json:"cost_estimated,omitempty"` // Some of the cost comes from the model's price
+	CostUnknown      bool    `json:"cost_unknown,omitempty"`   // Some requests cost an unknown amount left out of cost_usd
+}
+
+func (r *reportUsage) add(usage Usage) {
+	r.Requests++
+	r.PromptTokens += usage.PromptTokens
+	r.CompletionTokens += usage.CompletionTokens
+	r.ReasoningTokens += usage.ReasoningTokens
+	r.CachedTokens += usage.CachedTokens
+	r.TotalTokens += usage.TotalTokens()
+	r.CostUSD += usage.Cost
+	r.CostEstimated = r.CostEstimated || usage.CostEstimated
+	r.CostUnknown = r.CostUnknown || usage.CostUnknown
+}
+
+type reportSession struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	reportUsage
+}
+
+type reportDirectory struct {
+	Directory string          `json:"directory"`
+	Sessions  []reportSession `json:"sessions"`
+	reportUsage
+}
+
+type report struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Directories []reportDirectory `json:"directories"`
+	reportUsage
+}
+
+func writeJSONReport(w io.Writer, sessions []*Session) error {
+	result := report{GeneratedAt: time.Now(), Directories: []reportDirectory{}}
+	directories := map[string]int{}
+	for _, s := range sessions {
+		index, ok := directories[s.Dir]
+		if !ok {
+			index = len(result.Directories)
+			directories[s.Dir] = index
+			result.Directories = append(result.Directories, reportDirectory{Directory: s.Dir})
+		}
+
+		entry := reportSession{ID: s.ID, Title: s.reportTitle(), CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt}
+		for _, request := range s.Requests {
+			entry.add(request.Usage)
+			result.Directories[index].add(request.Usage)
+			result.add(request.Usage)
+		}
+		result.Directories[index].Sessions = append(result.Directories[index].Sessions, entry)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,92 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// maxTitleLength caps titles derived from the first message
+const maxTitleLength = 60
+
+// Session is a conversation saved to disk: the transcript shown in the UI and the history sent to the model
+type Session struct {
+	ID        string
+	Title     string
+	Model     config.SelectedModel
+	Dir       string `json:",omitempty"` // The directory the session was started in, usage reports group by it
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Messages  []Message
+	History   []openrouter.ChatCompletionMessage
+	Requests  []Request `json:",omitempty"` // Usage of every model call
+}
+
+// Message is one entry of the transcript
+type Message struct {
+	Role      string // "user" or "assistant"
+	Content   string
+	Thinking  string     `json:",omitempty"`
+	ToolCalls []ToolCall `json:",omitempty"`
+	Cancelled bool       `json:",omitempty"`
+}
+
+// ToolCall is a tool call as it was shown in the transcript
+type ToolCall struct {
+	Step    string
+	Content string `json:",omitempty"`
+	Diff    string `json:",omitempty"`
+	Output  string `json:",omitempty"`
+	Done    bool   `json:",omitempty"`
+	Denied  bool   `json:",omitempty"`
+}
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// New returns an empty session for the given model
+func New(model config.SelectedModel) *Session {
+	now := time.Now()
+	dir, _ := os.Getwd()
+	return &Session{
+		ID:        newID(now),
+		Model:     model,
+		Dir:       dir,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsEmpty reports whether nothing was said in the session yet, such sessions aren't saved
+func (s *Session) IsEmpty() bool {
+	return len(s.Messages) == 0
+}
+
+// DefaultTitle derives a title from the first user message
+func (s *Session) DefaultTitle() string {
+	for _, message := range s.Messages {
+		if message.Role != RoleUser {
+			continue
+		}
+		title := strings.Join(strings.Fields(message.Content), " ")
+		if runes := []rune(title); len(runes) > maxTitleLength {
+			title = string(runes[:maxTitleLength-1]) + "…"
+		}
+		return title
+	}
+	return "New session"
+}
+
+// newID returns a sortable, unique session ID like 20250101-150405-1a2b3c
+func newID(now time.Time) string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,210 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned for a session ID that has no file
+var ErrNotFound = errors.New("session not found")
+
+// Summary is what the session browser shows without loading whole transcripts
+type Summary struct {
+	ID        string
+	Title     string
+	Model     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Messages  int
+}
+
+// Store keeps one JSON file per session in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store that keeps its sessions in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultDir returns $XDG_DATA_HOME/nyron/sessions, or ~/.local/share/nyron/sessions when XDG_DATA_HOME is not set
+func DefaultDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "nyron", "sessions"), nil
+}
+
+// NewDefaultStore returns a store in DefaultDir
+func NewDefaultStore() (*Store, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(dir), nil
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Save writes the session, replacing the previous file atomically so a crash can't leave half a session
+func (s *Store) Save(session *Session) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("creating session directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, session.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(session.ID)); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+	return nil
+}
+
+// Load reads a session by ID
+func (s *Store) Load(id string) (*Session, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("reading session %s: %w", id, err)
+	}
+	return &session, nil
+}
+
+// List returns the saved sessions, most recently updated first. Unreadable files are skipped.
+func (s *Store) List() ([]Summary, error) {
+	sessions, err := s.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []Summary
+	for _, session := range sessions {
+		summaries = append(summaries, Summary{
+			ID:        session.ID,
+			Title:     session.Title,
+			Model:     session.Model.Model,
+			CreatedAt: session.CreatedAt,
+			UpdatedAt: session.UpdatedAt,
+			Messages:  len(session.Messages),
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].UpdatedAt.After(summaries[j].UpdatedAt)
+	})
+	return summaries, nil
+}
+
+// LoadAll reads every saved session in no particular order. Unreadable files are skipped.
+func (s *Store) LoadAll() ([]*Session, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+
+	var sessions []*Session
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		session, err := s.Load(id)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Latest returns the most recently updated session started in dir
+func (s *Store) Latest(dir string) (*Session, error) {
+	sessions, err := s.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Session
+	for _, session := range sessions {
+		if session.Dir != dir {
+			continue
+		}
+		if latest == nil || session.UpdatedAt.After(latest.UpdatedAt) {
+			latest = session
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+// Rename changes the title of a saved session
+func (s *Store) Rename(id string, title string) error {
+	session, err := s.Load(id)
+	if err != nil {
+		return err
+	}
+	session.Title = strings.TrimSpace(title)
+	if session.Title == "" {
+		session.Title = session.DefaultTitle()
+	}
+	return s.Save(session)
+}
+
+// Delete removes a saved session
+func (s *Store) Delete(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return ErrNotFound
+	}
+	err := os.Remove(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
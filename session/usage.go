@@ -0,0 +1,107 @@
+package session
+
+import (
+	"time"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// Usage counts the tokens and the cost of one or more model calls
+type Usage struct {
+	PromptTokens     int     `json:",omitempty"`
+	CompletionTokens int     `json:",omitempty"`
+	ReasoningTokens  int     `json:",omitempty"` // Part of CompletionTokens
+	CachedTokens     int     `json:",omitempty"` // Part of PromptTokens
+	Cost             float64 `json:",omitempty"` // In USD
+	CostEstimated    bool    `json:",omitempty"` // Some of the cost was computed from the model's price, not reported
+	CostUnknown      bool    `json:",omitempty"` // Some calls cost an unknown amount that Cost leaves out
+}
+
+// UsageFrom converts the usage reported by a provider
+func UsageFrom(usage openrouter.Usage) Usage {
+	return Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		ReasoningTokens:  usage.CompletionTokenDetails.ReasoningTokens,
+		CachedTokens:     usage.PromptTokenDetails.CachedTokens,
+		Cost:             usage.Cost,
+	}
+}
+
+// price fills in the cost from the price of model when the provider didn't report one, which only OpenRouter
+// does. Cached tokens are counted at the full prompt price, so the estimate errs on the high side.
+func (u *Usage) price(model config.SelectedModel) {
+	if u.Cost > 0 || u.TotalTokens() == 0 {
+		return
+	}
+	info, ok := config.FindModel(model)
+	if !ok || info.Pricing == nil {
+		u.CostUnknown = true
+		return
+	}
+	u.Cost = (float64(u.PromptTokens)*info.Pricing.Prompt + float64(u.CompletionTokens)*info.Pricing.Completion) / 1_000_000
+	u.CostEstimated = u.Cost > 0
+}
+
+// Add adds other to the usage
+func (u *Usage) Add(other Usage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.CachedTokens += other.CachedTokens
+	u.Cost += other.Cost
+	u.CostEstimated = u.CostEstimated || other.CostEstimated
+	u.CostUnknown = u.CostUnknown || other.CostUnknown
+}
+
+// TotalTokens returns the prompt and completion tokens together
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// IsZero reports whether nothing was counted
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+// Request is the usage of one model call
+type Request struct {
+	Time  time.Time
+	Turn  int // The user message that led to the call, counted from 1
+	Model config.SelectedModel
+	Usage
+}
+
+// AddRequest records the usage of a model call made for the given turn, with its cost estimated from the
+// model's price when the provider didn't report it
+func (s *Session) AddRequest(turn int, model config.SelectedModel, usage openrouter.Usage) {
+	request := Request{
+		Time:  time.Now(),
+		Turn:  turn,
+		Model: model,
+		Usage: UsageFrom(usage),
+	}
+	request.price(model)
+	s.Requests = append(s.Requests, request)
+}
+
+// TotalUsage returns the usage of every model call in the session
+func (s *Session) TotalUsage() Usage {
+	var total Usage
+	for _, request := range s.Requests {
+		total.Add(request.Usage)
+	}
+	return total
+}
+
+// TurnUsage returns the usage of the model calls made for one turn
+func (s *Session) TurnUsage(turn int) Usage {
+	var total Usage
+	for _, request := range s.Requests {
+		if request.Turn == turn {
+			total.Add(request.Usage)
+		}
+	}
+	return total
+}
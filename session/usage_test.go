@@ -0,0 +1,54 @@
+package session
+
+import (
+	"math"
+	"testing"
+
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+func TestAddRequestCost(t *testing.T) {
+	sonnet := config.SelectedModel{Provider: config.ProviderAnthropic.ID, Model: "claude-sonnet-4-5"}
+	tests := []struct {
+		name      string
+		model     config.SelectedModel
+		usage     openrouter.Usage
+		cost      float64
+		estimated bool
+		unknown   bool
+	}{
+		{"reported", config.DefaultModel, openrouter.Usage{PromptTokens: 1000, CompletionTokens: 100, Cost: 0.5}, 0.5, false, false},
+		// $3 per million prompt tokens and $15 per million completion tokens
+		{"from the price", sonnet, openrouter.Usage{PromptTokens: 1_000_000, CompletionTokens: 200_000}, 6, true, false},
+		{"free", config.SelectedModel{Provider: config.ProviderOllama.ID, Model: "llama3.1"}, openrouter.Usage{PromptTokens: 1000}, 0, false, false},
+		{"not in the catalog", config.SelectedModel{Provider: config.ProviderOpenAI.ID, Model: "my-finetune"}, openrouter.Usage{PromptTokens: 1000}, 0, false, true},
+		{"no tokens", config.SelectedModel{Provider: config.ProviderOpenAI.ID, Model: "my-finetune"}, openrouter.Usage{}, 0, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var s Session
+			s.AddRequest(1, test.model, test.usage)
+			got := s.Requests[0].Usage
+			if math.Abs(got.Cost-test.cost) > 1e-9 || got.CostEstimated != test.estimated || got.CostUnknown != test.unknown {
+				t.Errorf("usage = %+v, want cost %v, estimated %v, unknown %v", got, test.cost, test.estimated, test.unknown)
+			}
+		})
+	}
+}
+
+func TestTotalUsageKeepsCostFlags(t *testing.T) {
+	var s Session
+	s.AddRequest(1, config.DefaultModel, openrouter.Usage{PromptTokens: 10, Cost: 0.25})
+	s.AddRequest(1, config.SelectedModel{Provider: config.ProviderAnthropic.ID, Model: "claude-sonnet-4-5"}, openrouter.Usage{PromptTokens: 1_000_000})
+	s.AddRequest(2, config.SelectedModel{Provider: config.ProviderOpenAI.ID, Model: "my-finetune"}, openrouter.Usage{PromptTokens: 10})
+
+	turn := s.TurnUsage(1)
+	if turn.Cost != 3.25 || !turn.CostEstimated || turn.CostUnknown {
+		t.Errorf("turn 1 usage = %+v, want an estimated $3.25", turn)
+	}
+	total := s.TotalUsage()
+	if total.Cost != 3.25 || !total.CostEstimated || !total.CostUnknown {
+		t.Errorf("session usage = %+v, want an estimated $3.25 with unknown costs left out", total)
+	}
+}
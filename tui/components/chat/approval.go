@@ -0,0 +1,65 @@
+package chat
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/agent"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/approval"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// requestToolApproval shows the approval dialog for a tool call the agent is waiting on
+func (m *ChatModel) requestToolApproval(request agent.ApprovalRequested) tea.Cmd {
+	call := request.Call
+	warning := ""
+	if request.Dangerous {
+		warning = "This command can delete data or change the system, it always needs your approval"
+	}
+
+	title, detail := tools.DisplayCall(call.Function.Name, call.Function.Arguments)
+	dialog := approval.NewApprovalDialogComponent(approval.Request{
+		ToolCallID:   call.ID,
+		ToolName:     call.Function.Name,
+		Title:        title,
+		Detail:       detail,
+		Arguments:    call.Function.Arguments,
+		Diff:         previewDiff(m.turnCtx, call),
+		OutsidePaths: request.OutsidePaths,
+		Warning:      warning,
+	})
+	m.approval = &request
+	m.approvalDialog = &dialog
+	return tea.Batch(dialog.Init(), func() tea.Msg {
+		return tea.WindowSizeMsg{Width: m.width, Height: m.height}
+	})
+}
+
+// previewDiff returns the diff a file editing tool call would apply, empty for other tools.
+// The preview is only shown to the user, so it may read files outside the workspace.
+func previewDiff(ctx context.Context, call openrouter.ToolCall) string {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	outside := tools.CurrentWorkspace().OutsidePaths(ctx, call.Function.Name, call.Function.Arguments)
+	ctx = tools.WithGrantedPaths(ctx, outside)
+
+	change, ok := tools.PreviewChange(ctx, call.Function.Name, call.Function.Arguments)
+	if !ok {
+		return ""
+	}
+	return change.Diff()
+}
+
+// handleApprovalDecision passes the user's answer for the call in the dialog on to the agent
+func (m *ChatModel) handleApprovalDecision(msg approval.DecisionMsg) tea.Cmd {
+	if m.approval == nil || m.approval.Call.ID != msg.ToolCallID {
+		return nil
+	}
+
+	m.approval.Respond(agent.Approval{Decision: msg.Decision, Feedback: msg.Feedback})
+	m.approval = nil
+	m.approvalDialog = nil
+	return nil
+}
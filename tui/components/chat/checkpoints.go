@@ -0,0 +1,136 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/checkpoint"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/checkpoints"
+)
+
+// errTurnRunning is shown when files would be restored while the agent may still change them
+var errTurnRunning = errors.New("stop the current response before restoring files")
+
+// runUndoCommand takes the files back to before the last turn that changed them
+func (m *ChatModel) runUndoCommand() tea.Cmd {
+	if m.running {
+		m.err = errTurnRunning
+	} else if result, err := m.checkpoints.Undo(); err != nil {
+		m.err = err
+	} else {
+		m.showRestore("Undid", []checkpoint.Result{result})
+	}
+	m.updateViewportContentWithScroll(true)
+	return m.input.Focus()
+}
+
+// runRedoCommand applies the changes of the last undone turn again
+func (m *ChatModel) runRedoCommand() tea.Cmd {
+	if m.running {
+		m.err = errTurnRunning
+	} else if result, err := m.checkpoints.Redo(); err != nil {
+		m.err = err
+	} else {
+		m.showRestore("Redid", []checkpoint.Result{result})
+	}
+	m.updateViewportContentWithScroll(true)
+	return m.input.Focus()
+}
+
+// OpenCheckpointDialog lists the turns that changed files
+func (m *ChatModel) OpenCheckpointDialog() tea.Cmd {
+	if m.running {
+		m.err = errTurnRunning
+		m.updateViewportContentWithScroll(true)
+		return nil
+	}
+
+	dialog := checkpoints.NewCheckpointDialogComponent(m.checkpoints, tools.CurrentWorkspace().Root())
+	if m.width > 0 {
+		updated, _ := dialog.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		dialog = updated.(checkpoints.CheckpointDialogComponent)
+	}
+	m.checkpointDialog = &dialog
+	return dialog.Init()
+}
+
+// handleCheckpointDialogMsg restores the picked checkpoint and closes the dialog
+func (m *ChatModel) handleCheckpointDialogMsg(msg tea.Msg) tea.Cmd {
+	m.checkpointDialog = nil
+	if msg, ok := msg.(checkpoints.RestoreSelectedMsg); ok {
+		var results []checkpoint.Result
+		var err error
+		verb := "Undid"
+		if msg.Before {
+			results, err = m.checkpoints.RestoreBefore(msg.ID)
+		} else {
+			results, err = m.checkpoints.RestoreAfter(msg.ID)
+			verb = "Redid"
+		}
+		if err != nil {
+			m.err = err
+		} else {
+			m.showRestore(verb, results)
+		}
+		m.updateViewportContentWithScroll(true)
+	}
+
+	m.focused = focusInput
+	return m.input.Focus()
+}
+
+// showRestore tells what restoring checkpoints did, e.g. `Undid "add a README": 1 file restored, 2 removed`
+func (m *ChatModel) showRestore(verb string, results []checkpoint.Result) {
+	m.err = nil
+	if len(results) == 0 {
+		m.notice = "The files are already at that checkpoint"
+		return
+	}
+
+	restored, removed := 0, 0
+	var labels []string
+	for _, result := range results {
+		restored += result.Restored
+		removed += result.Removed
+		if result.Err != nil && m.err == nil {
+			m.err = fmt.Errorf("not every file could be restored: %w", result.Err)
+		}
+		labels = append(labels, fmt.Sprintf("%q", truncateLabel(result.Checkpoint.Label)))
+	}
+
+	turns := labels[0]
+	if len(labels) > 1 {
+		turns = fmt.Sprintf("%d turns", len(labels))
+	}
+	m.notice = fmt.Sprintf("%s %s: %s restored, %d removed",
+		verb, turns, pluralize(restored, "file", "files"), removed)
+}
+
+// lastUserMessage returns the last message the user sent, which names the checkpoint of a retried turn
+func (m *ChatModel) lastUserMessage() string {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].IsUser {
+			return m.messages[i].Content
+		}
+	}
+	return ""
+}
+
+// truncateLabel shortens the message a checkpoint is named after to fit a notice
+func truncateLabel(label string) string {
+	label, _, _ = strings.Cut(strings.TrimSpace(label), "\n")
+	if runes := []rune(label); len(runes) > 40 {
+		return string(runes[:37]) + "..."
+	}
+	return label
+}
+
+func pluralize(count int, singular string, plural string) string {
+	if count == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
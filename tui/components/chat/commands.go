@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/checkpoint"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// command is a slash command typed into the editor, e.g. "/retry"
+type command struct {
+	Name string
+	Args string
+}
+
+// parseCommand reports whether the input is a slash command and splits it into name and arguments
+func parseCommand(input string) (command, bool) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, "/") || strings.Contains(input, "\n") {
+		return command{}, false
+	}
+
+	name, args, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+	if name == "" {
+		return command{}, false
+	}
+
+	return command{Name: strings.ToLower(name), Args: strings.TrimSpace(args)}, true
+}
+
+func (m *ChatModel) runCommand(c command) tea.Cmd {
+	switch c.Name {
+	case "new", "clear":
+		m.newConversation()
+		return m.input.Focus()
+	case "retry":
+		return m.retryLastTurn()
+	case "sessions":
+		return m.OpenSessionDialog()
+	case "memory":
+		return m.runMemoryCommand(c.Args)
+	case "compact":
+		return m.compactConversation()
+	case "undo":
+		return m.runUndoCommand()
+	case "redo":
+		return m.runRedoCommand()
+	case "checkpoints":
+		return m.OpenCheckpointDialog()
+	case "mcp":
+		return m.OpenMCPDialog()
+	case "login":
+		return m.runLoginCommand()
+	case "usage":
+		m.showUsage(c.Args)
+		return m.input.Focus()
+	default:
+		m.err = fmt.Errorf("unknown command: /%s", c.Name)
+		m.updateViewportContentWithScroll(true)
+		return m.input.Focus()
+	}
+}
+
+// newConversation drops the transcript and the API history so the next message starts fresh
+func (m *ChatModel) newConversation() {
+	m.messages = []Message{}
+	m.session = session.New(m.selectedModel)
+	// Checkpoints belong to the conversation that made them
+	m.checkpoints = checkpoint.New()
+	m.checkpointDialog = nil
+	m.err = nil
+	m.notice = ""
+	m.updateViewportContentWithScroll(true)
+}
+
+// retryLastTurn discards everything after the last user message and sends the history again
+func (m *ChatModel) retryLastTurn() tea.Cmd {
+	lastUser := -1
+	for i := len(m.session.History) - 1; i >= 0; i-- {
+		if m.session.History[i].Role == openrouter.ChatMessageRoleUser {
+			lastUser = i
+			break
+		}
+	}
+	if lastUser == -1 {
+		m.err = fmt.Errorf("nothing to retry")
+		m.updateViewportContentWithScroll(true)
+		return m.input.Focus()
+	}
+	m.session.History = m.session.History[:lastUser+1]
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].IsUser {
+			m.messages = m.messages[:i+1]
+			break
+		}
+	}
+
+	return m.startRequest("")
+}
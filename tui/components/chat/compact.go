@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/agent"
+)
+
+// compactedMsg is sent when a /compact finished
+type compactedMsg struct {
+	ctx    context.Context
+	result agent.Compacted
+	err    error
+}
+
+// compactConversation summarizes the earlier turns of the history in the background
+func (m *ChatModel) compactConversation() tea.Cmd {
+	if m.running {
+		m.err = errors.New("wait for the current response before compacting")
+		m.updateViewportContentWithScroll(true)
+		return nil
+	}
+
+	m.turnCtx, m.cancel = context.WithCancel(context.Background())
+	m.err = nil
+	m.notice = ""
+	m.loading = true
+	m.running = true
+	m.updateViewportContentWithScroll(true)
+	m.focused = focusViewport
+	m.input.Blur()
+
+	ctx, runner, s := m.turnCtx, m.agent, m.session
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		result, err := runner.Compact(ctx, s)
+		return compactedMsg{ctx: ctx, result: result, err: err}
+	})
+}
+
+// handleCompacted shows the outcome of a /compact and saves the shorter history
+func (m *ChatModel) handleCompacted(msg compactedMsg) tea.Cmd {
+	m.running = false
+	if msg.result.Usage != nil {
+		m.session.AddRequest(m.currentTurn(), m.session.Model, *msg.result.Usage)
+	}
+	if msg.ctx.Err() != nil {
+		// Stopped with Esc, the history wasn't touched
+		m.saveSession()
+		return nil
+	}
+
+	m.endTurn()
+	m.loading = false
+	if msg.err != nil {
+		m.err = msg.err
+	} else {
+		m.notice = formatCompacted(msg.result)
+	}
+	m.saveSession()
+	m.updateViewportContentWithScroll(true)
+	m.focused = focusInput
+	return m.input.Focus()
+}
+
+// formatCompacted describes what a compaction did
+func formatCompacted(result agent.Compacted) string {
+	text := fmt.Sprintf("Compacted the conversation from about %s to %s tokens", formatTokens(result.Before), formatTokens(result.After))
+	if result.SummarizedMessages > 0 {
+		text += fmt.Sprintf(", %d earlier messages were summarized", result.SummarizedMessages)
+	}
+	if result.TruncatedOutputs > 0 {
+		text += fmt.Sprintf(", %d old tool results were shortened", result.TruncatedOutputs)
+	}
+	return text
+}
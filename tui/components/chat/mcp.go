@@ -0,0 +1,38 @@
+package chat
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/mcp"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/mcpservers"
+)
+
+// OpenMCPDialog shows the MCP servers, their state and their tools
+func (m *ChatModel) OpenMCPDialog() tea.Cmd {
+	dialog := mcpservers.NewMCPDialogComponent(mcp.Default())
+	if m.width > 0 {
+		updated, _ := dialog.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		dialog = updated.(mcpservers.MCPDialogComponent)
+	}
+	m.mcpDialog = &dialog
+	return dialog.Init()
+}
+
+// handleMCPDialogMsg keeps the open dialog up to date and closes it
+func (m *ChatModel) handleMCPDialogMsg(msg tea.Msg) tea.Cmd {
+	switch msg.(type) {
+	case mcpservers.RefreshMsg:
+		// A refresh still on its way after the dialog was closed ends the ticking
+		if m.mcpDialog == nil {
+			return nil
+		}
+		updatedDialog, cmd := m.mcpDialog.Update(msg)
+		*m.mcpDialog = updatedDialog.(mcpservers.MCPDialogComponent)
+		return cmd
+
+	case mcpservers.CloseMCPDialog:
+		m.mcpDialog = nil
+	}
+
+	m.focused = focusInput
+	return m.input.Focus()
+}
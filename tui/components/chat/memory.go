@@ -0,0 +1,120 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/agent"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/memory"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// memoryEditedMsg is sent when the editor opened by /memory exits
+type memoryEditedMsg struct {
+	path string
+	err  error
+}
+
+// runMemoryCommand handles /memory: without arguments it lists the memory files, "edit" opens the
+// project's file and "global" the user's global file in $EDITOR
+func (m *ChatModel) runMemoryCommand(args string) tea.Cmd {
+	m.err = nil
+	switch args {
+	case "":
+		m.notice = m.describeMemory()
+		m.updateViewportContentWithScroll(true)
+		return m.input.Focus()
+	case "edit":
+		return m.editMemory(memory.ProjectPath(tools.CurrentWorkspace().Root()))
+	case "global":
+		path, err := memory.GlobalPath()
+		if err != nil {
+			m.err = fmt.Errorf("finding the global memory file: %w", err)
+			m.updateViewportContentWithScroll(true)
+			return m.input.Focus()
+		}
+		return m.editMemory(path)
+	default:
+		m.err = fmt.Errorf("unknown /memory argument %q, use /memory, /memory edit or /memory global", args)
+		m.updateViewportContentWithScroll(true)
+		return m.input.Focus()
+	}
+}
+
+// describeMemory lists the memory files in the order of their precedence
+func (m *ChatModel) describeMemory() string {
+	files, err := memory.Discover(tools.CurrentWorkspace().Root())
+	if err != nil {
+		m.err = err
+	}
+	if len(files) == 0 {
+		return "No memory files yet. /memory edit creates NYRON.md in the workspace, /memory global your global file."
+	}
+
+	lines := []string{"Memory files, later ones take precedence:"}
+	for _, file := range files {
+		line := fmt.Sprintf("  %-8s %s (%s)", file.Scope, file.Path, formatTokens(len(file.Content)/4)+" tokens")
+		if file.Truncated {
+			line += ", truncated"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "/memory edit opens the project file, /memory global your global file.")
+	return strings.Join(lines, "\n")
+}
+
+// editMemory opens a memory file in the user's editor, creating its directory first
+func (m *ChatModel) editMemory(path string) tea.Cmd {
+	if m.running {
+		m.err = errors.New("wait for the current response before editing memory")
+		m.updateViewportContentWithScroll(true)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		m.err = fmt.Errorf("creating the memory directory: %w", err)
+		m.updateViewportContentWithScroll(true)
+		return m.input.Focus()
+	}
+
+	editor := editorCommand()
+	command := exec.Command(editor[0], append(editor[1:], path)...)
+	return tea.ExecProcess(command, func(err error) tea.Msg {
+		return memoryEditedMsg{path: path, err: err}
+	})
+}
+
+// editorCommand returns $VISUAL or $EDITOR split into the program and its arguments
+func editorCommand() []string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(variable)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// handleMemoryEdited applies the edited memory to the conversation, from the next message on
+func (m *ChatModel) handleMemoryEdited(msg memoryEditedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.err = fmt.Errorf("editing %s: %w", msg.path, msg.err)
+	} else {
+		if len(m.session.History) > 0 && m.session.History[0].Role == openrouter.ChatMessageRoleSystem {
+			m.session.History[0].Content = openrouter.Content{Text: agent.SystemPrompt()}
+			m.saveSession()
+		}
+		m.notice = "Memory saved to " + msg.path + ", it applies from your next message"
+	}
+	m.updateViewportContentWithScroll(true)
+	m.focused = focusInput
+	return m.input.Focus()
+}
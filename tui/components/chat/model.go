@@ -1,7 +1,8 @@
 package chat
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -10,11 +11,18 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/krishkalaria12/nyron-ai-cli/ai"
+	"github.com/krishkalaria12/nyron-ai-cli/agent"
 	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/checkpoint"
 	"github.com/krishkalaria12/nyron-ai-cli/config"
-	prompts "github.com/krishkalaria12/nyron-ai-cli/config/prompts"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/approval"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/checkpoints"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/mcpservers"
 	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/models"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/onboarding"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/sessions"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/diffview"
 	editor "github.com/krishkalaria12/nyron-ai-cli/tui/components/editor"
 	"github.com/krishkalaria12/nyron-ai-cli/util"
 	openrouter "github.com/revrost/go-openrouter"
@@ -27,6 +35,9 @@ const (
 	focusInput
 )
 
+// maxChatDiffLines caps the diff preview shown under a tool call in the transcript
+const maxChatDiffLines = 40
+
 type keyMap struct {
 	Tab        key.Binding
 	Up         key.Binding
@@ -35,7 +46,9 @@ type keyMap struct {
 	PageDown   key.Binding
 	Enter      key.Binding
 	Quit       key.Binding
+	Cancel     key.Binding
 	OpenDialog key.Binding
+	Sessions   key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -45,7 +58,7 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.PageUp, k.PageDown},
-		{k.Tab, k.Enter, k.OpenDialog, k.Quit},
+		{k.Tab, k.Enter, k.OpenDialog, k.Sessions, k.Cancel, k.Quit},
 	}
 }
 
@@ -57,7 +70,27 @@ var keys = keyMap{
 	Tab:        key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "switch focus")),
 	Enter:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "send message")),
 	Quit:       key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+	Cancel:     key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "stop generating")),
 	OpenDialog: key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "choose model")),
+	Sessions:   key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "sessions")),
+}
+
+// withBindings returns the key map with the keys the configuration binds to each action
+func (k keyMap) withBindings(bindings map[string][]string) keyMap {
+	actions := map[string]*key.Binding{
+		"switch_focus": &k.Tab, "scroll_up": &k.Up, "scroll_down": &k.Down, "page_up": &k.PageUp,
+		"page_down": &k.PageDown, "send": &k.Enter, "quit": &k.Quit, "cancel": &k.Cancel,
+		"models": &k.OpenDialog, "sessions": &k.Sessions,
+	}
+	for action, keys := range bindings {
+		binding, ok := actions[action]
+		if !ok || len(keys) == 0 {
+			continue
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+	return k
 }
 
 // Message represents a chat message for UI rendering
@@ -68,43 +101,61 @@ type Message struct {
 	IsRendered bool       // Whether markdown processing is complete
 	Thinking   string     // AI thinking process (if available)
 	ToolCalls  []ToolCall // Tool calls made during this message
+	Cancelled  bool       // The turn was stopped by the user at this point
+}
+
+func (msg Message) isEmpty() bool {
+	return msg.Content == "" && msg.Rendered == "" && msg.Thinking == "" && len(msg.ToolCalls) == 0 && !msg.Cancelled
 }
 
 // ToolCall represents a single tool call for UI rendering
 type ToolCall struct {
 	Step    string // The tool call step description
+	Diff    string // Unified diff of the change for tools that modify files
+	Output  string // Output streamed by the tool while it runs, e.g. a command's stdout
 	Content string // The content/result of the tool call
+	Done    bool   // The tool finished running
+	Denied  bool   // The call didn't run because of a policy or the user's answer
 }
 
 type ChatModel struct {
-	messages            []Message                          // For UI rendering
-	conversationHistory []openrouter.ChatCompletionMessage // For API calls
-	loading             bool
-	viewport            viewport.Model
-	spinner             spinner.Model
-	input               editor.InputModel
-	keys                keyMap
-	focused             focusState
-	help                help.Model
-	width               int
-	height              int
-	err                 error
-	selectedModel       config.SelectedModel
-	showDialog          bool
-	modelDialog         *models.ModelListComponent
-}
-
-// --- New Message Types for the event loop ---
-type responseMsg struct {
-	response openrouter.ChatCompletionResponse
-	err      error
-}
-
-type toolResultsMsg struct {
-	results []openrouter.ChatCompletionMessage
+	messages         []Message // For UI rendering
+	loading          bool
+	viewport         viewport.Model
+	spinner          spinner.Model
+	input            editor.InputModel
+	keys             keyMap
+	focused          focusState
+	help             help.Model
+	width            int
+	height           int
+	err              error
+	notice           string // Information shown at the end of the transcript until the next message, e.g. from /usage
+	selectedModel    config.SelectedModel
+	showDialog       bool
+	modelDialog      *models.ModelListComponent
+	stream           *streamState       // The response currently being streamed, if any
+	turnCtx          context.Context    // Context of the turn in flight
+	cancel           context.CancelFunc // Cancels the model call or tool loop in flight
+	agent            *agent.Agent
+	running          bool                     // An agent run is in flight, also while it winds down after a cancel
+	toolMessageIndex int                      // The transcript message showing the tool calls being run
+	approval         *agent.ApprovalRequested // The tool call waiting for the user's approval, if any
+	approvalDialog   *approval.ApprovalDialogComponent
+	sessions         *session.Store   // Where conversations are saved, nil if there is no data directory
+	session          *session.Session // The conversation, its history is owned by the agent while running
+	sessionDialog    *sessions.SessionDialogComponent
+	mcpDialog        *mcpservers.MCPDialogComponent
+	checkpoints      *checkpoint.Store // Snapshots of the files each turn changed, for /undo and /redo
+	checkpointDialog *checkpoints.CheckpointDialogComponent
+	onboardingDialog *onboarding.OnboardingDialogComponent // Asks for the API key of the selected provider when it has none
+	startup          tea.Cmd                               // Run by Init, for dialogs opened before the program started
 }
 
 func NewChatModel() ChatModel {
+	settings := config.Current()
+	applyTheme(settings.Theme)
+
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = loadingStyle
@@ -114,57 +165,51 @@ func NewChatModel() ChatModel {
 
 	vp := viewport.New(80, 20)
 
-	return ChatModel{
-		messages:            []Message{},
-		conversationHistory: []openrouter.ChatCompletionMessage{},
-		loading:             false,
-		spinner:             s,
-		input:               inputModel,
-		viewport:            vp,
-		focused:             focusInput,
-		keys:                keys,
-		help:                help.New(),
-		selectedModel: config.SelectedModel{
-			Provider: "openrouter",
-			Model:    "google/gemini-2.5-flash",
-		},
-		showDialog: false,
+	selectedModel := settings.Model
+
+	// Without a data directory the chat still works, it just isn't saved
+	store, _ := session.NewDefaultStore()
+
+	chat := ChatModel{
+		messages:      []Message{},
+		loading:       false,
+		spinner:       s,
+		input:         inputModel,
+		viewport:      vp,
+		focused:       focusInput,
+		keys:          keys.withBindings(settings.Keys),
+		help:          help.New(),
+		selectedModel: selectedModel,
+		showDialog:    false,
+		agent:         agent.NewFromConfig(),
+		sessions:      store,
+		session:       session.New(selectedModel),
+		checkpoints:   checkpoint.New(),
 		modelDialog: func() *models.ModelListComponent {
 			component := models.NewModelListComponent()
 			return &component
 		}(),
 	}
+	if needsAPIKey(selectedModel) {
+		dialog := onboarding.NewOnboardingDialogComponent(selectedModel.Provider)
+		chat.onboardingDialog = &dialog
+	}
+	// The alt screen hides the warnings printed at startup
+	chat.notice = strings.Join(settings.Warnings, "\n")
+	return chat
 }
 
 func (m ChatModel) Init() tea.Cmd {
-	return m.input.Focus()
-}
-
-func getAIResponse(history []openrouter.ChatCompletionMessage, selectedModel config.SelectedModel) tea.Cmd {
-	return func() tea.Msg {
-		modelID := selectedModel.Model
-		if selectedModel.Provider != "openrouter" {
-			modelID = "google/gemini-2.5-flash"
-		}
-		resp, err := ai.OpenRouterAPI(history, modelID)
-		return responseMsg{response: resp, err: err}
+	loadCatalogs := m.modelDialog.LoadCatalogs(false)
+	if m.onboardingDialog != nil {
+		return tea.Batch(m.onboardingDialog.Init(), loadCatalogs, m.startup)
 	}
+	return tea.Batch(m.input.Focus(), loadCatalogs, m.startup)
 }
 
-// executeToolsCmd processes the tool calls requested by the AI.
-func executeToolsCmd(calls []openrouter.ToolCall) tea.Cmd {
-	return func() tea.Msg {
-		var results []openrouter.ChatCompletionMessage
-		for _, call := range calls {
-			toolResult := tools.ExecuteTool(call.Function.Name, call.Function.Arguments)
-			results = append(results, openrouter.ChatCompletionMessage{
-				Role:       openrouter.ChatMessageRoleTool,
-				Content:    openrouter.Content{Text: toolResult},
-				ToolCallID: call.ID,
-			})
-		}
-		return toolResultsMsg{results: results}
-	}
+// RunAtStart has Init run cmd, for commands of dialogs opened before the program starts
+func (m *ChatModel) RunAtStart(cmd tea.Cmd) {
+	m.startup = tea.Batch(m.startup, cmd)
 }
 
 func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -179,6 +224,27 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.Width = m.width
 		m.help.Width = m.width
 
+		if m.approvalDialog != nil {
+			updatedDialog, _ := m.approvalDialog.Update(msg)
+			*m.approvalDialog = updatedDialog.(approval.ApprovalDialogComponent)
+		}
+		if m.sessionDialog != nil {
+			updatedDialog, _ := m.sessionDialog.Update(msg)
+			*m.sessionDialog = updatedDialog.(sessions.SessionDialogComponent)
+		}
+		if m.mcpDialog != nil {
+			updatedDialog, _ := m.mcpDialog.Update(msg)
+			*m.mcpDialog = updatedDialog.(mcpservers.MCPDialogComponent)
+		}
+		if m.checkpointDialog != nil {
+			updatedDialog, _ := m.checkpointDialog.Update(msg)
+			*m.checkpointDialog = updatedDialog.(checkpoints.CheckpointDialogComponent)
+		}
+		if m.onboardingDialog != nil {
+			updatedDialog, _ := m.onboardingDialog.Update(msg)
+			*m.onboardingDialog = updatedDialog.(onboarding.OnboardingDialogComponent)
+		}
+
 		// Calculate input width accounting for border and padding
 		inputFrameSize := focusedInputBorderStyle.GetHorizontalFrameSize()
 		m.input.TextArea.SetWidth(m.width - inputFrameSize)
@@ -187,10 +253,56 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateViewportHeight()
 
 		if len(m.messages) > 0 {
+			m.renderTranscript()
 			m.updateViewportContent()
 		}
 
 	case tea.KeyMsg:
+		if m.approvalDialog != nil {
+			if key.Matches(msg, m.keys.Quit) {
+				return m, m.cancelTurn()
+			}
+			updatedDialog, cmd := m.approvalDialog.Update(msg)
+			*m.approvalDialog = updatedDialog.(approval.ApprovalDialogComponent)
+			return m, cmd
+		}
+
+		if m.onboardingDialog != nil {
+			if key.Matches(msg, m.keys.Quit) {
+				return m, tea.Quit
+			}
+			updatedDialog, cmd := m.onboardingDialog.Update(msg)
+			*m.onboardingDialog = updatedDialog.(onboarding.OnboardingDialogComponent)
+			return m, cmd
+		}
+
+		if m.sessionDialog != nil {
+			if key.Matches(msg, m.keys.Quit) {
+				return m, tea.Quit
+			}
+			updatedDialog, cmd := m.sessionDialog.Update(msg)
+			*m.sessionDialog = updatedDialog.(sessions.SessionDialogComponent)
+			return m, cmd
+		}
+
+		if m.mcpDialog != nil {
+			if key.Matches(msg, m.keys.Quit) {
+				return m, tea.Quit
+			}
+			updatedDialog, cmd := m.mcpDialog.Update(msg)
+			*m.mcpDialog = updatedDialog.(mcpservers.MCPDialogComponent)
+			return m, cmd
+		}
+
+		if m.checkpointDialog != nil {
+			if key.Matches(msg, m.keys.Quit) {
+				return m, tea.Quit
+			}
+			updatedDialog, cmd := m.checkpointDialog.Update(msg)
+			*m.checkpointDialog = updatedDialog.(checkpoints.CheckpointDialogComponent)
+			return m, cmd
+		}
+
 		if m.showDialog {
 			var cmd tea.Cmd
 			updatedModel, cmd := m.modelDialog.Update(msg)
@@ -201,11 +313,18 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		switch {
+		case key.Matches(msg, m.keys.Quit) && m.loading:
+			// The first Ctrl+C stops the generation, the next one quits
+			cmds = append(cmds, m.cancelTurn())
 		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
+		case key.Matches(msg, m.keys.Cancel) && m.loading:
+			cmds = append(cmds, m.cancelTurn())
 		case key.Matches(msg, m.keys.OpenDialog):
 			m.showDialog = true
 			return m, m.modelDialog.Init()
+		case key.Matches(msg, m.keys.Sessions):
+			return m, m.OpenSessionDialog()
 		case key.Matches(msg, m.keys.Up), key.Matches(msg, m.keys.Down), key.Matches(msg, m.keys.PageUp), key.Matches(msg, m.keys.PageDown):
 			switch m.focused {
 			case focusViewport:
@@ -236,35 +355,21 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case key.Matches(msg, m.keys.Enter) && m.focused == focusInput:
 			// Only send message on plain Enter, not Shift+Enter
-			if msg.String() == "enter" && !m.loading && m.input.Value() != "" {
+			if msg.String() == "enter" && !m.loading && !m.running && m.input.Value() != "" {
 				userMessageContent := m.input.Value()
-				m.messages = append(m.messages, Message{Content: userMessageContent, IsUser: true})
 				m.input.Reset()
 
-				// If this is a new conversation, add the system prompt first.
-				if len(m.conversationHistory) == 0 {
-					promptPair := prompts.GetPrompts(userMessageContent, "openrouter")
-					m.conversationHistory = append(m.conversationHistory, openrouter.ChatCompletionMessage{
-						Role:    openrouter.ChatMessageRoleSystem,
-						Content: openrouter.Content{Text: promptPair.SystemPrompt},
-					})
-				}
-
-				// Append the user message to the API history
-				m.conversationHistory = append(m.conversationHistory, openrouter.ChatCompletionMessage{
-					Role:    openrouter.ChatMessageRoleUser,
-					Content: openrouter.Content{Text: userMessageContent},
-				})
-
 				// Reset input height to minimum after clearing
 				m.input.TextArea.SetHeight(m.input.MinHeight())
 				m.updateViewportHeight()
 
-				m.loading = true
-				m.updateViewportContentWithScroll(true)
-				m.focused = focusViewport
-				m.input.Blur()
-				cmds = append(cmds, m.spinner.Tick, getAIResponse(m.conversationHistory, m.selectedModel))
+				if command, ok := parseCommand(userMessageContent); ok {
+					cmds = append(cmds, m.runCommand(command))
+					break
+				}
+
+				m.messages = append(m.messages, Message{Content: userMessageContent, IsUser: true})
+				cmds = append(cmds, m.startRequest(userMessageContent))
 			}
 		default:
 			switch m.focused {
@@ -296,64 +401,31 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// Handling the conversation cycle
 
-	case responseMsg:
-		if msg.err != nil {
-			m.loading = false
-			m.err = msg.err
-			// Clear history on error to start fresh
-			m.conversationHistory = []openrouter.ChatCompletionMessage{}
-			return m, nil
-		}
+	case agentEventMsg:
+		cmds = append(cmds, m.handleAgentEvent(msg))
 
-		assistantMessage := msg.response.Choices[0].Message
-		m.conversationHistory = append(m.conversationHistory, assistantMessage)
-
-		if len(assistantMessage.ToolCalls) > 0 {
-			// AI wants to use tools
-			var uiToolCalls []ToolCall
-			for _, call := range assistantMessage.ToolCalls {
-				displayName, displayContent := formatToolCallForDisplay(call.Function.Name, call.Function.Arguments)
-				uiToolCalls = append(uiToolCalls, ToolCall{
-					Step:    displayName,
-					Content: displayContent,
-				})
-			}
-			// Add a new message to the UI to show what the AI is doing
-			m.messages = append(m.messages, Message{
-				IsUser:     false,
-				IsRendered: true, // Mark as rendered to show tool call info
-				ToolCalls:  uiToolCalls,
-			})
-			m.updateViewportContentWithScroll(true)
-			// Dispatch a command to execute the tools
-			cmds = append(cmds, executeToolsCmd(assistantMessage.ToolCalls))
-		} else {
-			// This is the final text response
-			messageIndex := len(m.messages)
-			finalContent := assistantMessage.Content.Text
-			thinking := ""
-			if assistantMessage.Reasoning != nil {
-				thinking = *assistantMessage.Reasoning
-			}
-			m.messages = append(m.messages, Message{
-				Content:    finalContent,
-				IsUser:     false,
-				IsRendered: false,
-				Thinking:   thinking,
-			})
-			// Render the final markdown response
-			cmds = append(cmds, util.RenderMarkdownAsync(finalContent, m.width-4, messageIndex))
-			// Conversation is over, clear history for the next prompt
-			m.conversationHistory = []openrouter.ChatCompletionMessage{}
-		}
+	case compactedMsg:
+		cmds = append(cmds, m.handleCompacted(msg))
 
-	case toolResultsMsg:
-		// Append tool results to history
-		m.conversationHistory = append(m.conversationHistory, msg.results...)
+	case memoryEditedMsg:
+		cmds = append(cmds, m.handleMemoryEdited(msg))
 
-		// add a UI message here to show the tool's raw output.
-		// For now, we immediately call the AI again with the new context.
-		cmds = append(cmds, getAIResponse(m.conversationHistory, m.selectedModel))
+	case streamRenderedMsg:
+		if m.stream == nil || m.stream.messageIndex != msg.messageIndex {
+			// The stream already finished and its message was rendered in full
+			break
+		}
+		m.messages[msg.messageIndex].Rendered = msg.rendered
+		m.stream.rendering = false
+		if m.stream.done {
+			// The stream ended during this render, so hand over to the final render
+			finalContent := m.messages[msg.messageIndex].Content
+			m.stream = nil
+			cmds = append(cmds, util.RenderMarkdownAsync(finalContent, m.width-4, msg.messageIndex))
+		} else if m.stream.dirty {
+			cmds = append(cmds, m.renderStream())
+		}
+		m.updateViewportContentWithScroll(true)
 
 	case spinner.TickMsg:
 		if m.loading {
@@ -379,7 +451,33 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case models.ModelSelectedMsg:
 		m.selectedModel = msg.Model
 		m.showDialog = false
-		cmds = append(cmds, m.input.Focus())
+		if !config.SupportsTools(m.selectedModel) {
+			m.notice = fmt.Sprintf("%s can't call tools, so it answers without reading files or running commands", m.selectedModel.Model)
+			m.updateViewportContentWithScroll(true)
+		}
+		if needsAPIKey(m.selectedModel) {
+			cmds = append(cmds, m.openOnboarding(m.selectedModel.Provider))
+		} else {
+			cmds = append(cmds, m.input.Focus())
+		}
+
+	case approval.DecisionMsg:
+		cmds = append(cmds, m.handleApprovalDecision(msg))
+
+	case sessions.SessionSelectedMsg, sessions.SessionRenamedMsg, sessions.SessionDeletedMsg, sessions.CloseSessionDialog:
+		cmds = append(cmds, m.handleSessionDialogMsg(msg))
+
+	case checkpoints.RestoreSelectedMsg, checkpoints.CloseCheckpointDialog:
+		cmds = append(cmds, m.handleCheckpointDialogMsg(msg))
+
+	case mcpservers.RefreshMsg, mcpservers.CloseMCPDialog:
+		cmds = append(cmds, m.handleMCPDialogMsg(msg))
+
+	case onboarding.KeyCheckedMsg, onboarding.CloseOnboardingDialog:
+		cmds = append(cmds, m.handleOnboardingMsg(msg))
+
+	case models.CatalogLoadedMsg:
+		m.modelDialog.SetCatalog(msg.Catalog)
 
 	case models.CloseModelDialog:
 		m.showDialog = false
@@ -389,6 +487,171 @@ func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// handleAgentEvent updates the transcript with an event of the agent run. Events of a cancelled turn
+// are drained without effect, except Done which tells that the run no longer touches the session.
+func (m *ChatModel) handleAgentEvent(msg agentEventMsg) tea.Cmd {
+	// Model calls of a cancelled turn are paid for too, so their usage is always recorded
+	if usage, ok := msg.event.(agent.Usage); ok {
+		m.session.AddRequest(m.currentTurn(), m.session.Model, usage.Usage)
+		m.updateViewportContent()
+		return waitForEvent(msg.ctx, msg.events)
+	}
+	if done, ok := msg.event.(agent.Done); ok {
+		m.running = false
+		if msg.ctx.Err() != nil {
+			m.saveSession()
+			return nil
+		}
+		if done.Err != nil {
+			m.stream = nil
+			return m.failTurn(done.Err)
+		}
+		m.endTurn()
+		m.saveSession()
+		return nil
+	}
+
+	next := waitForEvent(msg.ctx, msg.events)
+	if msg.ctx.Err() != nil {
+		return next
+	}
+
+	var cmd tea.Cmd
+	switch event := msg.event.(type) {
+	case agent.TextDelta:
+		cmd = m.handleTextDelta(event.Text)
+	case agent.ReasoningDelta:
+		m.handleReasoningDelta(event.Text)
+	case agent.ToolCallDelta:
+		m.handleToolCallDelta(event.Calls)
+	case agent.MessageDone:
+		cmd = m.handleAssistantMessage(event.Message)
+	case agent.ApprovalRequested:
+		cmd = m.requestToolApproval(event)
+	case agent.ToolOutput:
+		m.handleToolOutput(event.Index, event.Chunk)
+	case agent.ToolResult:
+		m.handleToolResult(event.Index, event.Denied)
+	case agent.Compacted:
+		m.notice = formatCompacted(event)
+		m.updateViewportContentWithScroll(true)
+	}
+	return tea.Batch(cmd, next)
+}
+
+// handleAssistantMessage shows a complete assistant message with its tool calls, or renders it
+// as the final answer. A message that was streamed reuses its live UI message.
+func (m *ChatModel) handleAssistantMessage(assistantMessage openrouter.ChatCompletionMessage) tea.Cmd {
+	thinking := ""
+	if assistantMessage.Reasoning != nil {
+		thinking = *assistantMessage.Reasoning
+	}
+
+	message := m.liveMessage()
+	messageIndex := m.stream.messageIndex
+	message.Content = assistantMessage.Content.Text
+	message.Thinking = thinking
+
+	if len(assistantMessage.ToolCalls) > 0 {
+		// AI wants to use tools
+		var uiToolCalls []ToolCall
+		for _, call := range assistantMessage.ToolCalls {
+			displayName, displayContent := tools.DisplayCall(call.Function.Name, call.Function.Arguments)
+			uiToolCalls = append(uiToolCalls, ToolCall{
+				Step:    displayName,
+				Content: displayContent,
+				Diff:    previewDiff(m.turnCtx, call),
+			})
+		}
+		message.ToolCalls = uiToolCalls
+		message.IsRendered = true // Mark as rendered to show tool call info
+
+		if message.Content != "" {
+			message.Rendered = m.renderNow(message.Content)
+		}
+		m.stream = nil
+		m.toolMessageIndex = messageIndex
+		m.updateViewportContentWithScroll(true)
+		return nil
+	}
+
+	// This is the final text response
+	if m.stream.rendering {
+		// Wait for the partial render in flight so it can't overwrite the final one
+		m.stream.done = true
+		return nil
+	}
+	m.stream = nil
+	return util.RenderMarkdownAsync(message.Content, m.width-4, messageIndex)
+}
+
+// failTurn stops loading and shows the error; the history is kept so the turn can be retried
+func (m *ChatModel) failTurn(err error) tea.Cmd {
+	m.endTurn()
+	m.loading = false
+	m.err = err
+	m.saveSession()
+	m.updateViewportContentWithScroll(true)
+	m.focused = focusInput
+	return m.input.Focus()
+}
+
+// startRequest shows the loading state and starts an agent run for input; an empty input resends the history
+func (m *ChatModel) startRequest(input string) tea.Cmd {
+	m.turnCtx, m.cancel = context.WithCancel(context.Background())
+	m.err = nil
+	m.notice = ""
+	m.loading = true
+	m.running = true
+	m.updateViewportContentWithScroll(true)
+	m.focused = focusViewport
+	m.input.Blur()
+
+	// Every file a tool changes in this turn is snapshotted first, so /undo can take it back
+	label := input
+	if label == "" {
+		label = m.lastUserMessage()
+	}
+	m.checkpoints.Begin(label)
+	runCtx := tools.WithChangeRecorder(m.turnCtx, m.checkpoints.Record)
+
+	m.session.Model = m.selectedModel
+	events := m.agent.Run(runCtx, m.session, input)
+	return tea.Batch(m.spinner.Tick, waitForEvent(m.turnCtx, events))
+}
+
+// endTurn releases the context of the turn in flight
+func (m *ChatModel) endTurn() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+// cancelTurn aborts the model call or tool loop in flight. The partial answer stays in the transcript;
+// the agent keeps it in the history and answers unfinished tool calls as cancelled before it reports Done.
+func (m *ChatModel) cancelTurn() tea.Cmd {
+	m.endTurn()
+
+	if m.stream != nil {
+		live := &m.messages[m.stream.messageIndex]
+		if live.Content != "" {
+			live.Rendered = m.renderNow(live.Content)
+		}
+		// Tool calls that were still streaming never ran
+		live.ToolCalls = nil
+		m.stream = nil
+	}
+	m.approval = nil
+	m.approvalDialog = nil
+
+	m.messages = append(m.messages, Message{IsUser: false, IsRendered: true, Cancelled: true})
+	m.loading = false
+	m.focused = focusInput
+	m.updateViewportContentWithScroll(true)
+	return m.input.Focus()
+}
+
 func (m *ChatModel) updateViewportContent() {
 	m.updateViewportContentWithScroll(false)
 }
@@ -397,17 +660,31 @@ func (m *ChatModel) updateViewportContentWithScroll(autoScroll bool) {
 	var content string
 	var hasAIMessageInCurrentConversation bool
 
+	turn := 0
 	for _, msg := range m.messages {
 		if msg.IsUser {
+			content += m.renderTurnUsage(turn)
+			turn++
 			userLabel := userMessageStyle.Render("You:")
 			userContent := userMessageContentStyle.Width(m.width - userMessageContentStyle.GetHorizontalFrameSize()).Render(msg.Content)
 			content += userLabel + " " + userContent + "\n\n"
 			hasAIMessageInCurrentConversation = false // Reset for new user message
-		} else if msg.IsRendered {
+		} else if msg.IsRendered && !msg.isEmpty() {
 			content += m.renderAIMessage(msg, !hasAIMessageInCurrentConversation)
 			hasAIMessageInCurrentConversation = true
 		}
 	}
+	if !m.loading {
+		content += m.renderTurnUsage(turn)
+	}
+	if m.err != nil {
+		errorText := errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+		hintText := helpStyle.Render("Type /retry to resend the last message or /new to start a new conversation")
+		content += lipgloss.JoinVertical(lipgloss.Left, errorText, hintText) + "\n\n"
+	}
+	if m.notice != "" {
+		content += noticeStyle.Render(m.notice) + "\n\n"
+	}
 	if m.loading {
 		aiLabel := ""
 		if !hasAIMessageInCurrentConversation {
@@ -422,129 +699,6 @@ func (m *ChatModel) updateViewportContentWithScroll(autoScroll bool) {
 	}
 }
 
-// formatToolCallForDisplay converts raw tool call information into user-friendly descriptions
-func formatToolCallForDisplay(toolName, arguments string) (string, string) {
-	var displayName, displayContent string
-
-	// Parse the JSON arguments to extract meaningful information
-	var args map[string]any
-	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
-		// If JSON parsing fails, use the tool name as-is
-		return toolName, arguments
-	}
-
-	switch toolName {
-	case "read_file":
-		if filePath, ok := args["FilePath"].(string); ok {
-			displayName = "Reading file"
-			displayContent = filePath
-		} else {
-			displayName = "Reading file"
-			displayContent = "unknown file"
-		}
-
-	case "search_files":
-		if pattern, ok := args["Pattern"].(string); ok {
-			displayName = "Searching for files"
-			displayContent = "pattern: " + pattern
-			if searchPath, ok := args["SearchPath"].(string); ok && searchPath != "" && searchPath != "." {
-				displayContent += " in " + searchPath
-			}
-		} else {
-			displayName = "Searching for files"
-			displayContent = "unknown pattern"
-		}
-
-	case "web_search":
-		if query, ok := args["Query"].(string); ok {
-			displayName = "Searching web"
-			displayContent = "\"" + query + "\""
-		} else {
-			displayName = "Searching web"
-			displayContent = "unknown query"
-		}
-
-	case "create_file_or_folder":
-		basePath, hasBasePath := args["BasePath"].(string)
-		name, hasName := args["Name"].(string)
-		if hasBasePath && hasName {
-			fullPath := basePath + "/" + name
-			if itemType, ok := args["TypeOfCreate"].(string); ok {
-				if itemType == "file" {
-					displayName = "Creating file"
-				} else {
-					displayName = "Creating folder"
-				}
-			} else {
-				displayName = "Creating"
-			}
-			displayContent = fullPath
-		} else {
-			displayName = "Creating item"
-			displayContent = "unknown path"
-		}
-
-	case "edit_content":
-		if filePath, ok := args["FilePath"].(string); ok {
-			displayName = "Editing file"
-			displayContent = filePath
-		} else {
-			displayName = "Editing file"
-			displayContent = "unknown file"
-		}
-
-	case "write_content":
-		if filePath, ok := args["FilePath"].(string); ok {
-			displayName = "Writing to file"
-			displayContent = filePath
-		} else {
-			displayName = "Writing to file"
-			displayContent = "unknown file"
-		}
-
-	case "list_directory":
-		if path, ok := args["Path"].(string); ok {
-			displayName = "Listing directory"
-			if path == "" || path == "." {
-				displayContent = "current directory"
-			} else {
-				displayContent = path
-			}
-		} else {
-			displayName = "Listing directory"
-			displayContent = "current directory"
-		}
-
-	case "get_file_info":
-		if filePath, ok := args["FilePath"].(string); ok {
-			displayName = "Getting file info"
-			displayContent = filePath
-		} else {
-			displayName = "Getting file info"
-			displayContent = "unknown file"
-		}
-
-	case "get_current_directory":
-		displayName = "Getting current directory"
-		displayContent = ""
-
-	default:
-		// For unknown tools, use a more readable format
-		displayName = strings.ReplaceAll(toolName, "_", " ")
-		// Capitalize first letter of each word manually (safer than deprecated strings.Title)
-		words := strings.Fields(displayName)
-		for i, word := range words {
-			if len(word) > 0 {
-				words[i] = strings.ToUpper(word[:1]) + word[1:]
-			}
-		}
-		displayName = strings.Join(words, " ")
-		displayContent = "executing..."
-	}
-
-	return displayName, displayContent
-}
-
 func (m *ChatModel) renderAIMessage(msg Message, showAILabel bool) string {
 	var contentParts []string
 
@@ -562,14 +716,32 @@ func (m *ChatModel) renderAIMessage(msg Message, showAILabel bool) string {
 
 		for _, toolCall := range msg.ToolCalls {
 			stepText := toolCallStyle.Render("• " + toolCall.Step)
+			switch {
+			case toolCall.Denied:
+				stepText += " " + toolDeniedStyle.Render("✗ denied")
+			case toolCall.Done:
+				stepText += " " + toolDoneStyle.Render("✓")
+			}
+
+			if toolCall.Diff != "" {
+				added, removed := diffview.Stats(toolCall.Diff)
+				stepText += " " + diffAddedStyle.Render(fmt.Sprintf("+%d", added)) + " " + diffRemovedStyle.Render(fmt.Sprintf("-%d", removed))
+			}
+			toolContent += stepText + "\n"
 
 			// Only show content if it's not empty
 			if toolCall.Content != "" {
-				contentText := toolCallContentStyle.Width(m.width - toolCallContentStyle.GetHorizontalFrameSize()).Render(toolCall.Content)
-				toolContent += stepText + "\n" + contentText + "\n\n"
-			} else {
-				toolContent += stepText + "\n\n"
+				toolContent += toolCallContentStyle.Width(m.width-toolCallContentStyle.GetHorizontalFrameSize()).Render(toolCall.Content) + "\n"
+			}
+			if toolCall.Output != "" {
+				outputText := toolOutputStyle.Width(m.width - toolOutputStyle.GetHorizontalFrameSize()).Render(tailLines(toolCall.Output, maxToolOutputLines))
+				toolContent += outputText + "\n"
+			}
+			if toolCall.Diff != "" {
+				diffWidth := m.width - toolCallDiffStyle.GetHorizontalFrameSize()
+				toolContent += toolCallDiffStyle.Render(diffview.Render(toolCall.Diff, diffWidth, maxChatDiffLines)) + "\n"
 			}
+			toolContent += "\n"
 		}
 		contentParts = append(contentParts, toolContent)
 	}
@@ -580,6 +752,10 @@ func (m *ChatModel) renderAIMessage(msg Message, showAILabel bool) string {
 		contentParts = append(contentParts, aiContent)
 	}
 
+	if msg.Cancelled {
+		contentParts = append(contentParts, cancelledStyle.Render("⏹ Cancelled"))
+	}
+
 	// Join all content parts
 	if len(contentParts) > 0 {
 		allContent := lipgloss.JoinVertical(lipgloss.Left, contentParts...)
@@ -0,0 +1,66 @@
+package chat
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/onboarding"
+)
+
+// needsAPIKey reports whether the model's provider needs an API key that isn't set anywhere
+func needsAPIKey(model config.SelectedModel) bool {
+	if !config.RequiresAPIKey(model.Provider) {
+		return false
+	}
+	_, err := config.APIKey(model.Provider)
+	return err != nil
+}
+
+// openOnboarding shows the dialog that asks for the API key of a provider
+func (m *ChatModel) openOnboarding(providerID string) tea.Cmd {
+	dialog := onboarding.NewOnboardingDialogComponent(providerID)
+	m.onboardingDialog = &dialog
+	return tea.Batch(dialog.Init(), func() tea.Msg {
+		return tea.WindowSizeMsg{Width: m.width, Height: m.height}
+	})
+}
+
+// runLoginCommand opens the API key dialog for the selected provider, also to replace a key that works
+func (m *ChatModel) runLoginCommand() tea.Cmd {
+	if !config.RequiresAPIKey(m.selectedModel.Provider) {
+		m.err = fmt.Errorf("%s doesn't use an API key", config.ProviderName(m.selectedModel.Provider))
+		m.updateViewportContentWithScroll(true)
+		return m.input.Focus()
+	}
+	return m.openOnboarding(m.selectedModel.Provider)
+}
+
+// handleOnboardingMsg closes the dialog once a key was saved or the user skipped it
+func (m *ChatModel) handleOnboardingMsg(msg tea.Msg) tea.Cmd {
+	if m.onboardingDialog == nil {
+		return nil
+	}
+
+	switch msg := msg.(type) {
+	case onboarding.KeyCheckedMsg:
+		if msg.ProviderID != m.onboardingDialog.ProviderID() {
+			return nil
+		}
+		if msg.Err != nil {
+			updatedDialog, cmd := m.onboardingDialog.Update(msg)
+			*m.onboardingDialog = updatedDialog.(onboarding.OnboardingDialogComponent)
+			return cmd
+		}
+		m.notice = fmt.Sprintf("Saved the %s API key to %s", config.ProviderName(msg.ProviderID), msg.Path)
+	case onboarding.CloseOnboardingDialog:
+		if needsAPIKey(m.selectedModel) {
+			m.notice = fmt.Sprintf("Messages to %s will fail without an API key, type /login to add one",
+				config.ProviderName(m.selectedModel.Provider))
+		}
+	}
+
+	m.onboardingDialog = nil
+	m.updateViewportContentWithScroll(true)
+	return m.input.Focus()
+}
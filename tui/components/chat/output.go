@@ -0,0 +1,49 @@
+package chat
+
+import (
+	"strings"
+)
+
+const (
+	// Characters of streamed tool output kept per tool call for display
+	maxToolOutputChars = 8 * 1024
+	// Trailing lines of streamed tool output shown under a tool call
+	maxToolOutputLines = 12
+)
+
+// handleToolOutput appends streamed output to its tool call in the transcript
+func (m *ChatModel) handleToolOutput(callIndex int, chunk string) {
+	messageIndex := m.toolMessageIndex
+	if messageIndex >= len(m.messages) || callIndex >= len(m.messages[messageIndex].ToolCalls) {
+		return
+	}
+
+	toolCall := &m.messages[messageIndex].ToolCalls[callIndex]
+	toolCall.Output += chunk
+	if len(toolCall.Output) > maxToolOutputChars {
+		toolCall.Output = toolCall.Output[len(toolCall.Output)-maxToolOutputChars:]
+	}
+	m.updateViewportContentWithScroll(true)
+}
+
+// handleToolResult marks a tool call as finished as soon as its result arrives; calls may finish out of order
+func (m *ChatModel) handleToolResult(callIndex int, denied bool) {
+	messageIndex := m.toolMessageIndex
+	if messageIndex >= len(m.messages) || callIndex >= len(m.messages[messageIndex].ToolCalls) {
+		return
+	}
+
+	toolCall := &m.messages[messageIndex].ToolCalls[callIndex]
+	toolCall.Done = true
+	toolCall.Denied = denied
+	m.updateViewportContentWithScroll(true)
+}
+
+// tailLines returns the last n lines of output
+func tailLines(output string, n int) string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
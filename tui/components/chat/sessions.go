@@ -0,0 +1,164 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/checkpoint"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs/sessions"
+)
+
+// saveSession writes the transcript and history to disk; empty conversations aren't saved
+func (m *ChatModel) saveSession() {
+	if m.sessions == nil || m.session == nil {
+		return
+	}
+
+	var messages []session.Message
+	for _, msg := range m.messages {
+		if msg.isEmpty() {
+			continue
+		}
+		saved := session.Message{
+			Role:      session.RoleAssistant,
+			Content:   msg.Content,
+			Thinking:  msg.Thinking,
+			Cancelled: msg.Cancelled,
+		}
+		if msg.IsUser {
+			saved.Role = session.RoleUser
+		}
+		for _, call := range msg.ToolCalls {
+			saved.ToolCalls = append(saved.ToolCalls, session.ToolCall{
+				Step:    call.Step,
+				Content: call.Content,
+				Diff:    call.Diff,
+				Output:  call.Output,
+				Done:    call.Done,
+				Denied:  call.Denied,
+			})
+		}
+		messages = append(messages, saved)
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	m.session.Messages = messages
+	m.session.Model = m.selectedModel
+	m.session.UpdatedAt = time.Now()
+	if m.session.Title == "" {
+		m.session.Title = m.session.DefaultTitle()
+	}
+
+	if err := m.sessions.Save(m.session); err != nil {
+		m.err = err
+	}
+}
+
+// ResumeSession replaces the conversation with a saved session
+func (m *ChatModel) ResumeSession(s *session.Session) {
+	m.session = s
+	m.selectedModel = s.Model
+	// The checkpoints of the previous conversation can't be restored from this one
+	m.checkpoints = checkpoint.New()
+	m.checkpointDialog = nil
+	m.err = nil
+
+	m.messages = []Message{}
+	for _, saved := range s.Messages {
+		msg := Message{
+			Content:    saved.Content,
+			IsUser:     saved.Role == session.RoleUser,
+			IsRendered: saved.Role != session.RoleUser,
+			Thinking:   saved.Thinking,
+			Cancelled:  saved.Cancelled,
+		}
+		for _, call := range saved.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				Step:    call.Step,
+				Content: call.Content,
+				Diff:    call.Diff,
+				Output:  call.Output,
+				Done:    call.Done,
+				Denied:  call.Denied,
+			})
+		}
+		m.messages = append(m.messages, msg)
+	}
+
+	if m.width > 0 {
+		m.renderTranscript()
+	}
+	m.updateViewportContentWithScroll(true)
+}
+
+// renderTranscript renders the markdown of resumed messages, which is not saved with the session
+func (m *ChatModel) renderTranscript() {
+	for i := range m.messages {
+		msg := &m.messages[i]
+		if !msg.IsUser && msg.Content != "" && msg.Rendered == "" {
+			msg.Rendered = m.renderNow(msg.Content)
+		}
+	}
+}
+
+// OpenSessionDialog shows the session browser
+func (m *ChatModel) OpenSessionDialog() tea.Cmd {
+	if m.sessions == nil {
+		m.err = errors.New("sessions are not available: no data directory could be found")
+		m.updateViewportContentWithScroll(true)
+		return nil
+	}
+	if m.loading || m.running {
+		m.err = errors.New("stop the current response before switching sessions")
+		m.updateViewportContentWithScroll(true)
+		return nil
+	}
+
+	dialog := sessions.NewSessionDialogComponent(m.sessions, m.session.ID)
+	if m.width > 0 {
+		updated, _ := dialog.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		dialog = updated.(sessions.SessionDialogComponent)
+	}
+	m.sessionDialog = &dialog
+	return dialog.Init()
+}
+
+// handleSessionDialogMsg reacts to the session browser's messages
+func (m *ChatModel) handleSessionDialogMsg(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case sessions.SessionSelectedMsg:
+		m.sessionDialog = nil
+		if msg.ID != m.session.ID {
+			resumed, err := m.sessions.Load(msg.ID)
+			if err != nil {
+				m.err = fmt.Errorf("resuming session: %w", err)
+				m.updateViewportContentWithScroll(true)
+			} else {
+				m.ResumeSession(resumed)
+			}
+		}
+
+	case sessions.SessionRenamedMsg:
+		if msg.ID == m.session.ID {
+			m.session.Title = msg.Title
+		}
+		return nil
+
+	case sessions.SessionDeletedMsg:
+		if msg.ID == m.session.ID {
+			m.newConversation()
+		}
+		return nil
+
+	case sessions.CloseSessionDialog:
+		m.sessionDialog = nil
+	}
+
+	m.focused = focusInput
+	return m.input.Focus()
+}
@@ -0,0 +1,118 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/agent"
+	"github.com/krishkalaria12/nyron-ai-cli/ai"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// streamState tracks the assistant message that is currently being streamed into the viewport
+type streamState struct {
+	messageIndex int
+	rendering    bool // A markdown render of the partial content is in flight
+	dirty        bool // Content arrived since the last render was started
+	done         bool // The stream finished while a render was still in flight
+}
+
+// agentEventMsg carries an event of the agent run of the turn in flight
+type agentEventMsg struct {
+	ctx    context.Context
+	events <-chan agent.Event // Where the next event comes from
+	event  agent.Event
+}
+
+type streamRenderedMsg struct {
+	messageIndex int
+	rendered     string
+}
+
+// waitForEvent blocks on the next event of the agent run and hands it to Update
+func waitForEvent(ctx context.Context, events <-chan agent.Event) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		return agentEventMsg{ctx: ctx, events: events, event: event}
+	}
+}
+
+// liveMessage returns the AI message the current stream writes into, adding it on the first delta
+func (m *ChatModel) liveMessage() *Message {
+	if m.stream == nil {
+		m.messages = append(m.messages, Message{IsUser: false, IsRendered: true})
+		m.stream = &streamState{messageIndex: len(m.messages) - 1}
+	}
+	return &m.messages[m.stream.messageIndex]
+}
+
+// handleTextDelta appends streamed content to the live message and renders it in the background
+func (m *ChatModel) handleTextDelta(text string) tea.Cmd {
+	live := m.liveMessage()
+	live.Content += text
+
+	var cmd tea.Cmd
+	m.stream.dirty = true
+	if !m.stream.rendering {
+		cmd = m.renderStream()
+	}
+	m.updateViewportContentWithScroll(true)
+	return cmd
+}
+
+// handleReasoningDelta appends streamed reasoning to the live message
+func (m *ChatModel) handleReasoningDelta(text string) {
+	live := m.liveMessage()
+	live.Thinking += text
+	m.updateViewportContentWithScroll(true)
+}
+
+// handleToolCallDelta shows the tool calls streamed so far under the live message
+func (m *ChatModel) handleToolCallDelta(calls []openrouter.ToolCall) {
+	live := m.liveMessage()
+	live.ToolCalls = live.ToolCalls[:0]
+	for _, call := range calls {
+		live.ToolCalls = append(live.ToolCalls, formatStreamingToolCall(call.Function.Name, call.Function.Arguments))
+	}
+	m.updateViewportContentWithScroll(true)
+}
+
+// renderStream renders the partial markdown in the background; only one render runs at a time
+func (m *ChatModel) renderStream() tea.Cmd {
+	m.stream.rendering = true
+	m.stream.dirty = false
+
+	content := m.messages[m.stream.messageIndex].Content
+	messageIndex := m.stream.messageIndex
+	width := m.width - 4
+	return func() tea.Msg {
+		rendered, err := ai.RenderToTerminalWithWidth(content, width)
+		if err != nil {
+			rendered = content
+		}
+		return streamRenderedMsg{messageIndex: messageIndex, rendered: rendered}
+	}
+}
+
+// renderNow renders markdown in place, for short content that must not race with streamed renders
+func (m *ChatModel) renderNow(content string) string {
+	rendered, err := ai.RenderToTerminalWithWidth(content, m.width-4)
+	if err != nil {
+		return content
+	}
+	return rendered
+}
+
+// formatStreamingToolCall shows a tool call whose arguments may still be arriving
+func formatStreamingToolCall(toolName, arguments string) ToolCall {
+	if !json.Valid([]byte(arguments)) {
+		return ToolCall{Step: toolName, Content: "receiving arguments..."}
+	}
+	displayName, displayContent := tools.DisplayCall(toolName, arguments)
+	return ToolCall{Step: displayName, Content: displayContent}
+}
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"github.com/charmbracelet/lipgloss"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
 )
 
 // Color palette for consistent theming
@@ -18,94 +19,186 @@ var (
 	textMuted     = lipgloss.Color("#9ca3af") // Gray-400
 	borderColor   = lipgloss.Color("#d1d5db") // Gray-300
 	borderFocused = primaryColor
+	headerColor   = lipgloss.Color("#D84797") // Pink
 )
 
 var (
+	appStyle                lipgloss.Style
+	loadingStyle            lipgloss.Style
+	inputBorderStyle        lipgloss.Style
+	focusedInputBorderStyle lipgloss.Style
+	userMessageStyle        lipgloss.Style
+	userMessageContentStyle lipgloss.Style
+	aiMessageStyle          lipgloss.Style
+	aiMessageContentStyle   lipgloss.Style
+	headerStyle             lipgloss.Style
+	errorStyle              lipgloss.Style
+	thinkingStyle           lipgloss.Style
+	thinkingHeaderStyle     lipgloss.Style
+	cancelledStyle          lipgloss.Style
+	helpStyle               lipgloss.Style
+	usageStyle              lipgloss.Style
+	headerUsageStyle        lipgloss.Style
+	noticeStyle             lipgloss.Style
+	dialogStyle             lipgloss.Style
+	toolCallStyle           lipgloss.Style
+	toolCallHeaderStyle     lipgloss.Style
+	toolCallContentStyle    lipgloss.Style
+	toolCallDiffStyle       lipgloss.Style
+	toolOutputStyle         lipgloss.Style
+	toolDoneStyle           lipgloss.Style
+	toolDeniedStyle         lipgloss.Style
+	diffAddedStyle          lipgloss.Style
+	diffRemovedStyle        lipgloss.Style
+)
+
+// buildStyles creates the styles from the palette; it runs again when a theme changes the palette
+func buildStyles() {
 	// A master style for the entire application, providing a container.
 	// FIX: Removed Margin(1, 0) to eliminate extra padding and horizontal shift.
 	appStyle = lipgloss.NewStyle()
 
 	// Loading spinner style
 	loadingStyle = lipgloss.NewStyle().
-			Foreground(primaryColor)
+		Foreground(primaryColor)
 
 	// Input border styles with focus states
 	inputBorderStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(borderColor).
-				Padding(0, 1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1)
 
 	focusedInputBorderStyle = lipgloss.NewStyle().
-				Border(lipgloss.ThickBorder()).
-				BorderForeground(borderFocused).
-				Padding(0, 1)
+		Border(lipgloss.ThickBorder()).
+		BorderForeground(borderFocused).
+		Padding(0, 1)
 
 	// Message styles with better visual hierarchy
 	userMessageStyle = lipgloss.NewStyle().
-				Foreground(successColor).
-				Bold(true)
+		Foreground(successColor).
+		Bold(true)
 
 	userMessageContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#ffffff"))
+		Foreground(lipgloss.Color("#ffffff"))
 
 	aiMessageStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true)
+		Foreground(primaryColor).
+		Bold(true)
 
 	aiMessageContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#ffffff"))
+		Foreground(lipgloss.Color("#ffffff"))
 
 	// Header style - a slim, single-line bar
 	headerStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#D84797")).
-			Bold(true).
-			Padding(0, 1).
-			MarginBottom(1).
-			Height(3)
+		Foreground(headerColor).
+		Bold(true).
+		Padding(0, 1).
+		MarginBottom(1).
+		Height(3)
 
 	// Error and status styles
 	errorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true).
-			Padding(1, 2).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(errorColor)
+		Foreground(errorColor).
+		Bold(true).
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(errorColor)
 
 	thinkingStyle = lipgloss.NewStyle().
-			Foreground(textMuted).
-			Italic(true).
-			PaddingLeft(2)
+		Foreground(textMuted).
+		Italic(true).
+		PaddingLeft(2)
 
 	// Thinking header style with greyer color
 	thinkingHeaderStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#6b7280")). // Gray-500 - greyer than textMuted
-				Bold(true).
-				PaddingLeft(2)
+		Foreground(lipgloss.Color("#6b7280")). // Gray-500 - greyer than textMuted
+		Bold(true).
+		PaddingLeft(2)
+
+	// Marker for turns stopped by the user
+	cancelledStyle = lipgloss.NewStyle().
+		Foreground(textMuted).
+		Italic(true).
+		PaddingLeft(2)
 
 	// Help text style
 	helpStyle = lipgloss.NewStyle().
-			Foreground(textMuted)
+		Foreground(textMuted)
+
+	usageStyle = lipgloss.NewStyle().
+		Foreground(textMuted).
+		PaddingLeft(2)
+
+	headerUsageStyle = lipgloss.NewStyle().
+		Foreground(textMuted).
+		Bold(false)
+
+	noticeStyle = lipgloss.NewStyle().
+		Foreground(accentColor)
 
 	// Dialog styles for modal appearance
 	dialogStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(primaryColor).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(primaryColor).
+		Padding(1, 2)
 
 	// Tool calling styles
 	toolCallStyle = lipgloss.NewStyle().
-			Foreground(accentColor).
-			Bold(true).
-			PaddingLeft(2)
+		Foreground(accentColor).
+		Bold(true).
+		PaddingLeft(2)
 
 	toolCallHeaderStyle = lipgloss.NewStyle().
-				Foreground(accentColor).
-				Bold(true).
-				PaddingLeft(2)
+		Foreground(accentColor).
+		Bold(true).
+		PaddingLeft(2)
 
 	toolCallContentStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#ffffff")).
-				PaddingLeft(4).
-				Border(lipgloss.Border{Left: "│"}).
-				BorderForeground(accentColor)
-)
+		Foreground(lipgloss.Color("#ffffff")).
+		PaddingLeft(4).
+		Border(lipgloss.Border{Left: "│"}).
+		BorderForeground(accentColor)
+
+	// Diff preview of file edits, the lines bring their own colors
+	toolCallDiffStyle = lipgloss.NewStyle().
+		PaddingLeft(4).
+		Border(lipgloss.Border{Left: "│"}).
+		BorderForeground(accentColor)
+
+	// Output streamed by a running tool, like a command's stdout
+	toolOutputStyle = lipgloss.NewStyle().
+		Foreground(textMuted).
+		PaddingLeft(4).
+		Border(lipgloss.Border{Left: "│"}).
+		BorderForeground(accentColor)
+
+	toolDoneStyle = lipgloss.NewStyle().
+		Foreground(successColor)
+
+	toolDeniedStyle = lipgloss.NewStyle().
+		Foreground(errorColor)
+
+	diffAddedStyle = lipgloss.NewStyle().
+		Foreground(successColor)
+
+	diffRemovedStyle = lipgloss.NewStyle().
+		Foreground(errorColor)
+}
+
+// applyTheme replaces the colors the theme sets and rebuilds the styles
+func applyTheme(theme config.Theme) {
+	for _, color := range []struct {
+		target *lipgloss.Color
+		value  string
+	}{
+		{&primaryColor, theme.Primary}, {&secondaryColor, theme.Secondary}, {&accentColor, theme.Accent},
+		{&successColor, theme.Success}, {&errorColor, theme.Error}, {&textMuted, theme.Muted},
+		{&borderColor, theme.Border}, {&headerColor, theme.Header},
+	} {
+		if color.value != "" {
+			*color.target = lipgloss.Color(color.value)
+		}
+	}
+	borderFocused = primaryColor
+	buildStyles()
+}
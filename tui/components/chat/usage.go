@@ -0,0 +1,144 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+)
+
+// currentTurn returns the number of the user message being answered, counted from 1
+func (m *ChatModel) currentTurn() int {
+	turn := 0
+	for _, msg := range m.messages {
+		if msg.IsUser {
+			turn++
+		}
+	}
+	return turn
+}
+
+// renderTurnUsage renders the usage line under the answer to a user message, empty when nothing was counted
+func (m *ChatModel) renderTurnUsage(turn int) string {
+	if turn == 0 {
+		return ""
+	}
+	usage := m.session.TurnUsage(turn)
+	if usage.IsZero() {
+		return ""
+	}
+	return usageStyle.Render(formatTurnUsage(usage)) + "\n\n"
+}
+
+// headerTitle returns the header with the running usage of the session
+func (m *ChatModel) headerTitle() string {
+	title := "💬 Nyron AI Chat"
+	usage := m.session.TotalUsage()
+	if usage.IsZero() {
+		return title
+	}
+	return title + headerUsageStyle.Render("  "+formatSessionUsage(usage))
+}
+
+// showUsage handles /usage: without arguments it sums up the session, with a path it exports the report there
+func (m *ChatModel) showUsage(path string) {
+	m.err = nil
+	if path == "" {
+		usage := m.session.TotalUsage()
+		m.notice = fmt.Sprintf("Session usage: %s over %d requests", formatTurnUsage(usage), len(m.session.Requests))
+	} else if err := m.exportUsage(path); err != nil {
+		m.notice = ""
+		m.err = err
+	} else {
+		m.notice = "Usage report written to " + path
+	}
+	m.updateViewportContentWithScroll(true)
+}
+
+// formatTokens shortens a token count, e.g. 12345 to 12.3k
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// formatCost shows small amounts with enough digits to tell them apart
+func formatCost(cost float64) string {
+	if cost < 0.01 {
+		return fmt.Sprintf("$%.4f", cost)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// formatUsageCost describes the cost of usage: "~" marks a cost computed from the model's price and "+" one that
+// leaves out calls of unknown cost. It's empty for free usage.
+func formatUsageCost(usage session.Usage) string {
+	if usage.Cost == 0 {
+		if usage.CostUnknown {
+			return "cost unknown"
+		}
+		return ""
+	}
+	text := formatCost(usage.Cost)
+	if usage.CostEstimated {
+		text = "~" + text
+	}
+	if usage.CostUnknown {
+		text += "+"
+	}
+	return text
+}
+
+// formatTurnUsage describes the usage of one turn, e.g. "1.2k in · 340 out (120 reasoning) · $0.0012"
+func formatTurnUsage(usage session.Usage) string {
+	parts := []string{formatTokens(usage.PromptTokens) + " in"}
+	out := formatTokens(usage.CompletionTokens) + " out"
+	if usage.ReasoningTokens > 0 {
+		out += fmt.Sprintf(" (%s reasoning)", formatTokens(usage.ReasoningTokens))
+	}
+	parts = append(parts, out)
+	if usage.CachedTokens > 0 {
+		parts = append(parts, formatTokens(usage.CachedTokens)+" cached")
+	}
+	if cost := formatUsageCost(usage); cost != "" {
+		parts = append(parts, cost)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatSessionUsage describes the usage of the whole session for the header
+func formatSessionUsage(usage session.Usage) string {
+	text := formatTokens(usage.TotalTokens()) + " tokens"
+	if cost := formatUsageCost(usage); cost != "" {
+		text += " · " + cost
+	}
+	return text
+}
+
+// exportUsage writes the usage report of the session to path, as JSON for a .json file and CSV otherwise
+func (m *ChatModel) exportUsage(path string) error {
+	format := session.ReportCSV
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = session.ReportJSON
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("exporting usage: %w", err)
+	}
+	if err := session.WriteReport(file, []*session.Session{m.session}, format); err != nil {
+		file.Close()
+		return fmt.Errorf("exporting usage: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("exporting usage: %w", err)
+	}
+	return nil
+}
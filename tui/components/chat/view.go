@@ -1,8 +1,6 @@
 package chat
 
 import (
-	"fmt"
-
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -11,11 +9,51 @@ func (m ChatModel) View() string {
 		return "Loading..."
 	}
 
-	// Error display
-	if m.err != nil {
-		errorMsg := errorStyle.Render(fmt.Sprintf("Error: %v", m.err))
-		helpMsg := helpStyle.Render("Press Ctrl+C to quit")
-		return appStyle.Render(lipgloss.JoinVertical(lipgloss.Center, errorMsg, helpMsg))
+	// Tool approval takes precedence, the turn can't continue without an answer
+	if m.approvalDialog != nil {
+		dialog := dialogStyle.Render(m.approvalDialog.View())
+		return lipgloss.Place(
+			m.width, m.height,
+			lipgloss.Center, lipgloss.Center,
+			dialog,
+		)
+	}
+
+	// The chat can't reach the model without a key, so ask for it before anything else
+	if m.onboardingDialog != nil {
+		dialog := dialogStyle.Render(m.onboardingDialog.View())
+		return lipgloss.Place(
+			m.width, m.height,
+			lipgloss.Center, lipgloss.Center,
+			dialog,
+		)
+	}
+
+	if m.sessionDialog != nil {
+		dialog := dialogStyle.Render(m.sessionDialog.View())
+		return lipgloss.Place(
+			m.width, m.height,
+			lipgloss.Center, lipgloss.Center,
+			dialog,
+		)
+	}
+
+	if m.checkpointDialog != nil {
+		dialog := dialogStyle.Render(m.checkpointDialog.View())
+		return lipgloss.Place(
+			m.width, m.height,
+			lipgloss.Center, lipgloss.Center,
+			dialog,
+		)
+	}
+
+	if m.mcpDialog != nil {
+		dialog := dialogStyle.Render(m.mcpDialog.View())
+		return lipgloss.Place(
+			m.width, m.height,
+			lipgloss.Center, lipgloss.Center,
+			dialog,
+		)
 	}
 
 	// Dialog view
@@ -29,7 +67,7 @@ func (m ChatModel) View() string {
 	}
 
 	// --- Main App View ---
-	headerView := headerStyle.Width(m.width).Render("💬 Nyron AI Chat")
+	headerView := headerStyle.Width(m.width).Render(m.headerTitle())
 	viewportView := m.viewport.View()
 	helpView := helpStyle.Width(m.width).Render(m.help.View(m.keys))
 
@@ -0,0 +1,229 @@
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/permission"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/diffview"
+)
+
+const (
+	defaultWidth    = 80
+	defaultHeight   = 30
+	maxArgumentRows = 12
+	minDiffRows     = 6
+)
+
+var (
+	primaryColor   = lipgloss.Color("#6366f1")
+	secondaryColor = lipgloss.Color("#8b5cf6")
+	accentColor    = lipgloss.Color("#06b6d4")
+	textMuted      = lipgloss.Color("#9ca3af")
+	warningColor   = lipgloss.Color("#f59e0b")
+)
+
+var (
+	titleStyle        = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Padding(0, 1)
+	toolStyle         = lipgloss.NewStyle().Foreground(accentColor).Bold(true).PaddingLeft(1)
+	detailStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).PaddingLeft(3)
+	argumentsStyle    = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(3)
+	warningStyle      = lipgloss.NewStyle().Foreground(warningColor).Bold(true).PaddingLeft(1)
+	diffStyle         = lipgloss.NewStyle().PaddingLeft(3)
+	itemStyle         = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("#FFFFFF"))
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(secondaryColor).Bold(true)
+	helpStyle         = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(1)
+)
+
+// Request describes the tool call that needs the user's approval
+type Request struct {
+	ToolCallID string
+	ToolName   string
+	Title      string // Human readable action, e.g. "Editing file"
+	Detail     string // What the action applies to, e.g. the file path
+	Arguments  string // Raw JSON arguments
+	Diff       string // Unified diff of the change, for tools that modify files
+	// Paths outside the workspace the call would use
+	OutsidePaths []string
+	Warning      string // Why the call is riskier than usual, if it is
+}
+
+// DecisionMsg is sent when the user has answered an approval request
+type DecisionMsg struct {
+	ToolCallID string
+	ToolName   string
+	Decision   permission.Decision
+	Feedback   string
+}
+
+// ApprovalDialog interface for the tool approval dialog
+type ApprovalDialog interface {
+	dialogs.DialogModel
+}
+
+type option struct {
+	label    string
+	decision permission.Decision
+}
+
+var options = []option{
+	{label: "Allow once", decision: permission.AllowOnce},
+	{label: "Allow for this session", decision: permission.AllowSession},
+	{label: "Deny", decision: permission.Deny},
+	{label: "Deny with feedback", decision: permission.DenyWithFeedback},
+}
+
+type ApprovalDialogComponent struct {
+	request  Request
+	cursor   int
+	feedback textinput.Model
+	// Whether the feedback input is shown instead of the options
+	enteringFeedback bool
+	width            int
+	height           int
+	keyMap           KeyMap
+	help             help.Model
+}
+
+func NewApprovalDialogComponent(request Request) ApprovalDialogComponent {
+	feedback := textinput.New()
+	feedback.Placeholder = "Tell the model what to do instead…"
+	feedback.CharLimit = 500
+	feedback.Width = defaultWidth - 8
+
+	return ApprovalDialogComponent{
+		request:  request,
+		feedback: feedback,
+		width:    defaultWidth,
+		height:   defaultHeight,
+		keyMap:   DefaultKeyMap(),
+		help:     help.New(),
+	}
+}
+
+func (m ApprovalDialogComponent) Init() tea.Cmd {
+	return nil
+}
+
+func (m ApprovalDialogComponent) decide(decision permission.Decision, feedback string) tea.Cmd {
+	request := m.request
+	return func() tea.Msg {
+		return DecisionMsg{
+			ToolCallID: request.ToolCallID,
+			ToolName:   request.ToolName,
+			Decision:   decision,
+			Feedback:   feedback,
+		}
+	}
+}
+
+func (m ApprovalDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(max(int(float64(msg.Width)*0.8), 40), 120)
+		m.feedback.Width = m.width - 8
+		m.height = msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		if m.enteringFeedback {
+			switch {
+			case key.Matches(msg, m.keyMap.Select):
+				return m, m.decide(permission.DenyWithFeedback, strings.TrimSpace(m.feedback.Value()))
+			case key.Matches(msg, m.keyMap.Close):
+				// Back to the options
+				m.enteringFeedback = false
+				m.feedback.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.feedback, cmd = m.feedback.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, m.keyMap.Next):
+			m.cursor = (m.cursor + 1) % len(options)
+		case key.Matches(msg, m.keyMap.Previous):
+			m.cursor = (m.cursor - 1 + len(options)) % len(options)
+		case key.Matches(msg, m.keyMap.Close):
+			return m, m.decide(permission.Deny, "")
+		case key.Matches(msg, m.keyMap.Select):
+			selected := options[m.cursor]
+			if selected.decision == permission.DenyWithFeedback {
+				m.enteringFeedback = true
+				return m, m.feedback.Focus()
+			}
+			return m, m.decide(selected.decision, "")
+		}
+	}
+	return m, nil
+}
+
+func (m ApprovalDialogComponent) View() string {
+	var sections []string
+	sections = append(sections, titleStyle.Render("🔐 Permission required"), "")
+	sections = append(sections, toolStyle.Render("The assistant wants to run: "+m.request.Title))
+	if m.request.Detail != "" {
+		sections = append(sections, detailStyle.Width(m.width-4).Render(m.request.Detail))
+	}
+	if m.request.Warning != "" {
+		sections = append(sections, "", warningStyle.Width(m.width-4).Render("⚠ "+m.request.Warning))
+	}
+	if len(m.request.OutsidePaths) > 0 {
+		sections = append(sections, "", warningStyle.Render("⚠ Outside the workspace:"))
+		for _, path := range m.request.OutsidePaths {
+			sections = append(sections, detailStyle.Width(m.width-4).Render(path))
+		}
+	}
+	if m.request.Diff != "" {
+		// Leave room for the title, the options and the help line
+		maxRows := max(m.height-20, minDiffRows)
+		sections = append(sections, "", diffStyle.Render(diffview.Render(m.request.Diff, m.width-6, maxRows)))
+	} else if arguments := formatArguments(m.request.Arguments, m.width-6); arguments != "" {
+		sections = append(sections, "", argumentsStyle.Render(arguments))
+	}
+	sections = append(sections, "")
+
+	if m.enteringFeedback {
+		sections = append(sections, toolStyle.Render("Feedback for the model:"), "  "+m.feedback.View(), "")
+		sections = append(sections, helpStyle.Render("enter send • esc back"))
+		return lipgloss.JoinVertical(lipgloss.Left, sections...)
+	}
+
+	for i, option := range options {
+		if i == m.cursor {
+			sections = append(sections, selectedItemStyle.Render("> "+option.label))
+		} else {
+			sections = append(sections, itemStyle.Render(option.label))
+		}
+	}
+	sections = append(sections, "", helpStyle.Render(m.help.View(m.keyMap)))
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+// formatArguments pretty prints the JSON arguments, cut to fit the dialog
+func formatArguments(arguments string, width int) string {
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(arguments), "", "  "); err != nil {
+		pretty.Reset()
+		pretty.WriteString(arguments)
+	}
+
+	lines := strings.Split(strings.TrimSpace(pretty.String()), "\n")
+	if len(lines) > maxArgumentRows {
+		lines = append(lines[:maxArgumentRows], "…")
+	}
+	for i, line := range lines {
+		if runes := []rune(line); width > 1 && len(runes) > width {
+			lines[i] = string(runes[:width-1]) + "…"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
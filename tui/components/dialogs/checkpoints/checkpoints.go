@@ -0,0 +1,195 @@
+package checkpoints
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/krishkalaria12/nyron-ai-cli/checkpoint"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 20
+)
+
+var (
+	primaryColor   = lipgloss.Color("#6366f1")
+	secondaryColor = lipgloss.Color("#8b5cf6")
+	textMuted      = lipgloss.Color("#9ca3af")
+)
+
+var (
+	titleStyle        = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Padding(0, 1)
+	itemStyle         = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("#FFFFFF"))
+	undoneItemStyle   = lipgloss.NewStyle().PaddingLeft(4).Foreground(textMuted).Strikethrough(true)
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(secondaryColor).Bold(true)
+	detailStyle       = lipgloss.NewStyle().PaddingLeft(6).Foreground(textMuted)
+	fileStyle         = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("#FFFFFF"))
+	helpStyle         = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(1)
+)
+
+// RestoreSelectedMsg is sent when the user picks a checkpoint to go back or forward to
+type RestoreSelectedMsg struct {
+	ID int
+	// Before takes the files back to before the turn; otherwise its undone changes are applied again
+	Before bool
+}
+
+// CloseCheckpointDialog is sent when the dialog is dismissed
+type CloseCheckpointDialog struct{}
+
+// CheckpointDialog interface for the checkpoint list dialog
+type CheckpointDialog interface {
+	dialogs.DialogModel
+}
+
+type CheckpointDialogComponent struct {
+	checkpoints []checkpoint.Checkpoint // Newest first
+	root        string
+	selected    int
+	width       int
+	height      int
+	keyMap      KeyMap
+	help        help.Model
+}
+
+// NewCheckpointDialogComponent lists the checkpoints of store; paths are shown relative to root
+func NewCheckpointDialogComponent(store *checkpoint.Store, root string) CheckpointDialogComponent {
+	checkpoints := store.List()
+	slices.Reverse(checkpoints)
+	return CheckpointDialogComponent{
+		checkpoints: checkpoints,
+		root:        root,
+		width:       defaultWidth,
+		height:      defaultHeight,
+		keyMap:      DefaultKeyMap(),
+		help:        help.New(),
+	}
+}
+
+func (m CheckpointDialogComponent) Init() tea.Cmd {
+	return nil
+}
+
+func (m CheckpointDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(max(int(float64(msg.Width)*0.8), 60), 120)
+		m.height = max(int(float64(msg.Height)*0.7), 12)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.Close):
+			return m, func() tea.Msg { return CloseCheckpointDialog{} }
+		case key.Matches(msg, m.keyMap.Next):
+			if m.selected < len(m.checkpoints)-1 {
+				m.selected++
+			}
+		case key.Matches(msg, m.keyMap.Previous):
+			if m.selected > 0 {
+				m.selected--
+			}
+		case key.Matches(msg, m.keyMap.Restore):
+			if m.selected < len(m.checkpoints) {
+				selected := m.checkpoints[m.selected]
+				return m, func() tea.Msg { return RestoreSelectedMsg{ID: selected.ID, Before: selected.Applied} }
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m CheckpointDialogComponent) View() string {
+	sections := []string{titleStyle.Render("Checkpoints"), ""}
+	if len(m.checkpoints) == 0 {
+		sections = append(sections,
+			helpStyle.Render("No file changes yet, a checkpoint is made for every turn that changes files"),
+			helpStyle.Render("Only the file tools are covered, commands run with run_command can't be undone"),
+			"",
+			helpStyle.Render(m.help.View(m.keyMap)),
+		)
+		return lipgloss.JoinVertical(lipgloss.Left, sections...)
+	}
+
+	// Leave room for the title, the files of the selected checkpoint and the help
+	visible := max((m.height-12)/2, 3)
+	start := max(0, min(m.selected-visible/2, len(m.checkpoints)-visible))
+	end := min(start+visible, len(m.checkpoints))
+	for i := start; i < end; i++ {
+		c := m.checkpoints[i]
+		label := truncate(firstLine(c.Label), m.width-10)
+		switch {
+		case i == m.selected:
+			sections = append(sections, selectedItemStyle.Render("> "+label))
+		case !c.Applied:
+			sections = append(sections, undoneItemStyle.Render(label))
+		default:
+			sections = append(sections, itemStyle.Render(label))
+		}
+		sections = append(sections, detailStyle.Render(describe(c)))
+	}
+
+	selected := m.checkpoints[m.selected]
+	sections = append(sections, "")
+	if selected.Applied {
+		sections = append(sections, helpStyle.Render("enter takes the files back to how they were before this turn"))
+	} else {
+		sections = append(sections, helpStyle.Render("enter applies the undone changes again, up to this turn"))
+	}
+	for i, path := range selected.Paths {
+		if i == 5 && len(selected.Paths) > 6 {
+			sections = append(sections, helpStyle.Render(fmt.Sprintf("   ... and %d more", len(selected.Paths)-i)))
+			break
+		}
+		sections = append(sections, fileStyle.Render(truncate(m.relative(path), m.width-6)))
+	}
+
+	sections = append(sections, "", helpStyle.Render(m.help.View(m.keyMap)))
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+// relative shows a path relative to the workspace root when it is inside it
+func (m CheckpointDialogComponent) relative(path string) string {
+	if rel, err := filepath.Rel(m.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// describe sums up a checkpoint on one line, e.g. "14:05 · 3 files · undone"
+func describe(c checkpoint.Checkpoint) string {
+	text := c.Time.Format("15:04")
+	if len(c.Paths) == 1 {
+		text += " · 1 file"
+	} else {
+		text += fmt.Sprintf(" · %d files", len(c.Paths))
+	}
+	if !c.Applied {
+		text += " · undone"
+	}
+	return text
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if line == "" {
+		return "(no message)"
+	}
+	return line
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if width < 4 || len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-3]) + "..."
+}
@@ -0,0 +1,239 @@
+package mcpservers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/krishkalaria12/nyron-ai-cli/mcp"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 20
+	// refreshInterval is how often the dialog shows the latest state of the connections
+	refreshInterval = 500 * time.Millisecond
+)
+
+var (
+	primaryColor   = lipgloss.Color("#6366f1")
+	secondaryColor = lipgloss.Color("#8b5cf6")
+	successColor   = lipgloss.Color("#10b981")
+	warningColor   = lipgloss.Color("#f59e0b")
+	errorColor     = lipgloss.Color("#ef4444")
+	textMuted      = lipgloss.Color("#9ca3af")
+)
+
+var (
+	titleStyle        = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Padding(0, 1)
+	itemStyle         = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("#FFFFFF"))
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(secondaryColor).Bold(true)
+	detailStyle       = lipgloss.NewStyle().PaddingLeft(6).Foreground(textMuted)
+	toolStyle         = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("#FFFFFF"))
+	errorStyle        = lipgloss.NewStyle().Foreground(errorColor).PaddingLeft(4)
+	warningStyle      = lipgloss.NewStyle().Foreground(warningColor).PaddingLeft(4)
+	helpStyle         = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(1)
+)
+
+// RefreshMsg asks the open dialog to read the state of the servers again
+type RefreshMsg struct {
+	dialog int // The dialog that asked for it, so a dialog closed and opened again ticks only once
+}
+
+// CloseMCPDialog is sent when the dialog is dismissed
+type CloseMCPDialog struct{}
+
+// MCPDialog interface for the MCP server dialog
+type MCPDialog interface {
+	dialogs.DialogModel
+}
+
+// opened counts the dialogs shown so far
+var opened int
+
+type MCPDialogComponent struct {
+	id       int
+	manager  *mcp.Manager
+	servers  []mcp.Server
+	selected int
+	width    int
+	height   int
+	keyMap   KeyMap
+	help     help.Model
+}
+
+// NewMCPDialogComponent shows the servers of manager
+func NewMCPDialogComponent(manager *mcp.Manager) MCPDialogComponent {
+	opened++
+	return MCPDialogComponent{
+		id:      opened,
+		manager: manager,
+		servers: manager.Servers(),
+		width:   defaultWidth,
+		height:  defaultHeight,
+		keyMap:  DefaultKeyMap(),
+		help:    help.New(),
+	}
+}
+
+func (m MCPDialogComponent) Init() tea.Cmd {
+	return m.refresh()
+}
+
+func (m MCPDialogComponent) refresh() tea.Cmd {
+	id := m.id
+	return tea.Tick(refreshInterval, func(time.Time) tea.Msg { return RefreshMsg{dialog: id} })
+}
+
+func (m MCPDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(max(int(float64(msg.Width)*0.8), 60), 120)
+		m.height = max(int(float64(msg.Height)*0.7), 12)
+		return m, nil
+
+	case RefreshMsg:
+		if msg.dialog != m.id {
+			return m, nil
+		}
+		m.servers = m.manager.Servers()
+		m.selected = min(m.selected, max(len(m.servers)-1, 0))
+		return m, m.refresh()
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.Close):
+			return m, func() tea.Msg { return CloseMCPDialog{} }
+		case key.Matches(msg, m.keyMap.Next):
+			if m.selected < len(m.servers)-1 {
+				m.selected++
+			}
+		case key.Matches(msg, m.keyMap.Previous):
+			if m.selected > 0 {
+				m.selected--
+			}
+		case key.Matches(msg, m.keyMap.Reconnect):
+			if m.selected < len(m.servers) {
+				m.manager.Reconnect(m.servers[m.selected].Name)
+				m.servers = m.manager.Servers()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m MCPDialogComponent) View() string {
+	sections := []string{titleStyle.Render("MCP Servers"), ""}
+	if len(m.servers) == 0 {
+		sections = append(sections,
+			helpStyle.Render("No MCP servers configured"),
+			helpStyle.Render("Add them to config.toml as [mcp.<name>] with a command or a url"),
+			"",
+			helpStyle.Render(m.help.View(m.keyMap)),
+		)
+		return lipgloss.JoinVertical(lipgloss.Left, sections...)
+	}
+
+	for i, server := range m.servers {
+		line := fmt.Sprintf("%s %s", stateIcon(server.State), server.Name)
+		if i == m.selected {
+			sections = append(sections, selectedItemStyle.Render("> "+line))
+		} else {
+			sections = append(sections, itemStyle.Render("  "+line))
+		}
+		sections = append(sections, detailStyle.Render(summary(server)))
+	}
+
+	sections = append(sections, "")
+	sections = append(sections, m.details(m.servers[m.selected])...)
+	sections = append(sections, "", helpStyle.Render(m.help.View(m.keyMap)))
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+// details lists the tools of a server, or why it isn't connected
+func (m MCPDialogComponent) details(server mcp.Server) []string {
+	var lines []string
+	switch server.State {
+	case mcp.StateFailed:
+		message := "Couldn't connect"
+		if server.Err != nil {
+			message += ": " + server.Err.Error()
+		}
+		lines = append(lines, errorStyle.Width(m.width-4).Render(message))
+	case mcp.StateConnecting:
+		lines = append(lines, helpStyle.Render("Connecting..."))
+	case mcp.StateDisabled:
+		lines = append(lines, helpStyle.Render("Disabled in the configuration"))
+	}
+	if server.Stderr != "" && server.State != mcp.StateConnected {
+		lines = append(lines, detailStyle.Width(m.width-4).Render(server.Stderr))
+	}
+	if server.State != mcp.StateConnected {
+		return lines
+	}
+
+	for _, warning := range server.Warnings {
+		lines = append(lines, warningStyle.Width(m.width-4).Render(warning))
+	}
+	if len(server.Tools) == 0 {
+		return append(lines, helpStyle.Render("The server offers no tools"))
+	}
+	// Leave room for the server list, the title, the warnings and the help
+	room := max(m.height-2*len(m.servers)-len(server.Warnings)-6, 3)
+	for i, tool := range server.Tools {
+		if i == room-1 && len(server.Tools) > room {
+			lines = append(lines, helpStyle.Render(fmt.Sprintf("   ... and %d more", len(server.Tools)-i)))
+			break
+		}
+		line := mcp.ToolName(server.Name, tool.Name)
+		if description := firstLine(tool.Description); description != "" {
+			line += " - " + description
+		}
+		lines = append(lines, toolStyle.Render(truncate(line, m.width-6)))
+	}
+	return lines
+}
+
+func stateIcon(state mcp.State) string {
+	switch state {
+	case mcp.StateConnected:
+		return lipgloss.NewStyle().Foreground(successColor).Render("●")
+	case mcp.StateConnecting:
+		return lipgloss.NewStyle().Foreground(warningColor).Render("◌")
+	case mcp.StateFailed:
+		return lipgloss.NewStyle().Foreground(errorColor).Render("✗")
+	default:
+		return lipgloss.NewStyle().Foreground(textMuted).Render("○")
+	}
+}
+
+// summary describes a server on one line, e.g. "stdio · connected · 4 tools"
+func summary(server mcp.Server) string {
+	text := server.Transport + " · " + string(server.State)
+	if server.State == mcp.StateConnected {
+		if len(server.Tools) == 1 {
+			text += " · 1 tool"
+		} else {
+			text += fmt.Sprintf(" · %d tools", len(server.Tools))
+		}
+	}
+	return text
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if width < 4 || len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-3]) + "..."
+}
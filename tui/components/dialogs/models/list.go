@@ -1,18 +1,27 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/krishkalaria12/nyron-ai-cli/catalog"
 	"github.com/krishkalaria12/nyron-ai-cli/config"
 )
 
+// catalogTimeout bounds fetching the catalog of one provider
+const catalogTimeout = 20 * time.Second
+
 var (
 	primaryColor   = lipgloss.Color("#6366f1")
 	secondaryColor = lipgloss.Color("#8b5cf6")
+	textMuted      = lipgloss.Color("#9ca3af")
+	warningColor   = lipgloss.Color("#f59e0b")
 )
 
 var (
@@ -23,10 +32,36 @@ var (
 
 	itemStyle         = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("#FFFFFF"))
 	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(secondaryColor).Bold(true)
+	attributesStyle   = lipgloss.NewStyle().Foreground(textMuted)
 	providerHeadStyle = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).PaddingLeft(2)
+	descriptionStyle  = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(2)
+	warningStyle      = lipgloss.NewStyle().Foreground(warningColor).PaddingLeft(2)
+	helpStyle         = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(2)
 	quitTextStyle     = lipgloss.NewStyle().Margin(1, 0, 2, 4)
 )
 
+// CatalogLoadedMsg is sent when the catalog of a provider was loaded
+type CatalogLoadedMsg struct {
+	Catalog catalog.Catalog
+}
+
+// loadCatalogs loads the model catalog of every provider in the background
+func loadCatalogs(refresh bool) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, provider := range config.GetAllProviders() {
+		providerID := provider.ID
+		cmds = append(cmds, func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), catalogTimeout)
+			defer cancel()
+			if refresh {
+				return CatalogLoadedMsg{Catalog: catalog.Refresh(ctx, providerID)}
+			}
+			return CatalogLoadedMsg{Catalog: catalog.Load(ctx, providerID)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
 type ListItem interface {
 	list.Item
 	IsHeader() bool
@@ -37,27 +72,73 @@ type ProviderHeader struct {
 }
 
 func (h ProviderHeader) FilterValue() string { return "" }
-func (h ProviderHeader) IsHeader() bool     { return true }
+func (h ProviderHeader) IsHeader() bool      { return true }
 
 type ModelItem struct {
 	provider config.Provider
 	model    config.Model
 }
 
-func (i ModelItem) FilterValue() string { return i.model.Name }
-func (i ModelItem) Title() string       { return i.model.Name }
+func (i ModelItem) FilterValue() string {
+	return i.model.Name + " " + i.model.ID + " " + i.provider.Name
+}
+func (i ModelItem) Title() string { return i.model.Name }
 func (i ModelItem) Description() string {
 	return fmt.Sprintf("%s - %s", i.provider.Name, i.model.Description)
 }
 func (i ModelItem) IsHeader() bool { return false }
 
+// Attributes sums up the context window, price and capabilities, e.g. "1M ctx · $0.30/$2.50 · tools · reasoning"
+func (i ModelItem) Attributes() string {
+	var attributes []string
+	if i.model.ContextWindow > 0 {
+		attributes = append(attributes, formatContext(i.model.ContextWindow)+" ctx")
+	}
+	if pricing := i.model.Pricing; pricing != nil {
+		if pricing.Prompt == 0 && pricing.Completion == 0 {
+			attributes = append(attributes, "free")
+		} else {
+			attributes = append(attributes, "$"+formatPrice(pricing.Prompt)+"/$"+formatPrice(pricing.Completion))
+		}
+	}
+	if i.model.Tools {
+		attributes = append(attributes, "tools")
+	} else {
+		attributes = append(attributes, "no tools")
+	}
+	if i.model.Reasoning {
+		attributes = append(attributes, "reasoning")
+	}
+	return strings.Join(attributes, " · ")
+}
+
+// formatContext shortens a token count, e.g. 1048576 to 1M and 131072 to 131K
+func formatContext(tokens int) string {
+	if tokens >= 1_000_000 {
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(tokens)/1_000_000), ".0") + "M"
+	}
+	return fmt.Sprintf("%dK", (tokens+500)/1000)
+}
+
+// formatPrice formats dollars per million tokens with cents, or more digits for very cheap models
+func formatPrice(price float64) string {
+	if price > 0 && price < 0.01 {
+		return fmt.Sprintf("%.4f", price)
+	}
+	return fmt.Sprintf("%.2f", price)
+}
+
 type ModelListComponent struct {
 	list     list.Model
 	choice   *ModelItem
 	quitting bool
+	width    int
+	catalogs map[string]catalog.Catalog // The last catalog loaded for each provider
+	loading  int                        // Catalogs still being loaded
 }
 
-func NewModelListComponent() ModelListComponent {
+// modelItems lists the models of every provider under a header per provider
+func modelItems() []list.Item {
 	var items []list.Item
 	providers := config.GetAllProviders()
 	for _, provider := range providers {
@@ -71,28 +152,60 @@ func NewModelListComponent() ModelListComponent {
 			}
 		}
 	}
+	return items
+}
+
+func NewModelListComponent() ModelListComponent {
+	items := modelItems()
 
 	// Calculate initial dimensions (will be updated on window resize)
-	initialWidth := 80  // Make it wider initially
+	initialWidth := 80 // Make it wider initially
 	initialHeight := 20
 	l := list.New(items, itemDelegate{}, initialWidth, initialHeight)
 	l.Title = "Choose an AI Model"
 	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false) // Disable filtering to preserve grouping
 	l.SetShowHelp(false)
 	l.SetShowPagination(false)
 	l.Styles.Title = titleStyle
 
-	// Set initial selection to first model (skip headers)
-	for i, item := range items {
-		if modelItem, ok := item.(ModelItem); ok {
-			l.Select(i)
-			_ = modelItem // Use the variable to avoid unused warning
-			break
+	m := ModelListComponent{list: l, width: initialWidth, catalogs: map[string]catalog.Catalog{}}
+	m.selectFirstModel()
+	return m
+}
+
+// selectFirstModel moves the selection to the first visible model, skipping headers
+func (m *ModelListComponent) selectFirstModel() {
+	for i, item := range m.list.VisibleItems() {
+		if _, ok := item.(ModelItem); ok {
+			m.list.Select(i)
+			return
 		}
 	}
+}
+
+// SetCatalog shows the models of a newly loaded catalog and keeps the selected model selected
+func (m *ModelListComponent) SetCatalog(loaded catalog.Catalog) {
+	m.catalogs[loaded.ProviderID] = loaded
+	m.loading = max(m.loading-1, 0)
 
-	return ModelListComponent{list: l}
+	selected, hadSelection := m.list.SelectedItem().(ModelItem)
+	m.list.SetItems(modelItems())
+	if hadSelection {
+		for i, item := range m.list.VisibleItems() {
+			if model, ok := item.(ModelItem); ok && model.provider.ID == selected.provider.ID && model.model.ID == selected.model.ID {
+				m.list.Select(i)
+				return
+			}
+		}
+	}
+	m.selectFirstModel()
+}
+
+// LoadCatalogs loads the model catalogs in the background, each arriving as a CatalogLoadedMsg for SetCatalog;
+// refresh fetches them even when their cache is still fresh
+func (m *ModelListComponent) LoadCatalogs(refresh bool) tea.Cmd {
+	m.loading = len(config.GetAllProviders())
+	return loadCatalogs(refresh)
 }
 
 func (m ModelListComponent) Init() tea.Cmd {
@@ -111,15 +224,20 @@ func (m ModelListComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			dynamicWidth = 150
 		}
 
-		// Calculate height based on terminal height (60% with min 15)
+		// Calculate height based on terminal height (60% with min 15), leaving room for the details below
 		dynamicHeight := int(float64(msg.Height) * 0.6)
 		if dynamicHeight < 15 {
 			dynamicHeight = 15
 		}
 
+		m.width = dynamicWidth
 		m.list.SetSize(dynamicWidth, dynamicHeight)
 		return m, nil
 	case tea.KeyMsg:
+		if m.list.FilterState() == list.Filtering {
+			// The filter input gets every key, enter applies the filter and esc drops it
+			break
+		}
 		switch keypress := msg.String(); keypress {
 		case "ctrl+c":
 			m.quitting = true
@@ -134,12 +252,20 @@ func (m ModelListComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// If it's a header, do nothing
 			return m, nil
 		case "esc":
+			if m.list.FilterState() == list.FilterApplied {
+				m.list.ResetFilter()
+				m.selectFirstModel()
+				return m, nil
+			}
 			return m, func() tea.Msg { return CloseModelDialog{} }
+		case "ctrl+r":
+			return m, m.LoadCatalogs(true)
 		case "up", "k":
 			// Move up and skip headers
 			currentIndex := m.list.Index()
+			items := m.list.VisibleItems()
 			for i := currentIndex - 1; i >= 0; i-- {
-				if item, ok := m.list.Items()[i].(ListItem); ok && !item.IsHeader() {
+				if item, ok := items[i].(ListItem); ok && !item.IsHeader() {
 					m.list.Select(i)
 					break
 				}
@@ -148,7 +274,7 @@ func (m ModelListComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "down", "j":
 			// Move down and skip headers
 			currentIndex := m.list.Index()
-			items := m.list.Items()
+			items := m.list.VisibleItems()
 			for i := currentIndex + 1; i < len(items); i++ {
 				if item, ok := items[i].(ListItem); ok && !item.IsHeader() {
 					m.list.Select(i)
@@ -159,8 +285,13 @@ func (m ModelListComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	wasFiltering := m.list.FilterState() == list.Filtering
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
+	if wasFiltering && m.list.FilterState() != list.Filtering {
+		// Headers drop out of a filtered list, but not out of an unfiltered one
+		m.selectFirstModel()
+	}
 	return m, cmd
 }
 
@@ -168,7 +299,42 @@ func (m ModelListComponent) View() string {
 	if m.quitting {
 		return quitTextStyle.Render("Goodbye!")
 	}
-	return m.list.View()
+
+	sections := []string{m.list.View(), ""}
+	if selected, ok := m.list.SelectedItem().(ModelItem); ok {
+		details := selected.model.ID
+		if selected.model.Description != "" {
+			details += " · " + selected.model.Description
+		}
+		sections = append(sections, descriptionStyle.Width(m.width-2).MaxHeight(3).Render(details))
+	}
+	for _, provider := range config.GetAllProviders() {
+		if status := catalogStatus(provider, m.catalogs[provider.ID]); status != "" {
+			sections = append(sections, warningStyle.Width(m.width-2).Render("⚠ "+status))
+		}
+	}
+
+	help := "enter select • / filter • ctrl+r refresh • esc close"
+	if m.loading > 0 {
+		help = "Loading model catalogs… • " + help
+	}
+	sections = append(sections, helpStyle.Render(help))
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+// catalogStatus explains why the models of a provider may be out of date, empty when they aren't
+func catalogStatus(provider config.Provider, loaded catalog.Catalog) string {
+	if loaded.Err == nil {
+		return ""
+	}
+	switch loaded.Source {
+	case catalog.SourceStale:
+		return fmt.Sprintf("%s: couldn't refresh the models, showing the list from %s", provider.Name, loaded.FetchedAt.Format("Jan 2 15:04"))
+	case catalog.SourceBuiltin:
+		return fmt.Sprintf("%s: couldn't load the models, showing the built-in list", provider.Name)
+	default:
+		return ""
+	}
 }
 
 type itemDelegate struct{}
@@ -186,8 +352,13 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 
 	// Handle model items
 	if modelItem, ok := listItem.(ModelItem); ok {
-		modelName := modelItem.Title()
-		line := fmt.Sprintf("  • %s", modelName)
+		attributes := modelItem.Attributes()
+		// Cut long names so the attributes stay on the line
+		modelName := []rune(modelItem.Title())
+		if room := m.Width() - lipgloss.Width(attributes) - 12; room > 1 && len(modelName) > room {
+			modelName = append(modelName[:room-1], '…')
+		}
+		line := fmt.Sprintf("  • %s", string(modelName))
 
 		fn := itemStyle.Render
 		if index == m.Index() {
@@ -195,6 +366,6 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 				return selectedItemStyle.Render("> " + s[0])
 			}
 		}
-		fmt.Fprint(w, fn(line))
+		fmt.Fprint(w, fn(line)+"  "+attributesStyle.Render(attributes))
 	}
 }
@@ -0,0 +1,194 @@
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/provider"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs"
+)
+
+const (
+	defaultWidth = 80
+	// checkTimeout bounds the request that checks the key
+	checkTimeout = 15 * time.Second
+)
+
+var (
+	primaryColor = lipgloss.Color("#6366f1")
+	accentColor  = lipgloss.Color("#06b6d4")
+	errorColor   = lipgloss.Color("#ef4444")
+	textMuted    = lipgloss.Color("#9ca3af")
+)
+
+var (
+	titleStyle  = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Padding(0, 1)
+	textStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).PaddingLeft(1)
+	linkStyle   = lipgloss.NewStyle().Foreground(accentColor).PaddingLeft(3)
+	mutedStyle  = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(1)
+	errorStyle  = lipgloss.NewStyle().Foreground(errorColor).PaddingLeft(1)
+	helpStyle   = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(1)
+	promptStyle = lipgloss.NewStyle().Foreground(primaryColor).Bold(true)
+)
+
+// keyPages are where each provider hands out API keys
+var keyPages = map[string]string{
+	config.ProviderOpenRouter.ID: "https://openrouter.ai/settings/keys",
+	config.ProviderOpenAI.ID:     "https://platform.openai.com/api-keys",
+	config.ProviderAnthropic.ID:  "https://console.anthropic.com/settings/keys",
+	config.ProviderGemini.ID:     "https://aistudio.google.com/apikey",
+}
+
+// KeyCheckedMsg is sent when a pasted key was checked, and saved when Err is nil
+type KeyCheckedMsg struct {
+	ProviderID string
+	Path       string // The credentials file the key was saved to
+	Err        error
+}
+
+// CloseOnboardingDialog is sent when the user skips entering a key
+type CloseOnboardingDialog struct{}
+
+// OnboardingDialog interface for the dialog that asks for a missing API key
+type OnboardingDialog interface {
+	dialogs.DialogModel
+}
+
+type OnboardingDialogComponent struct {
+	providerID string
+	input      textinput.Model
+	checking   bool  // The key is being checked with the provider
+	err        error // Why the last key wasn't accepted
+	width      int
+	keyMap     KeyMap
+	help       help.Model
+}
+
+func NewOnboardingDialogComponent(providerID string) OnboardingDialogComponent {
+	input := textinput.New()
+	input.Placeholder = "Paste your API key"
+	input.EchoMode = textinput.EchoPassword
+	input.EchoCharacter = '•'
+	input.Prompt = promptStyle.Render("> ")
+	input.Width = defaultWidth - 8
+	input.Focus()
+
+	return OnboardingDialogComponent{
+		providerID: providerID,
+		input:      input,
+		width:      defaultWidth,
+		keyMap:     DefaultKeyMap(),
+		help:       help.New(),
+	}
+}
+
+// ProviderID returns the provider the dialog asks a key for
+func (m OnboardingDialogComponent) ProviderID() string {
+	return m.providerID
+}
+
+func (m OnboardingDialogComponent) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// check validates the key with the provider and saves it when it is accepted
+func (m OnboardingDialogComponent) check(apiKey string) tea.Cmd {
+	providerID := m.providerID
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+		defer cancel()
+
+		if err := provider.ValidateKey(ctx, providerID, apiKey); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("%s didn't answer in time, check your connection", config.ProviderName(providerID))
+			}
+			return KeyCheckedMsg{ProviderID: providerID, Err: err}
+		}
+		path, err := config.SaveAPIKey(providerID, apiKey)
+		return KeyCheckedMsg{ProviderID: providerID, Path: path, Err: err}
+	}
+}
+
+func (m OnboardingDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(max(int(float64(msg.Width)*0.8), 40), 100)
+		m.input.Width = m.width - 8
+		return m, nil
+	case KeyCheckedMsg:
+		// Only failures reach the dialog, the chat closes it once the key is saved
+		m.checking = false
+		m.err = msg.Err
+		return m, nil
+	case tea.KeyMsg:
+		if m.checking {
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, m.keyMap.Close):
+			return m, func() tea.Msg { return CloseOnboardingDialog{} }
+		case key.Matches(msg, m.keyMap.Select):
+			apiKey := strings.TrimSpace(m.input.Value())
+			if apiKey == "" {
+				m.err = errors.New("paste the API key first")
+				return m, nil
+			}
+			m.checking = true
+			m.err = nil
+			return m, m.check(apiKey)
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m OnboardingDialogComponent) View() string {
+	name := config.ProviderName(m.providerID)
+	credentials, err := config.CredentialsPath()
+	if err != nil {
+		credentials = "the credentials file"
+	}
+
+	intro := fmt.Sprintf("There is no API key for %s yet. Create one at:", name)
+	if _, err := config.APIKey(m.providerID); err == nil {
+		intro = fmt.Sprintf("Paste a new API key for %s to replace the current one. Keys are made at:", name)
+	}
+
+	sections := []string{
+		titleStyle.Render("🔑 Connect to " + name),
+		"",
+		textStyle.Width(m.width - 4).Render(intro),
+		linkStyle.Render(keyPages[m.providerID]),
+		"",
+		"  " + m.input.View(),
+		"",
+	}
+
+	switch {
+	case m.checking:
+		sections = append(sections, mutedStyle.Render("Checking the key with "+name+"…"))
+	case m.err != nil:
+		message := m.err.Error()
+		if errors.Is(m.err, provider.ErrInvalidAPIKey) {
+			message = name + " rejected this key, check that it was copied in full"
+		}
+		sections = append(sections, errorStyle.Width(m.width-4).Render("✗ "+message))
+	default:
+		sections = append(sections, mutedStyle.Width(m.width-4).Render(
+			"The key is checked with a request that uses no tokens, then saved to "+credentials+", which only you can read."))
+	}
+
+	sections = append(sections, "", helpStyle.Render(m.help.View(m.keyMap)))
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
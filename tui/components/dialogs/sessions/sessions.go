@@ -0,0 +1,304 @@
+package sessions
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
+	"github.com/krishkalaria12/nyron-ai-cli/tui/components/dialogs"
+)
+
+const (
+	defaultWidth  = 80
+	defaultHeight = 20
+)
+
+var (
+	primaryColor   = lipgloss.Color("#6366f1")
+	secondaryColor = lipgloss.Color("#8b5cf6")
+	errorColor     = lipgloss.Color("#ef4444")
+	textMuted      = lipgloss.Color("#9ca3af")
+)
+
+var (
+	titleStyle        = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).Padding(0, 1)
+	itemStyle         = lipgloss.NewStyle().PaddingLeft(4).Foreground(lipgloss.Color("#FFFFFF"))
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(secondaryColor).Bold(true)
+	detailStyle       = lipgloss.NewStyle().PaddingLeft(6).Foreground(textMuted)
+	promptStyle       = lipgloss.NewStyle().Foreground(primaryColor).Bold(true).PaddingLeft(1)
+	errorStyle        = lipgloss.NewStyle().Foreground(errorColor).PaddingLeft(1)
+	helpStyle         = lipgloss.NewStyle().Foreground(textMuted).PaddingLeft(1)
+)
+
+// SessionSelectedMsg is sent when the user picks a session to resume
+type SessionSelectedMsg struct {
+	ID string
+}
+
+// SessionRenamedMsg is sent after a session got a new title
+type SessionRenamedMsg struct {
+	ID    string
+	Title string
+}
+
+// SessionDeletedMsg is sent after a session was deleted
+type SessionDeletedMsg struct {
+	ID string
+}
+
+// CloseSessionDialog is sent when the dialog is dismissed
+type CloseSessionDialog struct{}
+
+// SessionDialog interface for the session browser dialog
+type SessionDialog interface {
+	dialogs.DialogModel
+}
+
+type SessionItem struct {
+	summary session.Summary
+	current bool
+}
+
+func (i SessionItem) FilterValue() string { return i.summary.Title + " " + i.summary.Model }
+func (i SessionItem) Title() string       { return i.summary.Title }
+func (i SessionItem) Description() string {
+	description := fmt.Sprintf("%s · %d messages · %s", i.summary.Model, i.summary.Messages, relativeTime(i.summary.UpdatedAt))
+	if i.current {
+		description += " · current"
+	}
+	return description
+}
+
+// mode is what the keyboard currently drives
+type mode int
+
+const (
+	modeBrowse mode = iota
+	modeRename
+	modeConfirmDelete
+)
+
+type SessionDialogComponent struct {
+	store     *session.Store
+	currentID string
+	list      list.Model
+	mode      mode
+	title     textinput.Model
+	err       error
+	width     int
+	keyMap    KeyMap
+	help      help.Model
+}
+
+// NewSessionDialogComponent lists the sessions in store; currentID marks the session that is open
+func NewSessionDialogComponent(store *session.Store, currentID string) SessionDialogComponent {
+	l := list.New(nil, itemDelegate{}, defaultWidth, defaultHeight)
+	l.Title = "Sessions"
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.SetShowPagination(false)
+	l.Styles.Title = titleStyle
+	// Esc and q close the dialog instead of quitting the program
+	l.KeyMap.Quit.SetEnabled(false)
+	l.KeyMap.ForceQuit.SetEnabled(false)
+
+	title := textinput.New()
+	title.Placeholder = "Session title"
+	title.CharLimit = 120
+	title.Width = defaultWidth - 8
+
+	m := SessionDialogComponent{
+		store:     store,
+		currentID: currentID,
+		list:      l,
+		title:     title,
+		width:     defaultWidth,
+		keyMap:    DefaultKeyMap(),
+		help:      help.New(),
+	}
+	m.reload()
+	return m
+}
+
+// reload reads the session list from the store again
+func (m *SessionDialogComponent) reload() {
+	summaries, err := m.store.List()
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	items := make([]list.Item, 0, len(summaries))
+	for _, summary := range summaries {
+		items = append(items, SessionItem{summary: summary, current: summary.ID == m.currentID})
+	}
+	m.list.SetItems(items)
+}
+
+func (m SessionDialogComponent) Init() tea.Cmd {
+	return nil
+}
+
+func (m SessionDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(max(int(float64(msg.Width)*0.8), 60), 150)
+		m.title.Width = m.width - 8
+		m.list.SetSize(m.width, max(int(float64(msg.Height)*0.6), 10))
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.mode {
+		case modeRename:
+			return m.updateRename(msg)
+		case modeConfirmDelete:
+			return m.updateConfirmDelete(msg)
+		}
+
+		// While typing a search every key belongs to the filter
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, m.keyMap.Close):
+			if m.list.FilterState() == list.FilterApplied {
+				m.list.ResetFilter()
+				return m, nil
+			}
+			return m, func() tea.Msg { return CloseSessionDialog{} }
+		case key.Matches(msg, m.keyMap.Select):
+			if item, ok := m.list.SelectedItem().(SessionItem); ok {
+				return m, func() tea.Msg { return SessionSelectedMsg{ID: item.summary.ID} }
+			}
+			return m, nil
+		case key.Matches(msg, m.keyMap.Rename):
+			if item, ok := m.list.SelectedItem().(SessionItem); ok {
+				m.mode = modeRename
+				m.err = nil
+				m.title.SetValue(item.summary.Title)
+				m.title.CursorEnd()
+				return m, m.title.Focus()
+			}
+			return m, nil
+		case key.Matches(msg, m.keyMap.Delete):
+			if _, ok := m.list.SelectedItem().(SessionItem); ok {
+				m.mode = modeConfirmDelete
+				m.err = nil
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m SessionDialogComponent) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	item, ok := m.list.SelectedItem().(SessionItem)
+	switch {
+	case !ok || msg.String() == "esc":
+		m.mode = modeBrowse
+		m.title.Blur()
+		return m, nil
+	case msg.String() == "enter":
+		m.mode = modeBrowse
+		m.title.Blur()
+		if err := m.store.Rename(item.summary.ID, m.title.Value()); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.reload()
+		id, title := item.summary.ID, strings.TrimSpace(m.title.Value())
+		return m, func() tea.Msg { return SessionRenamedMsg{ID: id, Title: title} }
+	}
+
+	var cmd tea.Cmd
+	m.title, cmd = m.title.Update(msg)
+	return m, cmd
+}
+
+func (m SessionDialogComponent) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	item, ok := m.list.SelectedItem().(SessionItem)
+	if !ok || (msg.String() != "y" && msg.String() != "Y") {
+		return m, nil
+	}
+
+	if err := m.store.Delete(item.summary.ID); err != nil {
+		m.err = err
+		return m, nil
+	}
+	m.reload()
+	id := item.summary.ID
+	return m, func() tea.Msg { return SessionDeletedMsg{ID: id} }
+}
+
+func (m SessionDialogComponent) View() string {
+	var sections []string
+	if len(m.list.Items()) == 0 {
+		sections = append(sections, titleStyle.Render("Sessions"), "", helpStyle.Render("No saved sessions yet"))
+	} else {
+		sections = append(sections, m.list.View())
+	}
+
+	switch m.mode {
+	case modeRename:
+		sections = append(sections, "", promptStyle.Render("New title:"), "  "+m.title.View(), helpStyle.Render("enter save • esc cancel"))
+	case modeConfirmDelete:
+		if item, ok := m.list.SelectedItem().(SessionItem); ok {
+			sections = append(sections, "", promptStyle.Render(fmt.Sprintf("Delete %q? (y/n)", item.summary.Title)))
+		}
+	default:
+		sections = append(sections, "", helpStyle.Render(m.help.View(m.keyMap)))
+	}
+
+	if m.err != nil {
+		sections = append(sections, errorStyle.Render("Error: "+m.err.Error()))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+type itemDelegate struct{}
+
+func (d itemDelegate) Height() int                             { return 2 }
+func (d itemDelegate) Spacing() int                            { return 1 }
+func (d itemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, ok := listItem.(SessionItem)
+	if !ok {
+		return
+	}
+
+	title := itemStyle.Render(item.Title())
+	if index == m.Index() {
+		title = selectedItemStyle.Render("> " + item.Title())
+	}
+	fmt.Fprint(w, title+"\n"+detailStyle.Render(item.Description()))
+}
+
+// relativeTime formats a timestamp like "5m ago", falling back to the date for old sessions
+func relativeTime(t time.Time) string {
+	elapsed := time.Since(t)
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	case elapsed < 7*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+	default:
+		return t.Format("Jan 2, 2006")
+	}
+}
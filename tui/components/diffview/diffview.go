@@ -0,0 +1,74 @@
+package diffview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#10b981"))
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ef4444"))
+	hunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#06b6d4"))
+	headerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+	contextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#9ca3af"))
+)
+
+// Render colors a unified diff line by line, cut to width and to maxLines when maxLines is positive
+func Render(diff string, width int, maxLines int) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+
+	hidden := 0
+	if maxLines > 0 && len(lines) > maxLines {
+		hidden = len(lines) - maxLines
+		lines = lines[:maxLines]
+	}
+
+	rendered := make([]string, 0, len(lines)+1)
+	for _, line := range lines {
+		line = truncate(strings.ReplaceAll(line, "\t", "    "), width)
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			rendered = append(rendered, headerStyle.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			rendered = append(rendered, hunkStyle.Render(line))
+		case strings.HasPrefix(line, "+"):
+			rendered = append(rendered, addedStyle.Render(line))
+		case strings.HasPrefix(line, "-"):
+			rendered = append(rendered, removedStyle.Render(line))
+		default:
+			rendered = append(rendered, contextStyle.Render(line))
+		}
+	}
+	if hidden > 0 {
+		rendered = append(rendered, contextStyle.Render(fmt.Sprintf("… %d more lines", hidden)))
+	}
+
+	return strings.Join(rendered, "\n")
+}
+
+// Stats counts the added and removed lines of a unified diff
+func Stats(diff string) (added int, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func truncate(line string, width int) string {
+	if width <= 1 || lipgloss.Width(line) <= width {
+		return line
+	}
+	runes := []rune(line)
+	if len(runes) > width-1 {
+		runes = runes[:width-1]
+	}
+	return string(runes) + "…"
+}
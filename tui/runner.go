@@ -1,23 +1,100 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/krishkalaria12/nyron-ai-cli/ai/tools"
+	"github.com/krishkalaria12/nyron-ai-cli/catalog"
+	"github.com/krishkalaria12/nyron-ai-cli/config"
+	"github.com/krishkalaria12/nyron-ai-cli/mcp"
+	"github.com/krishkalaria12/nyron-ai-cli/session"
 	"github.com/krishkalaria12/nyron-ai-cli/tui/components/chat"
+	openrouter "github.com/revrost/go-openrouter"
 )
 
 // RunChatModel starts the main chat TUI
-func RunChatModel() {
+func RunChatModel(options Options) {
+	// The OpenRouter client logs stream events through slog, which would draw over the TUI
+	openrouter.DisableLogs()
+
+	workspace, err := tools.NewWorkspaceFromConfig()
+	if err != nil {
+		fmt.Println("Error setting up the workspace:", err)
+		os.Exit(1)
+	}
+	tools.SetWorkspace(workspace)
+
+	// The cached catalogs fill the model dialog until the chat has loaded fresh ones
+	for _, provider := range config.GetAllProviders() {
+		catalog.LoadCached(provider.ID)
+	}
+
+	// MCP servers connect in the background, their tools join once they are ready
+	servers := mcp.NewManager(config.Current().MCP)
+	mcp.SetDefault(servers)
+	servers.Start()
+
+	chatModel := chat.NewChatModel()
+	if err := restoreSession(&chatModel, options); err != nil {
+		servers.Close()
+		fmt.Println("Error resuming session:", err)
+		os.Exit(1)
+	}
+
 	p := tea.NewProgram(
-		chat.NewChatModel(),
+		chatModel,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	servers.Close()
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
+// restoreSession applies --continue and --resume to the chat before it starts
+func restoreSession(chatModel *chat.ChatModel, options Options) error {
+	if !options.Continue && !options.Resume {
+		return nil
+	}
+	if options.Resume && options.SessionID == "" {
+		// The dialog loads the sessions once the program runs
+		chatModel.RunAtStart(chatModel.OpenSessionDialog())
+		return nil
+	}
+
+	store, err := session.NewDefaultStore()
+	if err != nil {
+		return err
+	}
+
+	var resumed *session.Session
+	if options.Resume {
+		resumed, err = store.Load(options.SessionID)
+		if errors.Is(err, session.ErrNotFound) {
+			return fmt.Errorf("no session with ID %q", options.SessionID)
+		}
+	} else {
+		var dir string
+		if dir, err = os.Getwd(); err != nil {
+			return err
+		}
+		resumed, err = store.Latest(dir)
+		if errors.Is(err, session.ErrNotFound) {
+			// Nothing to continue yet, start a new session
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	chatModel.ResumeSession(resumed)
+	return nil
+}
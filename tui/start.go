@@ -1,5 +1,12 @@
 package tui
 
-func StartTUI() {
-	RunChatModel()
+// Options are the command line options that affect how the TUI starts
+type Options struct {
+	Continue  bool // Continue the most recent session of the current directory
+	Resume    bool // Resume SessionID, or open the session browser when it is empty
+	SessionID string
+}
+
+func StartTUI(options Options) {
+	RunChatModel(options)
 }